@@ -0,0 +1,168 @@
+// Package checkpoint saves and restores the full state of a training run so
+// it can be resumed later: the parameters of the model, the state of the
+// optimizer and the learning rate scheduler, the random number generator and
+// the progress counters.
+//
+// Checkpoints are stored as safetensors files, tensors are prefixed with
+// "model." or "optimizer." and everything else is kept in the metadata.
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/nn"
+	"github.com/blast-go/blast/optim"
+	"github.com/blast-go/blast/random"
+	"github.com/blast-go/blast/safetensors"
+	"github.com/blast-go/blast/tensor"
+)
+
+// Version of the checkpoint format written by Save. Checkpoints written with
+// a newer format version are rejected by Load.
+const Version = 1
+
+const (
+	modelPrefix     = "model."
+	optimizerPrefix = "optimizer."
+
+	versionKey   = "blast.version"
+	epochKey     = "blast.epoch"
+	stepKey      = "blast.step"
+	optimizerKey = "blast.optimizer"
+	schedulerKey = "blast.scheduler"
+	rngKey       = "blast.rng"
+)
+
+// Checkpoint references the objects that make up the state of a training run.
+// Only Model is required, the rest of the components are saved and restored
+// only when they are set.
+type Checkpoint[T constraints.Number] struct {
+	Model     nn.Module[T]
+	Optimizer optim.Optimizer[T]
+	Scheduler optim.Scheduler
+	RNG       *random.Source
+	Epoch     int
+	Step      int
+}
+
+// Save writes the state of all the components of the checkpoint into the file
+// located at path.
+func Save[T constraints.Number](path string, c *Checkpoint[T]) error {
+	tensors := make(map[string]*tensor.Tensor[T])
+	for name, t := range nn.StateDict(c.Model) {
+		tensors[modelPrefix+name] = t
+	}
+
+	metadata := map[string]string{
+		versionKey: strconv.Itoa(Version),
+		epochKey:   strconv.Itoa(c.Epoch),
+		stepKey:    strconv.Itoa(c.Step),
+	}
+
+	if c.Optimizer != nil {
+		state := c.Optimizer.State()
+		for name, t := range state.Tensors {
+			tensors[optimizerPrefix+name] = t
+		}
+		values, err := json.Marshal(state.Values)
+		if err != nil {
+			return err
+		}
+		metadata[optimizerKey] = string(values)
+	}
+
+	if c.Scheduler != nil {
+		values, err := json.Marshal(c.Scheduler.State())
+		if err != nil {
+			return err
+		}
+		metadata[schedulerKey] = string(values)
+	}
+
+	if c.RNG != nil {
+		metadata[rngKey] = strconv.FormatUint(c.RNG.State(), 10)
+	}
+
+	return safetensors.Save(path, tensors, metadata)
+}
+
+// Load restores the components of the checkpoint from the file located at
+// path. Returns an error if the file was written by a newer format version,
+// if the shapes of the stored tensors don't match the ones of the model or
+// if a component set in the checkpoint is missing from the file.
+func Load[T constraints.Number](path string, c *Checkpoint[T]) error {
+	tensors, metadata, err := safetensors.Load[T](path)
+	if err != nil {
+		return err
+	}
+
+	version, err := strconv.Atoi(metadata[versionKey])
+	if err != nil {
+		return fmt.Errorf("checkpoint: missing format version")
+	}
+	if version > Version {
+		return fmt.Errorf("checkpoint: unsupported format version=%d max=%d", version, Version)
+	}
+
+	model := make(map[string]*tensor.Tensor[T])
+	optimizer := optim.State[T]{Tensors: make(map[string]*tensor.Tensor[T])}
+	for name, t := range tensors {
+		switch {
+		case strings.HasPrefix(name, modelPrefix):
+			model[strings.TrimPrefix(name, modelPrefix)] = t
+		case strings.HasPrefix(name, optimizerPrefix):
+			optimizer.Tensors[strings.TrimPrefix(name, optimizerPrefix)] = t
+		}
+	}
+
+	if err := nn.LoadStateDict(c.Model, model, true); err != nil {
+		return err
+	}
+
+	if c.Optimizer != nil {
+		values, ok := metadata[optimizerKey]
+		if !ok {
+			return fmt.Errorf("checkpoint: missing optimizer state")
+		}
+		if err := json.Unmarshal([]byte(values), &optimizer.Values); err != nil {
+			return fmt.Errorf("checkpoint: invalid optimizer state: %w", err)
+		}
+		if err := c.Optimizer.LoadState(optimizer); err != nil {
+			return err
+		}
+	}
+
+	if c.Scheduler != nil {
+		values, ok := metadata[schedulerKey]
+		if !ok {
+			return fmt.Errorf("checkpoint: missing scheduler state")
+		}
+		var state map[string]float64
+		if err := json.Unmarshal([]byte(values), &state); err != nil {
+			return fmt.Errorf("checkpoint: invalid scheduler state: %w", err)
+		}
+		if err := c.Scheduler.LoadState(state); err != nil {
+			return err
+		}
+	}
+
+	if c.RNG != nil {
+		state, err := strconv.ParseUint(metadata[rngKey], 10, 64)
+		if err != nil {
+			return fmt.Errorf("checkpoint: missing random number generator state")
+		}
+		c.RNG.SetState(state)
+	}
+
+	if c.Epoch, err = strconv.Atoi(metadata[epochKey]); err != nil {
+		return fmt.Errorf("checkpoint: invalid epoch: %w", err)
+	}
+	if c.Step, err = strconv.Atoi(metadata[stepKey]); err != nil {
+		return fmt.Errorf("checkpoint: invalid step: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package checkpoint_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/blast-go/blast/checkpoint"
+	"github.com/blast-go/blast/optim"
+	"github.com/blast-go/blast/random"
+	"github.com/blast-go/blast/safetensors"
+	"github.com/blast-go/blast/tensor"
+)
+
+type model struct {
+	weight *tensor.Tensor[float32]
+}
+
+func (m *model) Parameters() map[string]*tensor.Tensor[float32] {
+	return map[string]*tensor.Tensor[float32]{"weight": m.weight}
+}
+
+func newCheckpoint(shape tensor.Shape) *checkpoint.Checkpoint[float32] {
+	m := &model{weight: tensor.Rand[float32](shape)}
+	o := optim.NewAdam(m.Parameters(), 0.1)
+	return &checkpoint.Checkpoint[float32]{
+		Model:     m,
+		Optimizer: o,
+		Scheduler: optim.NewStepLR[float32](o, 1, 0.5),
+		RNG:       random.NewSource(0),
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.safetensors")
+
+	c1 := newCheckpoint(tensor.Shape{2, 2})
+	for i := range c1.Model.Parameters()["weight"].Grad() {
+		c1.Model.Parameters()["weight"].Grad()[i] = 1
+	}
+	c1.Optimizer.Step()
+	c1.Scheduler.Step()
+	c1.RNG.Uint64()
+	c1.Epoch = 3
+	c1.Step = 120
+
+	if err := checkpoint.Save(path, c1); err != nil {
+		t.Fatalf("%s: save failed: %v", t.Name(), err)
+	}
+
+	c2 := newCheckpoint(tensor.Shape{2, 2})
+	if err := checkpoint.Load(path, c2); err != nil {
+		t.Fatalf("%s: load failed: %v", t.Name(), err)
+	}
+
+	if !tensor.Equal(c1.Model.Parameters()["weight"], c2.Model.Parameters()["weight"]) {
+		t.Errorf("%s: model not restored", t.Name())
+	}
+	if c2.Optimizer.LR() != 0.05 {
+		t.Errorf("%s: learning rate not restored got=%f", t.Name(), c2.Optimizer.LR())
+	}
+	if !tensor.Equal(c1.Optimizer.State().Tensors["m.weight"], c2.Optimizer.State().Tensors["m.weight"]) {
+		t.Errorf("%s: optimizer not restored", t.Name())
+	}
+	if c1.RNG.Uint64() != c2.RNG.Uint64() {
+		t.Errorf("%s: random number generator not restored", t.Name())
+	}
+	if c2.Epoch != 3 || c2.Step != 120 {
+		t.Errorf("%s: counters not restored epoch=%d step=%d", t.Name(), c2.Epoch, c2.Step)
+	}
+}
+
+func TestLoadIncompatible(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.safetensors")
+
+	if err := checkpoint.Save(path, newCheckpoint(tensor.Shape{2, 2})); err != nil {
+		t.Fatalf("%s: save failed: %v", t.Name(), err)
+	}
+	if err := checkpoint.Load(path, newCheckpoint(tensor.Shape{3, 2})); err == nil {
+		t.Errorf("%s: should fail due to incompatible shapes", t.Name())
+	}
+
+	m := &model{weight: tensor.Rand[float32](tensor.Shape{2, 2})}
+	metadata := map[string]string{"blast.version": "2", "blast.epoch": "0", "blast.step": "0"}
+	if err := safetensors.Save(path, map[string]*tensor.Tensor[float32]{"model.weight": m.weight}, metadata); err != nil {
+		t.Fatalf("%s: save failed: %v", t.Name(), err)
+	}
+	if err := checkpoint.Load(path, &checkpoint.Checkpoint[float32]{Model: m}); err == nil {
+		t.Errorf("%s: should fail due to newer version", t.Name())
+	}
+}
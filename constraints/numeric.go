@@ -1,5 +1,33 @@
 package constraints
 
+import "github.com/blast-go/blast/half"
+
 type Number interface {
 	~float32 | ~float64 | ~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
 }
+
+type Float interface {
+	~float32 | ~float64
+}
+
+type Complex interface {
+	~complex64 | ~complex128
+}
+
+// Element is the constraint of the elements of a tensor that devices compute
+// on, numbers or complex numbers.
+type Element interface {
+	Number | Complex
+}
+
+// Half is the constraint of the half precision types, which tensors store but
+// devices don't compute on.
+type Half interface {
+	half.Float16 | half.BFloat16
+}
+
+// Storage is the constraint of the elements tensors can store. Only Element
+// types are computed on, the half precision types are converted first.
+type Storage interface {
+	Element | Half
+}
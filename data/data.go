@@ -0,0 +1,95 @@
+// Package data provides the abstractions used to feed samples to a model
+// during training: datasets and loaders that group samples into batches.
+package data
+
+import (
+	"fmt"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/tensor"
+)
+
+// Dataset is a collection of samples that can be accessed by index. Each
+// sample is made of one or more tensors, for example the input of a model
+// and its expected output.
+type Dataset[T constraints.Number] interface {
+	// Len returns the number of samples of the dataset.
+	Len() int
+	// Get returns the tensors of the sample at index i.
+	Get(i int) []*tensor.Tensor[T]
+}
+
+// TensorDataset is a dataset in memory where every sample is a slice along
+// the outermost dimension of the tensors.
+type TensorDataset[T constraints.Number] struct {
+	tensors []*tensor.Tensor[T]
+	size    int
+}
+
+// NewTensorDataset returns a dataset over the given tensors. Panics if the
+// tensors do not have the same size on their outermost dimension.
+func NewTensorDataset[T constraints.Number](tensors ...*tensor.Tensor[T]) *TensorDataset[T] {
+	if len(tensors) == 0 {
+		panic("at least one tensor is required")
+	}
+
+	size := -1
+	for i, t := range tensors {
+		shape := t.Shape()
+		if len(shape) == 0 {
+			panic(fmt.Sprintf("tensor %d has no dimensions", i))
+		}
+		n := int(shape[len(shape)-1])
+		if size != -1 && n != size {
+			panic(fmt.Sprintf("tensors have different number of samples expected=%d got=%d", size, n))
+		}
+		size = n
+		// materialize the elements so samples can be read concurrently
+		t.Elements()
+	}
+
+	return &TensorDataset[T]{tensors: tensors, size: size}
+}
+
+// Len returns the number of samples of the dataset.
+func (d *TensorDataset[T]) Len() int {
+	return d.size
+}
+
+// Get returns the tensors of the sample at index i.
+func (d *TensorDataset[T]) Get(i int) []*tensor.Tensor[T] {
+	if i < 0 || i >= d.size {
+		panic(fmt.Sprintf("index out of bounds %d for size %d", i, d.size))
+	}
+
+	sample := make([]*tensor.Tensor[T], len(d.tensors))
+	for j, t := range d.tensors {
+		shape := make(tensor.Shape, len(t.Shape())-1)
+		copy(shape, t.Shape())
+		stride := len(t.Elements()) / d.size
+		elements := make([]T, stride)
+		copy(elements, t.Elements()[i*stride:(i+1)*stride])
+		sample[j] = tensor.New(shape, elements)
+	}
+	return sample
+}
+
+// stack joins tensors with the same shape into a new tensor with an extra
+// outermost dimension.
+func stack[T constraints.Number](tensors []*tensor.Tensor[T]) *tensor.Tensor[T] {
+	first := tensors[0]
+	shape := make(tensor.Shape, len(first.Shape()), len(first.Shape())+1)
+	copy(shape, first.Shape())
+	shape = append(shape, uint(len(tensors)))
+
+	size := len(first.Elements())
+	elements := make([]T, 0, size*len(tensors))
+	for _, t := range tensors {
+		if !tensor.EqualShape(first, t) {
+			panic(fmt.Sprintf("samples must have the same shape expected=%v got=%v", first.Shape(), t.Shape()))
+		}
+		elements = append(elements, t.Elements()...)
+	}
+
+	return tensor.New(shape, elements)
+}
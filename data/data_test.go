@@ -0,0 +1,35 @@
+package data_test
+
+import (
+	"testing"
+
+	"github.com/blast-go/blast/data"
+	"github.com/blast-go/blast/tensor"
+)
+
+func TestTensorDataset(t *testing.T) {
+	x := tensor.New(tensor.Shape{2, 3}, []int{1, 2, 3, 4, 5, 6})
+	y := tensor.New(tensor.Shape{3}, []int{7, 8, 9})
+	d := data.NewTensorDataset(x, y)
+
+	if d.Len() != 3 {
+		t.Errorf("%s: invalid length expected=3 got=%d", t.Name(), d.Len())
+	}
+
+	sample := d.Get(1)
+	if !tensor.Equal(sample[0], tensor.New(tensor.Shape{2}, []int{3, 4})) {
+		t.Errorf("%s: invalid input got=%v", t.Name(), sample[0])
+	}
+	if !tensor.Equal(sample[1], tensor.New(tensor.Shape{}, []int{8})) {
+		t.Errorf("%s: invalid target got=%v", t.Name(), sample[1])
+	}
+}
+
+func TestTensorDatasetInvalid(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("%s: should have failed due to different number of samples", t.Name())
+		}
+	}()
+	data.NewTensorDataset(tensor.Zeros[int](tensor.Shape{2, 3}), tensor.Zeros[int](tensor.Shape{2}))
+}
@@ -0,0 +1,198 @@
+// Package idx reads and writes tensors stored in the IDX file format used by
+// the MNIST dataset. Files compressed with gzip are decompressed
+// transparently.
+//
+// IDX files store dimensions with the outermost first while blast stores them
+// with the innermost first, so a file of 60000 images of 28x28 pixels is read
+// as a tensor with shape {28, 28, 60000}.
+package idx
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/tensor"
+)
+
+// DType identifies the type of the elements stored in an IDX file.
+type DType byte
+
+const (
+	UByte  DType = 0x08
+	SByte  DType = 0x09
+	Short  DType = 0x0B
+	Int    DType = 0x0C
+	Float  DType = 0x0D
+	Double DType = 0x0E
+)
+
+// Size returns the number of bytes used by a single element of the type.
+// Returns zero for unknown types.
+func (d DType) Size() int {
+	switch d {
+	case UByte, SByte:
+		return 1
+	case Short:
+		return 2
+	case Int, Float:
+		return 4
+	case Double:
+		return 8
+	default:
+		return 0
+	}
+}
+
+// Read decodes an IDX file from r converting its elements to the numeric type
+// T.
+func Read[T constraints.Number](r io.Reader) (*tensor.Tensor[T], error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil {
+		return nil, fmt.Errorf("idx: %w", err)
+	}
+	if magic[0] == 0x1f && magic[1] == 0x8b {
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("idx: %w", err)
+		}
+		defer gr.Close()
+		br = bufio.NewReader(gr)
+	}
+
+	var header [4]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return nil, fmt.Errorf("idx: can't read header: %w", err)
+	}
+	if header[0] != 0 || header[1] != 0 {
+		return nil, fmt.Errorf("idx: invalid magic number %x", header)
+	}
+
+	dtype := DType(header[2])
+	if dtype.Size() == 0 {
+		return nil, fmt.Errorf("idx: unknown dtype %#x", header[2])
+	}
+
+	ndims := int(header[3])
+	dims := make([]uint32, ndims)
+	if err := binary.Read(br, binary.BigEndian, dims); err != nil {
+		return nil, fmt.Errorf("idx: can't read dimensions: %w", err)
+	}
+
+	// the number of bytes is bounded at each step so the product can't
+	// overflow
+	limit := math.MaxInt / dtype.Size()
+	shape := make(tensor.Shape, ndims)
+	size := 1
+	for i, d := range dims {
+		if d == 0 {
+			return nil, fmt.Errorf("idx: dimension %d can't be zero", i)
+		}
+		if uint64(d) > uint64(limit/size) {
+			return nil, fmt.Errorf("idx: too many elements in dimensions %v", dims)
+		}
+		shape[ndims-1-i] = uint(d)
+		size *= int(d)
+	}
+
+	// the elements are read as they come instead of allocating the size of
+	// the header, which may be larger than the stream
+	n := size * dtype.Size()
+	buf, err := io.ReadAll(io.LimitReader(br, int64(n)))
+	if err != nil {
+		return nil, fmt.Errorf("idx: can't read elements: %w", err)
+	}
+	if len(buf) != n {
+		return nil, fmt.Errorf("idx: can't read elements: %w", io.ErrUnexpectedEOF)
+	}
+
+	return tensor.New(shape, decode[T](dtype, buf)), nil
+}
+
+// Load reads the IDX file located at path.
+func Load[T constraints.Number](path string) (*tensor.Tensor[T], error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read[T](f)
+}
+
+// Write encodes the tensor into w in the IDX format storing its elements
+// with the given dtype. Elements that don't fit in dtype are truncated.
+func Write[T constraints.Number](w io.Writer, t *tensor.Tensor[T], dtype DType) error {
+	if dtype.Size() == 0 {
+		return fmt.Errorf("idx: unknown dtype %#x", byte(dtype))
+	}
+
+	shape := t.Shape()
+	if len(shape) > math.MaxUint8 {
+		return fmt.Errorf("idx: too many dimensions %d", len(shape))
+	}
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write([]byte{0, 0, byte(dtype), byte(len(shape))}); err != nil {
+		return err
+	}
+	for i := len(shape) - 1; i >= 0; i-- {
+		if err := binary.Write(bw, binary.BigEndian, uint32(shape[i])); err != nil {
+			return err
+		}
+	}
+	if _, err := bw.Write(encode(dtype, t.Elements())); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func decode[T constraints.Number](dtype DType, b []byte) []T {
+	be := binary.BigEndian
+	elements := make([]T, len(b)/dtype.Size())
+	for i := range elements {
+		switch dtype {
+		case UByte:
+			elements[i] = T(b[i])
+		case SByte:
+			elements[i] = T(int8(b[i]))
+		case Short:
+			elements[i] = T(int16(be.Uint16(b[2*i:])))
+		case Int:
+			elements[i] = T(int32(be.Uint32(b[4*i:])))
+		case Float:
+			elements[i] = T(math.Float32frombits(be.Uint32(b[4*i:])))
+		case Double:
+			elements[i] = T(math.Float64frombits(be.Uint64(b[8*i:])))
+		}
+	}
+	return elements
+}
+
+func encode[T constraints.Number](dtype DType, elements []T) []byte {
+	be := binary.BigEndian
+	b := make([]byte, len(elements)*dtype.Size())
+	for i, e := range elements {
+		switch dtype {
+		case UByte:
+			b[i] = uint8(e)
+		case SByte:
+			b[i] = uint8(int8(e))
+		case Short:
+			be.PutUint16(b[2*i:], uint16(int16(e)))
+		case Int:
+			be.PutUint32(b[4*i:], uint32(int32(e)))
+		case Float:
+			be.PutUint32(b[4*i:], math.Float32bits(float32(e)))
+		case Double:
+			be.PutUint64(b[8*i:], math.Float64bits(float64(e)))
+		}
+	}
+	return b
+}
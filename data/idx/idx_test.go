@@ -0,0 +1,90 @@
+package idx_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/blast-go/blast/data/idx"
+	"github.com/blast-go/blast/tensor"
+)
+
+func TestRead(t *testing.T) {
+	// 2 images of 2x3 pixels
+	b := []byte{
+		0, 0, 0x08, 3,
+		0, 0, 0, 2,
+		0, 0, 0, 2,
+		0, 0, 0, 3,
+		1, 2, 3, 4, 5, 6,
+		7, 8, 9, 10, 11, 255,
+	}
+
+	actual, err := idx.Read[float32](bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("%s: read failed: %v", t.Name(), err)
+	}
+
+	expected := tensor.New(tensor.Shape{3, 2, 2}, []float32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 255})
+	if !tensor.Equal(actual, expected) {
+		t.Errorf("%s: expected=%v got=%v", t.Name(), expected, actual)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	inputs := [][]byte{
+		{1, 0, 0x08, 1, 0, 0, 0, 1, 0},
+		{0, 0, 0x0A, 1, 0, 0, 0, 1, 0},
+		{0, 0, 0x08, 1, 0, 0, 0, 2, 0},
+		{0, 0, 0x08, 1, 0, 0, 0, 0},
+		// dimensions overflowing the number of elements
+		{0, 0, 0x0D, 4, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0},
+		// 2^32-1 elements in a stream of one byte
+		{0, 0, 0x08, 1, 0xff, 0xff, 0xff, 0xff, 0},
+	}
+
+	for _, b := range inputs {
+		if _, err := idx.Read[uint8](bytes.NewReader(b)); err == nil {
+			t.Errorf("%s: should fail to read %v", t.Name(), b)
+		}
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	dtypes := []idx.DType{idx.UByte, idx.SByte, idx.Short, idx.Int, idx.Float, idx.Double}
+	expected := tensor.New(tensor.Shape{2, 3}, []int32{1, 2, 3, 4, 5, 120})
+
+	for _, dtype := range dtypes {
+		var buf bytes.Buffer
+		if err := idx.Write(&buf, expected, dtype); err != nil {
+			t.Fatalf("%s: write failed: %v", t.Name(), err)
+		}
+
+		actual, err := idx.Read[int32](&buf)
+		if err != nil {
+			t.Fatalf("%s: read failed: %v", t.Name(), err)
+		}
+		if !tensor.Equal(actual, expected) {
+			t.Errorf("%s: dtype %#x expected=%v got=%v", t.Name(), byte(dtype), expected, actual)
+		}
+	}
+}
+
+func TestReadGzip(t *testing.T) {
+	expected := tensor.New(tensor.Shape{4}, []float64{0.5, -1, 2, 3e10})
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if err := idx.Write(gw, expected, idx.Double); err != nil {
+		t.Fatalf("%s: write failed: %v", t.Name(), err)
+	}
+	gw.Close()
+
+	actual, err := idx.Read[float64](&buf)
+	if err != nil {
+		t.Fatalf("%s: read failed: %v", t.Name(), err)
+	}
+	if !tensor.Equal(actual, expected) {
+		t.Errorf("%s: expected=%v got=%v", t.Name(), expected, actual)
+	}
+}
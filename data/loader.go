@@ -0,0 +1,231 @@
+package data
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/random"
+	"github.com/blast-go/blast/tensor"
+)
+
+type option func(*options)
+
+type options struct {
+	batchSize int
+	shuffle   bool
+	seed      int64
+	dropLast  bool
+	prefetch  int
+	workers   int
+}
+
+// WithBatchSize sets the number of samples in every batch.
+func WithBatchSize(v int) option {
+	return func(o *options) {
+		o.batchSize = v
+	}
+}
+
+// WithShuffle enables shuffling the samples on every epoch using a random
+// number generator initialized with seed.
+func WithShuffle(seed int64) option {
+	return func(o *options) {
+		o.shuffle = true
+		o.seed = seed
+	}
+}
+
+// WithDropLast drops the last batch of an epoch when it has fewer samples
+// than the batch size.
+func WithDropLast(v bool) option {
+	return func(o *options) {
+		o.dropLast = v
+	}
+}
+
+// WithPrefetch sets the number of batches prepared in advance.
+func WithPrefetch(v int) option {
+	return func(o *options) {
+		o.prefetch = v
+	}
+}
+
+// WithWorkers sets the number of goroutines used to prepare batches.
+func WithWorkers(v int) option {
+	return func(o *options) {
+		o.workers = v
+	}
+}
+
+// Loader iterates over a dataset in batches. The tensors of the samples of a
+// batch are stacked into tensors with an extra outermost dimension of the
+// size of the batch.
+type Loader[T constraints.Number] struct {
+	dataset Dataset[T]
+	cfg     options
+	rand    *rand.Rand
+	src     *random.Source
+}
+
+// NewLoader returns a new Loader for the dataset. By default batches contain
+// a single sample, samples are not shuffled and two batches are prepared in
+// advance by a single goroutine.
+func NewLoader[T constraints.Number](dataset Dataset[T], opts ...option) *Loader[T] {
+	cfg := options{batchSize: 1, prefetch: 2, workers: 1}
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	if cfg.batchSize <= 0 {
+		panic(fmt.Sprintf("invalid batch size %d", cfg.batchSize))
+	}
+	if cfg.prefetch <= 0 || cfg.workers <= 0 {
+		panic("prefetch and workers must be positive")
+	}
+
+	l := &Loader[T]{dataset: dataset, cfg: cfg}
+	if cfg.shuffle {
+		l.rand, l.src = random.New(cfg.seed)
+	}
+	return l
+}
+
+// Len returns the number of batches in an epoch.
+func (l *Loader[T]) Len() int {
+	n := l.dataset.Len()
+	if l.cfg.dropLast {
+		return n / l.cfg.batchSize
+	}
+	return (n + l.cfg.batchSize - 1) / l.cfg.batchSize
+}
+
+// RNG returns the source of random numbers used to shuffle the samples, so
+// its state can be saved in a checkpoint. Returns nil if shuffling is
+// disabled.
+func (l *Loader[T]) RNG() *random.Source {
+	return l.src
+}
+
+// Iter starts a new epoch and returns an iterator over its batches.
+func (l *Loader[T]) Iter() *Iterator[T] {
+	n := l.dataset.Len()
+	var indices []int
+	if l.rand != nil {
+		indices = l.rand.Perm(n)
+	} else {
+		indices = make([]int, n)
+		for i := range indices {
+			indices[i] = i
+		}
+	}
+
+	it := &Iterator[T]{
+		pending: make(chan chan result[T], l.cfg.prefetch),
+		done:    make(chan struct{}),
+	}
+
+	jobs := make(chan job[T])
+	for w := 0; w < l.cfg.workers; w++ {
+		go func() {
+			for j := range jobs {
+				j.out <- l.load(j.indices)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		defer close(it.pending)
+		for b := 0; b < l.Len(); b++ {
+			end := (b + 1) * l.cfg.batchSize
+			if end > n {
+				end = n
+			}
+			j := job[T]{indices: indices[b*l.cfg.batchSize : end], out: make(chan result[T], 1)}
+
+			select {
+			case it.pending <- j.out:
+			case <-it.done:
+				return
+			}
+			select {
+			case jobs <- j:
+			case <-it.done:
+				return
+			}
+		}
+	}()
+
+	return it
+}
+
+func (l *Loader[T]) load(indices []int) (r result[T]) {
+	defer func() {
+		if err := recover(); err != nil {
+			r.err = err
+		}
+	}()
+
+	var fields [][]*tensor.Tensor[T]
+	for _, i := range indices {
+		sample := l.dataset.Get(i)
+		if fields == nil {
+			fields = make([][]*tensor.Tensor[T], len(sample))
+		}
+		if len(sample) != len(fields) {
+			panic(fmt.Sprintf("samples must have the same number of tensors expected=%d got=%d", len(fields), len(sample)))
+		}
+		for f, t := range sample {
+			fields[f] = append(fields[f], t)
+		}
+	}
+
+	r.batch = make([]*tensor.Tensor[T], len(fields))
+	for f, tensors := range fields {
+		r.batch[f] = stack(tensors)
+	}
+	return r
+}
+
+type job[T constraints.Number] struct {
+	indices []int
+	out     chan result[T]
+}
+
+type result[T constraints.Number] struct {
+	batch []*tensor.Tensor[T]
+	err   any
+}
+
+// Iterator returns the batches of an epoch in order.
+type Iterator[T constraints.Number] struct {
+	pending chan chan result[T]
+	done    chan struct{}
+	once    sync.Once
+}
+
+// Next returns the next batch and true, or false when the epoch is over. It
+// panics if the dataset panicked while preparing the batch.
+func (it *Iterator[T]) Next() ([]*tensor.Tensor[T], bool) {
+	out, ok := <-it.pending
+	if !ok {
+		return nil, false
+	}
+
+	r := <-out
+	if r.err != nil {
+		it.Close()
+		panic(r.err)
+	}
+	return r.batch, true
+}
+
+// Close stops preparing batches, it must be called when the iteration is
+// stopped before the end of the epoch.
+func (it *Iterator[T]) Close() {
+	it.once.Do(func() {
+		close(it.done)
+	})
+}
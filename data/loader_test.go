@@ -0,0 +1,91 @@
+package data_test
+
+import (
+	"testing"
+
+	"github.com/blast-go/blast/data"
+	"github.com/blast-go/blast/tensor"
+)
+
+func newDataset(n int) *data.TensorDataset[int] {
+	x := make([]int, 2*n)
+	y := make([]int, n)
+	for i := 0; i < n; i++ {
+		x[2*i] = i
+		x[2*i+1] = -i
+		y[i] = i
+	}
+	return data.NewTensorDataset(
+		tensor.New(tensor.Shape{2, uint(n)}, x),
+		tensor.New(tensor.Shape{uint(n)}, y),
+	)
+}
+
+func collect(l *data.Loader[int]) [][]*tensor.Tensor[int] {
+	var batches [][]*tensor.Tensor[int]
+	it := l.Iter()
+	for batch, ok := it.Next(); ok; batch, ok = it.Next() {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+func TestLoader(t *testing.T) {
+	l := data.NewLoader[int](newDataset(5), data.WithBatchSize(2), data.WithWorkers(3))
+	batches := collect(l)
+
+	if len(batches) != 3 || l.Len() != 3 {
+		t.Fatalf("%s: invalid number of batches expected=3 got=%d", t.Name(), len(batches))
+	}
+
+	expected := tensor.New(tensor.Shape{2, 2}, []int{2, -2, 3, -3})
+	if !tensor.Equal(batches[1][0], expected) {
+		t.Errorf("%s: expected=%v got=%v", t.Name(), expected, batches[1][0])
+	}
+
+	expected = tensor.New(tensor.Shape{1}, []int{4})
+	if !tensor.Equal(batches[2][1], expected) {
+		t.Errorf("%s: expected=%v got=%v", t.Name(), expected, batches[2][1])
+	}
+}
+
+func TestLoaderDropLast(t *testing.T) {
+	l := data.NewLoader[int](newDataset(5), data.WithBatchSize(2), data.WithDropLast(true))
+	if batches := collect(l); len(batches) != 2 || l.Len() != 2 {
+		t.Errorf("%s: invalid number of batches expected=2 got=%d", t.Name(), len(batches))
+	}
+}
+
+func TestLoaderShuffle(t *testing.T) {
+	l1 := data.NewLoader[int](newDataset(20), data.WithBatchSize(20), data.WithShuffle(1))
+	l2 := data.NewLoader[int](newDataset(20), data.WithBatchSize(20), data.WithShuffle(1))
+
+	b1 := collect(l1)[0][1]
+	b2 := collect(l2)[0][1]
+	if !tensor.Equal(b1, b2) {
+		t.Errorf("%s: same seed should produce the same order", t.Name())
+	}
+
+	seen := make(map[int]bool)
+	sorted := true
+	for i, e := range b1.Elements() {
+		seen[e] = true
+		if e != i {
+			sorted = false
+		}
+	}
+	if len(seen) != 20 || sorted {
+		t.Errorf("%s: samples not shuffled got=%v", t.Name(), b1)
+	}
+
+	if next := collect(l1)[0][1]; tensor.Equal(b1, next) {
+		t.Errorf("%s: every epoch should be shuffled differently", t.Name())
+	}
+}
+
+func TestLoaderClose(t *testing.T) {
+	l := data.NewLoader[int](newDataset(10), data.WithBatchSize(1), data.WithPrefetch(1))
+	it := l.Iter()
+	it.Next()
+	it.Close()
+}
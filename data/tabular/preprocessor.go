@@ -0,0 +1,292 @@
+package tabular
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/tensor"
+)
+
+// Stats are the values computed for a column while fitting.
+type Stats struct {
+	Mean       float64  `json:"mean"`
+	Std        float64  `json:"std"`
+	Categories []string `json:"categories,omitempty"`
+	Mode       string   `json:"mode,omitempty"`
+}
+
+// Preprocessor encodes tables into tensors using a schema and the statistics
+// computed from the table it was fitted to.
+type Preprocessor struct {
+	Schema Schema           `json:"schema"`
+	Stats  map[string]Stats `json:"stats"`
+}
+
+// Fit computes the statistics of the columns of the schema over the table.
+// Missing values are ignored when computing the statistics.
+func Fit(schema Schema, table *Table) (*Preprocessor, error) {
+	for _, l := range schema.Labels {
+		found := false
+		for _, c := range schema.Columns {
+			found = found || c.Name == l
+		}
+		if !found {
+			return nil, fmt.Errorf("tabular: label %q is not a column of the schema", l)
+		}
+	}
+
+	p := &Preprocessor{Schema: schema, Stats: make(map[string]Stats)}
+	for _, c := range schema.Columns {
+		i, err := table.column(c.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		var stats Stats
+		switch c.Kind {
+		case Numeric:
+			var sum, sumSq float64
+			n := 0
+			for row, r := range table.Records {
+				if schema.isMissing(r[i]) {
+					continue
+				}
+				v, err := parse(r[i])
+				if err != nil {
+					return nil, fmt.Errorf("tabular: row %d column %q: %w", row, c.Name, err)
+				}
+				sum += v
+				sumSq += v * v
+				n++
+			}
+			if n > 0 {
+				stats.Mean = sum / float64(n)
+				stats.Std = math.Sqrt(math.Max(sumSq/float64(n)-stats.Mean*stats.Mean, 0))
+			}
+		case Categorical, Ordinal:
+			counts := make(map[string]int)
+			for _, r := range table.Records {
+				if !schema.isMissing(r[i]) {
+					counts[strings.TrimSpace(r[i])]++
+				}
+			}
+			for v, count := range counts {
+				stats.Categories = append(stats.Categories, v)
+				if count > counts[stats.Mode] || (count == counts[stats.Mode] && v < stats.Mode) {
+					stats.Mode = v
+				}
+			}
+			sort.Strings(stats.Categories)
+		default:
+			return nil, fmt.Errorf("tabular: column %q has unknown kind %d", c.Name, c.Kind)
+		}
+		p.Stats[c.Name] = stats
+	}
+	return p, nil
+}
+
+// width returns the number of features used to encode the column.
+func (p *Preprocessor) width(c Column) int {
+	if c.Kind == Categorical {
+		return len(p.Stats[c.Name].Categories)
+	}
+	return 1
+}
+
+// encode writes the encoded value v of column c into out. The rows with
+// missing values in DropRow columns are dropped before they are encoded.
+func (p *Preprocessor) encode(c Column, v string, out []float64) error {
+	stats := p.Stats[c.Name]
+	if p.Schema.isMissing(v) {
+		switch c.Missing {
+		case Fail:
+			return fmt.Errorf("missing value")
+		case FillMean:
+			if c.Kind == Numeric {
+				p.encodeNumeric(c, stats.Mean, out)
+				return nil
+			}
+			v = stats.Mode
+		case FillValue:
+			if c.Kind == Numeric {
+				p.encodeNumeric(c, c.Fill, out)
+				return nil
+			}
+			for i := range out {
+				out[i] = 0
+			}
+			return nil
+		}
+	}
+
+	switch c.Kind {
+	case Numeric:
+		f, err := parse(v)
+		if err != nil {
+			return err
+		}
+		p.encodeNumeric(c, f, out)
+	case Categorical:
+		v = strings.TrimSpace(v)
+		for i, category := range stats.Categories {
+			if category == v {
+				out[i] = 1
+			} else {
+				out[i] = 0
+			}
+		}
+	case Ordinal:
+		v = strings.TrimSpace(v)
+		i := sort.SearchStrings(stats.Categories, v)
+		if i == len(stats.Categories) || stats.Categories[i] != v {
+			return fmt.Errorf("unknown category %q", v)
+		}
+		out[0] = float64(i)
+	}
+	return nil
+}
+
+func (p *Preprocessor) encodeNumeric(c Column, v float64, out []float64) {
+	if c.Standardize {
+		stats := p.Stats[c.Name]
+		std := stats.Std
+		if std == 0 {
+			std = 1
+		}
+		v = (v - stats.Mean) / std
+	}
+	out[0] = v
+}
+
+// Features returns the names of the encoded features in order, one-hot
+// encoded columns produce a feature named column=category per category.
+func (p *Preprocessor) Features() []string {
+	var names []string
+	for _, c := range p.Schema.Columns {
+		if p.Schema.isLabel(c.Name) {
+			continue
+		}
+		if c.Kind == Categorical {
+			for _, category := range p.Stats[c.Name].Categories {
+				names = append(names, c.Name+"="+category)
+			}
+		} else {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// Transform encodes the table into a features tensor with shape {features,
+// rows} and a labels tensor with shape {labels, rows}. Labels is nil when
+// the schema has no labels or the table doesn't contain them, which is
+// usually the case at inference.
+func Transform[T constraints.Number](p *Preprocessor, table *Table) (features, labels *tensor.Tensor[T], err error) {
+	type encoder struct {
+		column Column
+		index  int
+		label  bool
+	}
+
+	withLabels := len(p.Schema.Labels) > 0
+	for _, l := range p.Schema.Labels {
+		if _, err := table.column(l); err != nil {
+			withLabels = false
+		}
+	}
+
+	var encoders []encoder
+	featureWidth, labelWidth := 0, 0
+	for _, c := range p.Schema.Columns {
+		label := p.Schema.isLabel(c.Name)
+		if label && !withLabels {
+			continue
+		}
+		i, err := table.column(c.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		encoders = append(encoders, encoder{column: c, index: i, label: label})
+		if label {
+			labelWidth += p.width(c)
+		} else {
+			featureWidth += p.width(c)
+		}
+	}
+
+	var featureElements, labelElements []T
+	featureRow := make([]float64, featureWidth)
+	labelRow := make([]float64, labelWidth)
+	rows := 0
+records:
+	for r, record := range table.Records {
+		// the row is dropped before any column is encoded, so a missing value
+		// in a Fail column doesn't depend on the order of the columns
+		for _, e := range encoders {
+			if e.column.Missing == DropRow && p.Schema.isMissing(record[e.index]) {
+				continue records
+			}
+		}
+
+		featureOffset, labelOffset := 0, 0
+		for _, e := range encoders {
+			w := p.width(e.column)
+			var out []float64
+			if e.label {
+				out = labelRow[labelOffset : labelOffset+w]
+				labelOffset += w
+			} else {
+				out = featureRow[featureOffset : featureOffset+w]
+				featureOffset += w
+			}
+
+			if err := p.encode(e.column, record[e.index], out); err != nil {
+				return nil, nil, fmt.Errorf("tabular: row %d column %q: %w", r, e.column.Name, err)
+			}
+		}
+
+		for _, f := range featureRow {
+			featureElements = append(featureElements, T(f))
+		}
+		for _, l := range labelRow {
+			labelElements = append(labelElements, T(l))
+		}
+		rows++
+	}
+
+	if rows == 0 || featureWidth == 0 {
+		return nil, nil, fmt.Errorf("tabular: no features or rows to encode")
+	}
+
+	features = tensor.New(tensor.Shape{uint(featureWidth), uint(rows)}, featureElements)
+	if withLabels && labelWidth > 0 {
+		labels = tensor.New(tensor.Shape{uint(labelWidth), uint(rows)}, labelElements)
+	}
+	return features, labels, nil
+}
+
+// Save writes the preprocessor into w encoded as JSON.
+func (p *Preprocessor) Save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// Load reads a preprocessor previously written with Save from r.
+func Load(r io.Reader) (*Preprocessor, error) {
+	var p Preprocessor
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return nil, fmt.Errorf("tabular: %w", err)
+	}
+	return &p, nil
+}
+
+func parse(v string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimSpace(v), 64)
+}
@@ -0,0 +1,129 @@
+// Package tabular converts tabular data like CSV files into feature and label
+// tensors. A Schema describes how every column is encoded, fitting it to a
+// training table produces a Preprocessor holding the statistics needed to
+// encode new data the same way, which can be saved and loaded at inference.
+package tabular
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Kind describes how the values of a column are encoded.
+type Kind int
+
+const (
+	// Numeric columns are parsed as floating point numbers.
+	Numeric Kind = iota
+	// Categorical columns are one-hot encoded, every distinct value seen
+	// while fitting becomes a new feature. Values not seen while fitting,
+	// like new categories in inference data, are encoded as all zeros.
+	Categorical
+	// Ordinal columns are encoded as the index of the value in the sorted
+	// list of distinct values seen while fitting, useful for class labels.
+	// Values not seen while fitting have no index and return an error.
+	Ordinal
+)
+
+// Missing is the strategy used to handle missing values in a column.
+type Missing int
+
+const (
+	// Fail returns an error when a value is missing.
+	Fail Missing = iota
+	// FillMean replaces missing values by the mean of the column, or by the
+	// most frequent value for categorical and ordinal columns.
+	FillMean
+	// FillValue replaces missing numeric values by the Fill value of the
+	// column. Missing categorical values are encoded as all zeros.
+	FillValue
+	// DropRow skips the rows with missing values, whatever the values of
+	// their other columns.
+	DropRow
+)
+
+// Column describes how a column of the table is encoded.
+type Column struct {
+	Name        string  `json:"name"`
+	Kind        Kind    `json:"kind"`
+	Missing     Missing `json:"missing"`
+	Fill        float64 `json:"fill,omitempty"`
+	Standardize bool    `json:"standardize,omitempty"`
+}
+
+// Schema lists the columns used as features and labels, columns of the table
+// not present in the schema are ignored.
+type Schema struct {
+	Columns []Column `json:"columns"`
+	// Labels are the names of the columns used as labels instead of
+	// features.
+	Labels []string `json:"labels"`
+	// MissingValues are the values considered missing, by default the empty
+	// string, "NA", "NaN", "null" and "?".
+	MissingValues []string `json:"missing_values,omitempty"`
+}
+
+var defaultMissingValues = []string{"", "NA", "NaN", "null", "?"}
+
+func (s *Schema) isMissing(v string) bool {
+	values := s.MissingValues
+	if values == nil {
+		values = defaultMissingValues
+	}
+	v = strings.TrimSpace(v)
+	for _, m := range values {
+		if v == m {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Schema) isLabel(name string) bool {
+	for _, l := range s.Labels {
+		if l == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Table holds the raw values of a table indexed by column name.
+type Table struct {
+	Header  []string
+	Records [][]string
+}
+
+// ReadCSV reads a table from r, the first record is used as header.
+func ReadCSV(r io.Reader) (*Table, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("tabular: can't read header: %w", err)
+	}
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("tabular: %w", err)
+	}
+	return &Table{Header: header, Records: records}, nil
+}
+
+// Len returns the number of rows of the table.
+func (t *Table) Len() int {
+	return len(t.Records)
+}
+
+// column returns the position of the column with the given name.
+func (t *Table) column(name string) (int, error) {
+	for i, h := range t.Header {
+		if h == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("tabular: column %q not found", name)
+}
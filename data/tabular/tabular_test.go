@@ -0,0 +1,172 @@
+package tabular_test
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/blast-go/blast/data/tabular"
+	"github.com/blast-go/blast/tensor"
+)
+
+const train = `age,color,height,class
+20,red,1.5,a
+30,blue,NA,b
+,red,2.5,a
+40,green,2.0,c
+`
+
+var schema = tabular.Schema{
+	Columns: []tabular.Column{
+		{Name: "age", Kind: tabular.Numeric, Missing: tabular.FillMean, Standardize: true},
+		{Name: "color", Kind: tabular.Categorical},
+		{Name: "height", Kind: tabular.Numeric, Missing: tabular.FillValue, Fill: -1},
+		{Name: "class", Kind: tabular.Ordinal},
+	},
+	Labels: []string{"class"},
+}
+
+func fit(t *testing.T) (*tabular.Preprocessor, *tabular.Table) {
+	table, err := tabular.ReadCSV(strings.NewReader(train))
+	if err != nil {
+		t.Fatalf("%s: read failed: %v", t.Name(), err)
+	}
+	p, err := tabular.Fit(schema, table)
+	if err != nil {
+		t.Fatalf("%s: fit failed: %v", t.Name(), err)
+	}
+	return p, table
+}
+
+func TestTransform(t *testing.T) {
+	p, table := fit(t)
+
+	features, labels, err := tabular.Transform[float64](p, table)
+	if err != nil {
+		t.Fatalf("%s: transform failed: %v", t.Name(), err)
+	}
+
+	names := p.Features()
+	expectedNames := []string{"age", "color=blue", "color=green", "color=red", "height"}
+	if strings.Join(names, ",") != strings.Join(expectedNames, ",") {
+		t.Errorf("%s: invalid features expected=%v got=%v", t.Name(), expectedNames, names)
+	}
+
+	std := math.Sqrt(200.0 / 3)
+	expected := tensor.New(tensor.Shape{5, 4}, []float64{
+		-10 / std, 0, 0, 1, 1.5,
+		0, 1, 0, 0, -1,
+		0, 0, 0, 1, 2.5,
+		10 / std, 0, 1, 0, 2,
+	})
+	for i, e := range features.Elements() {
+		if math.Abs(e-expected.Elements()[i]) > 1e-9 {
+			t.Errorf("%s: expected=%v got=%v", t.Name(), expected, features)
+			break
+		}
+	}
+
+	if !tensor.Equal(labels, tensor.New(tensor.Shape{1, 4}, []float64{0, 1, 0, 2})) {
+		t.Errorf("%s: invalid labels got=%v", t.Name(), labels)
+	}
+}
+
+func TestTransformMissing(t *testing.T) {
+	table, _ := tabular.ReadCSV(strings.NewReader("a,b\n1,2\n?,3\n4,5\n"))
+
+	s := tabular.Schema{Columns: []tabular.Column{
+		{Name: "a", Kind: tabular.Numeric, Missing: tabular.DropRow},
+		{Name: "b", Kind: tabular.Numeric},
+	}}
+	p, err := tabular.Fit(s, table)
+	if err != nil {
+		t.Fatalf("%s: fit failed: %v", t.Name(), err)
+	}
+
+	features, labels, err := tabular.Transform[float32](p, table)
+	if err != nil {
+		t.Fatalf("%s: transform failed: %v", t.Name(), err)
+	}
+	if labels != nil {
+		t.Errorf("%s: labels should be nil", t.Name())
+	}
+	if !tensor.Equal(features, tensor.New(tensor.Shape{2, 2}, []float32{1, 2, 4, 5})) {
+		t.Errorf("%s: missing rows not dropped got=%v", t.Name(), features)
+	}
+
+	s.Columns[0].Missing = tabular.Fail
+	p, _ = tabular.Fit(s, table)
+	if _, _, err := tabular.Transform[float32](p, table); err == nil {
+		t.Errorf("%s: should fail due to missing value", t.Name())
+	}
+
+	// a dropped row isn't checked for missing values in Fail columns, before
+	// or after the DropRow column
+	table, _ = tabular.ReadCSV(strings.NewReader("a,b,c\n1,2,3\n?,?,?\n"))
+	s.Columns = []tabular.Column{
+		{Name: "a", Kind: tabular.Numeric},
+		{Name: "b", Kind: tabular.Numeric, Missing: tabular.DropRow},
+		{Name: "c", Kind: tabular.Numeric},
+	}
+	p, _ = tabular.Fit(s, table)
+	features, _, err = tabular.Transform[float32](p, table)
+	if err != nil {
+		t.Fatalf("%s: transform failed: %v", t.Name(), err)
+	}
+	if !tensor.Equal(features, tensor.New(tensor.Shape{3, 1}, []float32{1, 2, 3})) {
+		t.Errorf("%s: missing row not dropped got=%v", t.Name(), features)
+	}
+}
+
+func TestTransformUnknown(t *testing.T) {
+	p, _ := fit(t)
+
+	// unseen categorical values are encoded as all zeros
+	table, _ := tabular.ReadCSV(strings.NewReader("age,color,height,class\n30,purple,1.5,a\n"))
+	features, _, err := tabular.Transform[float64](p, table)
+	if err != nil {
+		t.Fatalf("%s: transform failed: %v", t.Name(), err)
+	}
+	if !tensor.Equal(features, tensor.New(tensor.Shape{5, 1}, []float64{0, 0, 0, 0, 1.5})) {
+		t.Errorf("%s: unseen category not encoded as zeros got=%v", t.Name(), features)
+	}
+
+	// unseen ordinal values have no index
+	table, _ = tabular.ReadCSV(strings.NewReader("age,color,height,class\n30,red,1.5,d\n"))
+	if _, _, err := tabular.Transform[float64](p, table); err == nil || !strings.Contains(err.Error(), "unknown category") {
+		t.Errorf("%s: should fail due to unknown category got=%v", t.Name(), err)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	p, _ := fit(t)
+
+	var buf bytes.Buffer
+	if err := p.Save(&buf); err != nil {
+		t.Fatalf("%s: save failed: %v", t.Name(), err)
+	}
+	loaded, err := tabular.Load(&buf)
+	if err != nil {
+		t.Fatalf("%s: load failed: %v", t.Name(), err)
+	}
+
+	// inference data has no labels and may contain unseen categories
+	table, _ := tabular.ReadCSV(strings.NewReader("age,color,height\n25,yellow,1.0\n"))
+	features, labels, err := tabular.Transform[float64](loaded, table)
+	if err != nil {
+		t.Fatalf("%s: transform failed: %v", t.Name(), err)
+	}
+	if labels != nil {
+		t.Errorf("%s: labels should be nil", t.Name())
+	}
+
+	std := math.Sqrt(200.0 / 3)
+	expected := []float64{-5 / std, 0, 0, 0, 1}
+	for i, e := range features.Elements() {
+		if math.Abs(e-expected[i]) > 1e-9 {
+			t.Errorf("%s: expected=%v got=%v", t.Name(), expected, features)
+			break
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package vision
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/tensor"
+)
+
+// Folder is a dataset of images organized in a directory with a subdirectory
+// per class, the classes are numbered following the sorted names of the
+// subdirectories. Every sample is made of the image, after applying the
+// transform, and a scalar tensor with its class.
+type Folder[T constraints.Number] struct {
+	classes   []string
+	paths     []string
+	labels    []int
+	transform Transform[T]
+}
+
+var extensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true}
+
+// NewFolder returns a dataset with the images found in the subdirectories of
+// root. The transform is optional, it must produce images of the same size so
+// samples can be batched together.
+func NewFolder[T constraints.Number](root string, transform Transform[T]) (*Folder[T], error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	// entries are sorted by name so labels follow the order of the classes
+	f := &Folder[T]{transform: transform}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+
+		label := len(f.classes)
+		f.classes = append(f.classes, e.Name())
+		files, err := os.ReadDir(filepath.Join(root, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if file.IsDir() || !extensions[strings.ToLower(filepath.Ext(file.Name()))] {
+				continue
+			}
+			f.paths = append(f.paths, filepath.Join(root, e.Name(), file.Name()))
+			f.labels = append(f.labels, label)
+		}
+	}
+
+	if len(f.paths) == 0 {
+		return nil, fmt.Errorf("vision: no images found in %s", root)
+	}
+	return f, nil
+}
+
+// Classes returns the names of the classes indexed by label.
+func (f *Folder[T]) Classes() []string {
+	return f.classes
+}
+
+// Len returns the number of images.
+func (f *Folder[T]) Len() int {
+	return len(f.paths)
+}
+
+// Get returns the image at index i and its class. Panics if the image can't
+// be decoded.
+func (f *Folder[T]) Get(i int) []*tensor.Tensor[T] {
+	img, err := Load[T](f.paths[i])
+	if err != nil {
+		panic(fmt.Sprintf("can't load %s: %v", f.paths[i], err))
+	}
+	if f.transform != nil {
+		img = f.transform(img)
+	}
+	return []*tensor.Tensor[T]{img, tensor.New(tensor.Shape{}, []T{T(f.labels[i])})}
+}
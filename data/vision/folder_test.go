@@ -0,0 +1,49 @@
+package vision_test
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blast-go/blast/data"
+	"github.com/blast-go/blast/data/vision"
+	"github.com/blast-go/blast/tensor"
+)
+
+func TestFolder(t *testing.T) {
+	root := t.TempDir()
+	for class, sizes := range map[string][]int{"cat": {4, 6}, "dog": {8}} {
+		if err := os.Mkdir(filepath.Join(root, class), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		for i, size := range sizes {
+			f, err := os.Create(filepath.Join(root, class, string(rune('a'+i))+".png"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := png.Encode(f, image.NewRGBA(image.Rect(0, 0, size, size))); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+		}
+	}
+
+	folder, err := vision.NewFolder(root, vision.Resize[float32](2, 2))
+	if err != nil {
+		t.Fatalf("%s: can't create dataset: %v", t.Name(), err)
+	}
+	if folder.Len() != 3 || len(folder.Classes()) != 2 || folder.Classes()[1] != "dog" {
+		t.Errorf("%s: invalid dataset classes=%v len=%d", t.Name(), folder.Classes(), folder.Len())
+	}
+
+	it := data.NewLoader[float32](folder, data.WithBatchSize(3)).Iter()
+	batch, _ := it.Next()
+	if !tensor.Equal(batch[1], tensor.New(tensor.Shape{3}, []float32{0, 0, 1})) {
+		t.Errorf("%s: invalid labels got=%v", t.Name(), batch[1])
+	}
+	if shape := batch[0].Shape(); len(shape) != 4 || shape[0] != 2 || shape[2] != 3 || shape[3] != 3 {
+		t.Errorf("%s: invalid batch shape got=%v", t.Name(), shape)
+	}
+}
@@ -0,0 +1,318 @@
+package vision
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/random"
+	"github.com/blast-go/blast/tensor"
+)
+
+// Transform returns a new image tensor derived from the given one. Random
+// transforms are safe to use from the goroutines of a data.Loader.
+type Transform[T constraints.Number] func(*tensor.Tensor[T]) *tensor.Tensor[T]
+
+// Compose returns a transform that applies all transforms in order.
+func Compose[T constraints.Number](transforms ...Transform[T]) Transform[T] {
+	return func(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+		for _, transform := range transforms {
+			t = transform(t)
+		}
+		return t
+	}
+}
+
+// Resize scales the image to w by h pixels using bilinear interpolation.
+func Resize[T constraints.Number](w, h int) Transform[T] {
+	if w <= 0 || h <= 0 {
+		panic(fmt.Sprintf("invalid size %dx%d", w, h))
+	}
+
+	return func(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+		tw, th, c := dims(t)
+		sx := float64(tw) / float64(w)
+		sy := float64(th) / float64(h)
+		return mapPixels(t, w, h, c, func(x, y int) (float64, float64) {
+			fx := math.Max((float64(x)+0.5)*sx-0.5, 0)
+			fy := math.Max((float64(y)+0.5)*sy-0.5, 0)
+			return math.Min(fx, float64(tw-1)), math.Min(fy, float64(th-1))
+		})
+	}
+}
+
+// CenterCrop extracts a region of w by h pixels from the center of the image.
+func CenterCrop[T constraints.Number](w, h int) Transform[T] {
+	return func(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+		tw, th, _ := dims(t)
+		return crop(t, (tw-w)/2, (th-h)/2, w, h)
+	}
+}
+
+// RandomCrop extracts a region of w by h pixels from a random location of the
+// image.
+func RandomCrop[T constraints.Number](w, h int, seed int64) Transform[T] {
+	r := newRand(seed)
+	return func(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+		tw, th, _ := dims(t)
+		if w > tw || h > th {
+			panic(fmt.Sprintf("crop size %dx%d larger than image %dx%d", w, h, tw, th))
+		}
+		return crop(t, r.intn(tw-w+1), r.intn(th-h+1), w, h)
+	}
+}
+
+// HorizontalFlip mirrors the image from left to right.
+func HorizontalFlip[T constraints.Number]() Transform[T] {
+	return func(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+		w, h, c := dims(t)
+		return remap(t, w, h, c, func(x, y int) (int, int) { return w - 1 - x, y })
+	}
+}
+
+// VerticalFlip mirrors the image from top to bottom.
+func VerticalFlip[T constraints.Number]() Transform[T] {
+	return func(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+		w, h, c := dims(t)
+		return remap(t, w, h, c, func(x, y int) (int, int) { return x, h - 1 - y })
+	}
+}
+
+// RandomHorizontalFlip mirrors the image from left to right with probability
+// p.
+func RandomHorizontalFlip[T constraints.Number](p float64, seed int64) Transform[T] {
+	return randomApply(HorizontalFlip[T](), p, seed)
+}
+
+// RandomVerticalFlip mirrors the image from top to bottom with probability p.
+func RandomVerticalFlip[T constraints.Number](p float64, seed int64) Transform[T] {
+	return randomApply(VerticalFlip[T](), p, seed)
+}
+
+// Rotate rotates the image counterclockwise by the given degrees around its
+// center, areas outside the original image are filled with zeros.
+func Rotate[T constraints.Number](degrees float64) Transform[T] {
+	return func(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+		return rotate(t, degrees)
+	}
+}
+
+// RandomRotation rotates the image by an angle chosen uniformly between
+// -degrees and degrees.
+func RandomRotation[T constraints.Number](degrees float64, seed int64) Transform[T] {
+	r := newRand(seed)
+	return func(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+		return rotate(t, (2*r.float64()-1)*degrees)
+	}
+}
+
+// Normalize subtracts mean and divides by std every channel of the image.
+// Normalized values are negative and fractional, so the image must be
+// converted to floating point numbers first. Panics if the number of values
+// doesn't match the number of channels.
+func Normalize[T constraints.Float](mean, std []float64) Transform[T] {
+	if len(mean) != len(std) {
+		panic("mean and std must have the same length")
+	}
+
+	return func(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+		w, h, c := dims(t)
+		if c != len(mean) {
+			panic(fmt.Sprintf("invalid number of channels expected=%d got=%d", len(mean), c))
+		}
+
+		plane := w * h
+		src := t.Elements()
+		elements := make([]T, len(src))
+		for i, e := range src {
+			ch := i / plane
+			elements[i] = T((float64(e) - mean[ch]) / std[ch])
+		}
+		return tensor.New(tensor.Shape{uint(w), uint(h), uint(c)}, elements)
+	}
+}
+
+// ColorJitter randomly changes the brightness, contrast and saturation of the
+// image. Each property is scaled by a factor chosen uniformly in [1-v, 1+v],
+// zero disables the change. Saturation only applies to RGB images. Results are
+// clamped to [0, 255] so it must be applied before Normalize.
+func ColorJitter[T constraints.Number](brightness, contrast, saturation float64, seed int64) Transform[T] {
+	r := newRand(seed)
+	factor := func(v float64) float64 {
+		if v == 0 {
+			return 1
+		}
+		return math.Max(0, 1+(2*r.float64()-1)*v)
+	}
+
+	return func(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+		w, h, c := dims(t)
+		plane := w * h
+
+		pixels := make([]float64, len(t.Elements()))
+		for i, e := range t.Elements() {
+			pixels[i] = float64(e)
+		}
+
+		// gray returns the luminance of the pixel at index i
+		gray := func(i int) float64 {
+			if c != 3 {
+				return pixels[i]
+			}
+			return 0.299*pixels[i] + 0.587*pixels[plane+i] + 0.114*pixels[2*plane+i]
+		}
+
+		if b := factor(brightness); b != 1 {
+			for i := range pixels {
+				pixels[i] = clamp(pixels[i] * b)
+			}
+		}
+
+		if ct := factor(contrast); ct != 1 {
+			mean := 0.0
+			for i := 0; i < plane; i++ {
+				mean += gray(i)
+			}
+			mean /= float64(plane)
+			for i := range pixels {
+				pixels[i] = clamp((pixels[i]-mean)*ct + mean)
+			}
+		}
+
+		if s := factor(saturation); s != 1 && c == 3 {
+			for i := 0; i < plane; i++ {
+				g := gray(i)
+				for ch := 0; ch < 3; ch++ {
+					p := ch*plane + i
+					pixels[p] = clamp((pixels[p]-g)*s + g)
+				}
+			}
+		}
+
+		elements := make([]T, len(pixels))
+		for i, p := range pixels {
+			elements[i] = fromFloat[T](p)
+		}
+		return tensor.New(tensor.Shape{uint(w), uint(h), uint(c)}, elements)
+	}
+}
+
+func randomApply[T constraints.Number](transform Transform[T], p float64, seed int64) Transform[T] {
+	r := newRand(seed)
+	return func(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+		if r.float64() < p {
+			return transform(t)
+		}
+		return t
+	}
+}
+
+func crop[T constraints.Number](t *tensor.Tensor[T], x0, y0, w, h int) *tensor.Tensor[T] {
+	tw, th, c := dims(t)
+	if x0 < 0 || y0 < 0 || x0+w > tw || y0+h > th || w <= 0 || h <= 0 {
+		panic(fmt.Sprintf("crop %dx%d at %d,%d out of bounds for image %dx%d", w, h, x0, y0, tw, th))
+	}
+	return remap(t, w, h, c, func(x, y int) (int, int) { return x0 + x, y0 + y })
+}
+
+func rotate[T constraints.Number](t *tensor.Tensor[T], degrees float64) *tensor.Tensor[T] {
+	w, h, c := dims(t)
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+	cx := float64(w-1) / 2
+	cy := float64(h-1) / 2
+
+	// rows grow downwards so a counterclockwise rotation on screen maps each
+	// destination pixel back to the source with the inverse rotation
+	return mapPixels(t, w, h, c, func(x, y int) (float64, float64) {
+		dx := float64(x) - cx
+		dy := float64(y) - cy
+		return cos*dx - sin*dy + cx, sin*dx + cos*dy + cy
+	})
+}
+
+// remap builds a new w by h image in which every pixel is copied from the
+// source pixel returned by src.
+func remap[T constraints.Number](t *tensor.Tensor[T], w, h, c int, src func(x, y int) (int, int)) *tensor.Tensor[T] {
+	tw, th, _ := dims(t)
+	tElements := t.Elements()
+	elements := make([]T, w*h*c)
+	for ch := 0; ch < c; ch++ {
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				sx, sy := src(x, y)
+				elements[(ch*h+y)*w+x] = tElements[(ch*th+sy)*tw+sx]
+			}
+		}
+	}
+	return tensor.New(tensor.Shape{uint(w), uint(h), uint(c)}, elements)
+}
+
+// mapPixels builds a new w by h image in which every pixel is interpolated
+// bilinearly at the source coordinates returned by src, coordinates outside
+// the source image produce zeros.
+func mapPixels[T constraints.Number](t *tensor.Tensor[T], w, h, c int, src func(x, y int) (float64, float64)) *tensor.Tensor[T] {
+	tw, th, _ := dims(t)
+	tElements := t.Elements()
+	at := func(ch, x, y int) float64 {
+		if x < 0 || y < 0 || x >= tw || y >= th {
+			return 0
+		}
+		return float64(tElements[(ch*th+y)*tw+x])
+	}
+
+	elements := make([]T, w*h*c)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			fx, fy := src(x, y)
+			if fx <= -1 || fy <= -1 || fx >= float64(tw) || fy >= float64(th) {
+				continue
+			}
+			x0, y0 := int(math.Floor(fx)), int(math.Floor(fy))
+			ax, ay := fx-float64(x0), fy-float64(y0)
+			for ch := 0; ch < c; ch++ {
+				top := at(ch, x0, y0)*(1-ax) + at(ch, x0+1, y0)*ax
+				bottom := at(ch, x0, y0+1)*(1-ax) + at(ch, x0+1, y0+1)*ax
+				elements[(ch*h+y)*w+x] = fromFloat[T](top*(1-ay) + bottom*ay)
+			}
+		}
+	}
+	return tensor.New(tensor.Shape{uint(w), uint(h), uint(c)}, elements)
+}
+
+// fromFloat converts v to T rounding to the nearest value for integer types.
+func fromFloat[T constraints.Number](v float64) T {
+	half := 0.5
+	if T(half) == 0 {
+		return T(math.Round(v))
+	}
+	return T(v)
+}
+
+func clamp(v float64) float64 {
+	return math.Max(0, math.Min(255, v))
+}
+
+// lockedRand is a random number generator safe for concurrent use.
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func newRand(seed int64) *lockedRand {
+	r, _ := random.New(seed)
+	return &lockedRand{r: r}
+}
+
+func (l *lockedRand) float64() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Float64()
+}
+
+func (l *lockedRand) intn(n int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Intn(n)
+}
@@ -0,0 +1,121 @@
+package vision_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/blast-go/blast/data/vision"
+	"github.com/blast-go/blast/tensor"
+)
+
+// image with 3 columns, 2 rows and a single channel
+func newImage() *tensor.Tensor[float64] {
+	return tensor.New(tensor.Shape{3, 2, 1}, []float64{1, 2, 3, 4, 5, 6})
+}
+
+func TestResize(t *testing.T) {
+	img := tensor.New(tensor.Shape{2, 2, 1}, []float64{0, 10, 20, 30})
+
+	actual := vision.Resize[float64](4, 4)(img)
+	expected := []float64{
+		0, 2.5, 7.5, 10,
+		5, 7.5, 12.5, 15,
+		15, 17.5, 22.5, 25,
+		20, 22.5, 27.5, 30,
+	}
+	if !tensor.Equal(actual, tensor.New(tensor.Shape{4, 4, 1}, expected)) {
+		t.Errorf("%s: expected=%v got=%v", t.Name(), expected, actual)
+	}
+
+	if back := vision.Resize[float64](2, 2)(actual); !tensor.EqualShape(back, img) {
+		t.Errorf("%s: invalid shape got=%v", t.Name(), back.Shape())
+	}
+}
+
+func TestCrop(t *testing.T) {
+	actual := vision.CenterCrop[float64](1, 2)(newImage())
+	if !tensor.Equal(actual, tensor.New(tensor.Shape{1, 2, 1}, []float64{2, 5})) {
+		t.Errorf("%s: center crop failed got=%v", t.Name(), actual)
+	}
+
+	crop := vision.RandomCrop[float64](2, 2, 1)
+	for i := 0; i < 10; i++ {
+		actual := crop(newImage())
+		first := actual.Elements()[0]
+		if (first != 1 && first != 2) || actual.Elements()[3] != first+4 {
+			t.Errorf("%s: random crop failed got=%v", t.Name(), actual)
+		}
+	}
+}
+
+func TestFlip(t *testing.T) {
+	actual := vision.HorizontalFlip[float64]()(newImage())
+	if !tensor.Equal(actual, tensor.New(tensor.Shape{3, 2, 1}, []float64{3, 2, 1, 6, 5, 4})) {
+		t.Errorf("%s: horizontal flip failed got=%v", t.Name(), actual)
+	}
+
+	actual = vision.VerticalFlip[float64]()(newImage())
+	if !tensor.Equal(actual, tensor.New(tensor.Shape{3, 2, 1}, []float64{4, 5, 6, 1, 2, 3})) {
+		t.Errorf("%s: vertical flip failed got=%v", t.Name(), actual)
+	}
+
+	flipped := 0
+	flip := vision.RandomHorizontalFlip[float64](0.5, 1)
+	for i := 0; i < 100; i++ {
+		if flip(newImage()).Elements()[0] == 3 {
+			flipped++
+		}
+	}
+	if flipped < 30 || flipped > 70 {
+		t.Errorf("%s: flipped %d of 100 images", t.Name(), flipped)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	img := tensor.New(tensor.Shape{3, 3, 1}, []uint8{
+		0, 0, 1,
+		0, 0, 0,
+		0, 0, 0,
+	})
+
+	actual := vision.Rotate[uint8](90)(img)
+	expected := tensor.New(tensor.Shape{3, 3, 1}, []uint8{
+		1, 0, 0,
+		0, 0, 0,
+		0, 0, 0,
+	})
+	if !tensor.Equal(actual, expected) {
+		t.Errorf("%s: expected=%v got=%v", t.Name(), expected, actual)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	img := tensor.New(tensor.Shape{1, 1, 2}, []float32{10, 20})
+
+	actual := vision.Normalize[float32]([]float64{5, 10}, []float64{5, 2})(img)
+	if !tensor.Equal(actual, tensor.New(tensor.Shape{1, 1, 2}, []float32{1, 5})) {
+		t.Errorf("%s: normalize failed got=%v", t.Name(), actual)
+	}
+}
+
+func TestColorJitter(t *testing.T) {
+	img := tensor.Rand[float64](tensor.Shape{4, 4, 3})
+	for i, e := range img.Elements() {
+		img.Elements()[i] = math.Min(math.Abs(e)*100, 255)
+	}
+
+	jitter := vision.ColorJitter[float64](0.5, 0.5, 0.5, 1)
+	actual := jitter(img)
+	if !tensor.EqualShape(actual, img) || tensor.Equal(actual, img) {
+		t.Errorf("%s: image not changed got=%v", t.Name(), actual)
+	}
+	for _, e := range actual.Elements() {
+		if e < 0 || e > 255 {
+			t.Errorf("%s: value out of range %f", t.Name(), e)
+		}
+	}
+
+	if same := vision.ColorJitter[float64](0, 0, 0, 1)(img); !tensor.Equal(same, img) {
+		t.Errorf("%s: image should not change got=%v", t.Name(), same)
+	}
+}
@@ -0,0 +1,124 @@
+// Package vision converts images into tensors and provides the transforms
+// commonly used to augment them during training.
+//
+// Images are stored in CHW layout: channels are the outermost dimension and
+// pixels of a row are contiguous, so an RGB image of 32x24 pixels is a tensor
+// with shape {32, 24, 3}. Elements are in the range [0, 255].
+package vision
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+
+	// register the decoders of the supported formats
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/tensor"
+)
+
+// FromImage converts the image into a tensor. Grayscale images produce a
+// single channel and everything else three RGB channels, transparency is
+// ignored.
+func FromImage[T constraints.Number](img image.Image) *tensor.Tensor[T] {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	channels := 3
+	switch img.ColorModel() {
+	case color.GrayModel, color.Gray16Model:
+		channels = 1
+	}
+
+	elements := make([]T, w*h*channels)
+	plane := w * h
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			i := y*w + x
+			if channels == 1 {
+				elements[i] = T(r >> 8)
+				continue
+			}
+			elements[i] = T(r >> 8)
+			elements[plane+i] = T(g >> 8)
+			elements[2*plane+i] = T(b >> 8)
+		}
+	}
+
+	return tensor.New(tensor.Shape{uint(w), uint(h), uint(channels)}, elements)
+}
+
+// ToImage converts a tensor with one or three channels back into an image,
+// elements are clamped to the range [0, 255].
+func ToImage[T constraints.Number](t *tensor.Tensor[T]) image.Image {
+	w, h, c := dims(t)
+	elements := t.Elements()
+	plane := w * h
+
+	if c == 1 {
+		img := image.NewGray(image.Rect(0, 0, w, h))
+		for i := 0; i < plane; i++ {
+			img.Pix[i] = clampByte(float64(elements[i]))
+		}
+		return img
+	}
+	if c != 3 {
+		panic(fmt.Sprintf("can't convert tensor with %d channels into an image", c))
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < plane; i++ {
+		img.Pix[4*i] = clampByte(float64(elements[i]))
+		img.Pix[4*i+1] = clampByte(float64(elements[plane+i]))
+		img.Pix[4*i+2] = clampByte(float64(elements[2*plane+i]))
+		img.Pix[4*i+3] = 255
+	}
+	return img
+}
+
+// Decode reads an image in PNG, JPEG or GIF format from r and converts it
+// into a tensor.
+func Decode[T constraints.Number](r io.Reader) (*tensor.Tensor[T], error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("vision: %w", err)
+	}
+	return FromImage[T](img), nil
+}
+
+// Load reads the image located at path and converts it into a tensor.
+func Load[T constraints.Number](path string) (*tensor.Tensor[T], error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Decode[T](f)
+}
+
+// dims returns the width, height and number of channels of an image tensor.
+// Panics if the tensor doesn't have three dimensions.
+func dims[T constraints.Number](t *tensor.Tensor[T]) (w, h, c int) {
+	shape := t.Shape()
+	if len(shape) != 3 {
+		panic(fmt.Sprintf("image tensors must have 3 dimensions got=%v", shape))
+	}
+	return int(shape[0]), int(shape[1]), int(shape[2])
+}
+
+func clampByte(v float64) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v + 0.5)
+}
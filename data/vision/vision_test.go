@@ -0,0 +1,49 @@
+package vision_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/blast-go/blast/data/vision"
+	"github.com/blast-go/blast/tensor"
+)
+
+func TestDecode(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.RGBA{R: 10, G: 20, B: 30, A: 255})
+	img.Set(1, 0, color.RGBA{R: 40, G: 50, B: 60, A: 255})
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := vision.Decode[float32](&buf)
+	if err != nil {
+		t.Fatalf("%s: decode failed: %v", t.Name(), err)
+	}
+
+	expected := tensor.New(tensor.Shape{2, 1, 3}, []float32{10, 40, 20, 50, 30, 60})
+	if !tensor.Equal(actual, expected) {
+		t.Errorf("%s: expected=%v got=%v", t.Name(), expected, actual)
+	}
+}
+
+func TestGrayImage(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 2, 2))
+	copy(img.Pix, []uint8{1, 2, 3, 4})
+
+	actual := vision.FromImage[uint8](img)
+	expected := tensor.New(tensor.Shape{2, 2, 1}, []uint8{1, 2, 3, 4})
+	if !tensor.Equal(actual, expected) {
+		t.Errorf("%s: expected=%v got=%v", t.Name(), expected, actual)
+	}
+
+	back := vision.ToImage(actual).(*image.Gray)
+	if !bytes.Equal(back.Pix, img.Pix) {
+		t.Errorf("%s: invalid image got=%v", t.Name(), back.Pix)
+	}
+}
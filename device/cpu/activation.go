@@ -0,0 +1,353 @@
+package cpu
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/blast-go/blast/tensor"
+)
+
+const (
+	// scale and alpha of SELU that make it self-normalizing.
+	seluScale = 1.0507009873554804934193349852946
+	seluAlpha = 1.6732632423543772848170429916717
+	// coefficient of the cubic term of the tanh approximation of GELU.
+	geluCoefficient = 0.044715
+)
+
+// GELU returns a new tensor with the Gaussian Error Linear Unit activation
+// function applied element-wise, x * Φ(x) where Φ is the cumulative
+// distribution function of the standard normal distribution.
+func (c CPU[T]) GELU(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return c.elementwise(t,
+		func(x float64) float64 {
+			return x * normalCDF(x)
+		},
+		func(x float64) float64 {
+			return normalCDF(x) + x*math.Exp(-x*x/2)/math.Sqrt(2*math.Pi)
+		},
+	)
+}
+
+// GELUTanh returns a new tensor with the tanh approximation of the GELU
+// activation function applied element-wise,
+// 0.5 * x * (1 + tanh(sqrt(2/π) * (x + 0.044715 * x³))).
+func (c CPU[T]) GELUTanh(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+	k := math.Sqrt(2 / math.Pi)
+	return c.elementwise(t,
+		func(x float64) float64 {
+			return 0.5 * x * (1 + math.Tanh(k*(x+geluCoefficient*x*x*x)))
+		},
+		func(x float64) float64 {
+			tanh := math.Tanh(k * (x + geluCoefficient*x*x*x))
+			return 0.5*(1+tanh) + 0.5*x*(1-tanh*tanh)*k*(1+3*geluCoefficient*x*x)
+		},
+	)
+}
+
+// SiLU returns a new tensor with the Sigmoid Linear Unit activation function,
+// also known as Swish, applied element-wise, x * sigmoid(x).
+func (c CPU[T]) SiLU(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return c.elementwise(t,
+		func(x float64) float64 {
+			return x * sigmoid(x)
+		},
+		func(x float64) float64 {
+			s := sigmoid(x)
+			return s * (1 + x*(1-s))
+		},
+	)
+}
+
+// LeakyReLU returns a new tensor with the leaky ReLU activation function
+// applied element-wise, negative elements are multiplied by slope.
+func (c CPU[T]) LeakyReLU(t *tensor.Tensor[T], slope T) *tensor.Tensor[T] {
+	s := float64(slope)
+	return c.elementwise(t,
+		func(x float64) float64 {
+			if x > 0 {
+				return x
+			}
+			return s * x
+		},
+		func(x float64) float64 {
+			if x > 0 {
+				return 1
+			}
+			return s
+		},
+	)
+}
+
+// PReLU returns a new tensor with the parametric ReLU activation function
+// applied element-wise, negative elements are multiplied by a learnable
+// slope. The slope is a one dimensional tensor with either a single element
+// shared by all elements of t or one element for every entry of t along
+// axis, usually the channels. Gradients flow to both t and slope. Panics if
+// the shape of slope is invalid.
+func (c CPU[T]) PReLU(t, slope *tensor.Tensor[T], axis int) *tensor.Tensor[T] {
+	shape := t.Shape()
+	checkAxis(axis, len(shape))
+	slopeShape := slope.Shape()
+	if len(slopeShape) != 1 || (slopeShape[0] != 1 && slopeShape[0] != shape[axis]) {
+		panic(fmt.Sprintf("slope of shape %v must have 1 or %d elements", slopeShape, shape[axis]))
+	}
+
+	// channel of every element of t
+	inner := tensor.Size(shape[:axis])
+	n := int(shape[axis])
+	channel := func(i int) int {
+		if slopeShape[0] == 1 {
+			return 0
+		}
+		return i / inner % n
+	}
+
+	parents := []*tensor.Tensor[T]{t, slope}
+	forward := func() []T {
+		tElements := t.Elements()
+		slopeElements := slope.Elements()
+		elements := make([]T, len(tElements))
+		for i, e := range tElements {
+			if e > 0 {
+				elements[i] = e
+			} else {
+				elements[i] = slopeElements[channel(i)] * e
+			}
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[T]) {
+			tElements := t.Elements()
+			slopeElements := slope.Elements()
+			tGrad := t.Grad()
+			slopeGrad := slope.Grad()
+			for i, g := range tOut.Grad() {
+				if tElements[i] > 0 {
+					tGrad[i] += g
+				} else {
+					tGrad[i] += g * slopeElements[channel(i)]
+					slopeGrad[channel(i)] += g * tElements[i]
+				}
+			}
+		}
+	}
+
+	return tensor.Op(tensor.CopyShape(shape), parents, forward, backward)
+}
+
+// ELU returns a new tensor with the Exponential Linear Unit activation
+// function applied element-wise, negative elements become
+// alpha * (exp(x) - 1).
+func (c CPU[T]) ELU(t *tensor.Tensor[T], alpha T) *tensor.Tensor[T] {
+	a := float64(alpha)
+	return c.elementwise(t,
+		func(x float64) float64 {
+			if x > 0 {
+				return x
+			}
+			return a * (math.Exp(x) - 1)
+		},
+		func(x float64) float64 {
+			if x > 0 {
+				return 1
+			}
+			return a * math.Exp(x)
+		},
+	)
+}
+
+// SELU returns a new tensor with the Scaled Exponential Linear Unit
+// activation function applied element-wise, an ELU with fixed alpha scaled so
+// that activations keep zero mean and unit variance.
+func (c CPU[T]) SELU(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return c.elementwise(t,
+		func(x float64) float64 {
+			if x > 0 {
+				return seluScale * x
+			}
+			return seluScale * seluAlpha * (math.Exp(x) - 1)
+		},
+		func(x float64) float64 {
+			if x > 0 {
+				return seluScale
+			}
+			return seluScale * seluAlpha * math.Exp(x)
+		},
+	)
+}
+
+// Softplus returns a new tensor with the softplus activation function applied
+// element-wise, log(1 + exp(x)), a smooth approximation of ReLU.
+func (c CPU[T]) Softplus(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return c.elementwise(t, softplus, sigmoid)
+}
+
+// Mish returns a new tensor with the Mish activation function applied
+// element-wise, x * tanh(softplus(x)).
+func (c CPU[T]) Mish(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return c.elementwise(t,
+		func(x float64) float64 {
+			return x * math.Tanh(softplus(x))
+		},
+		func(x float64) float64 {
+			tanh := math.Tanh(softplus(x))
+			return tanh + x*(1-tanh*tanh)*sigmoid(x)
+		},
+	)
+}
+
+// Hardtanh returns a new tensor with the elements of t clamped between min
+// and max. Gradients only flow to the elements that were not clamped. Panics
+// if min is greater than max.
+func (c CPU[T]) Hardtanh(t *tensor.Tensor[T], min, max T) *tensor.Tensor[T] {
+	if min > max {
+		panic(fmt.Sprintf("min %v can't be greater than max %v", min, max))
+	}
+
+	parents := []*tensor.Tensor[T]{t}
+	forward := func() []T {
+		tElements := t.Elements()
+		elements := make([]T, len(tElements))
+		for i, e := range tElements {
+			switch {
+			case e < min:
+				elements[i] = min
+			case e > max:
+				elements[i] = max
+			default:
+				elements[i] = e
+			}
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[T]) {
+			tElements := t.Elements()
+			tGrad := t.Grad()
+			for i, g := range tOut.Grad() {
+				if tElements[i] > min && tElements[i] < max {
+					tGrad[i] += g
+				}
+			}
+		}
+	}
+
+	return tensor.Op(tensor.CopyShape(t.Shape()), parents, forward, backward)
+}
+
+// Softmax returns a new tensor in which the elements along axis are
+// replaced by their exponentials divided by their sum, so they add up to
+// one. Elements equal to minus infinity get a probability of zero, when all
+// the elements are minus infinity all the probabilities are zero.
+func (c CPU[T]) Softmax(t *tensor.Tensor[T], axis int) *tensor.Tensor[T] {
+	shape := t.Shape()
+	checkAxis(axis, len(shape))
+	inner := tensor.Size(shape[:axis])
+	n := int(shape[axis])
+	outer := tensor.Size(shape[axis+1:])
+
+	parents := []*tensor.Tensor[T]{t}
+	forward := func() []T {
+		tElements := t.Elements()
+		elements := make([]T, len(tElements))
+		exp := make([]float64, n)
+		for o := 0; o < outer; o++ {
+			for i := 0; i < inner; i++ {
+				offset := o*n*inner + i
+
+				// the maximum is subtracted to avoid overflows
+				max := math.Inf(-1)
+				for j := 0; j < n; j++ {
+					max = math.Max(max, float64(tElements[offset+j*inner]))
+				}
+				if math.IsInf(max, -1) {
+					continue
+				}
+
+				sum := 0.0
+				for j := range exp {
+					exp[j] = math.Exp(float64(tElements[offset+j*inner]) - max)
+					sum += exp[j]
+				}
+				for j, e := range exp {
+					elements[offset+j*inner] = T(e / sum)
+				}
+			}
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[T]) {
+			tOutElements := tOut.Elements()
+			tOutGrad := tOut.Grad()
+			tGrad := t.Grad()
+			for o := 0; o < outer; o++ {
+				for i := 0; i < inner; i++ {
+					offset := o*n*inner + i
+
+					// dx = y * (g - sum(g * y))
+					dot := 0.0
+					for j := 0; j < n; j++ {
+						k := offset + j*inner
+						dot += float64(tOutGrad[k]) * float64(tOutElements[k])
+					}
+					for j := 0; j < n; j++ {
+						k := offset + j*inner
+						tGrad[k] += T(float64(tOutElements[k]) * (float64(tOutGrad[k]) - dot))
+					}
+				}
+			}
+		}
+	}
+
+	return tensor.Op(tensor.CopyShape(shape), parents, forward, backward)
+}
+
+// elementwise returns a new tensor with f applied to every element of t, df
+// is the derivative of f used in the backward pass. Both are evaluated in
+// float64.
+func (c CPU[T]) elementwise(t *tensor.Tensor[T], f, df func(float64) float64) *tensor.Tensor[T] {
+	parents := []*tensor.Tensor[T]{t}
+	forward := func() []T {
+		tElements := t.Elements()
+		elements := make([]T, len(tElements))
+		for i, e := range tElements {
+			elements[i] = T(f(float64(e)))
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[T]) {
+			tElements := t.Elements()
+			tGrad := t.Grad()
+			for i, g := range tOut.Grad() {
+				tGrad[i] += T(float64(g) * df(float64(tElements[i])))
+			}
+		}
+	}
+
+	return tensor.Op(tensor.CopyShape(t.Shape()), parents, forward, backward)
+}
+
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+// softplus is computed as max(x, 0) + log(1 + exp(-|x|)) to avoid overflows.
+func softplus(x float64) float64 {
+	return math.Max(x, 0) + math.Log1p(math.Exp(-math.Abs(x)))
+}
+
+func normalCDF(x float64) float64 {
+	return 0.5 * (1 + math.Erf(x/math.Sqrt2))
+}
@@ -0,0 +1,164 @@
+package cpu_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/tensor"
+)
+
+var activations = map[string]func(cpu.CPU[float64], *tensor.Tensor[float64]) *tensor.Tensor[float64]{
+	"GELU":     func(d cpu.CPU[float64], t *tensor.Tensor[float64]) *tensor.Tensor[float64] { return d.GELU(t) },
+	"GELUTanh": func(d cpu.CPU[float64], t *tensor.Tensor[float64]) *tensor.Tensor[float64] { return d.GELUTanh(t) },
+	"SiLU":     func(d cpu.CPU[float64], t *tensor.Tensor[float64]) *tensor.Tensor[float64] { return d.SiLU(t) },
+	"LeakyReLU": func(d cpu.CPU[float64], t *tensor.Tensor[float64]) *tensor.Tensor[float64] {
+		return d.LeakyReLU(t, 0.1)
+	},
+	"ELU":      func(d cpu.CPU[float64], t *tensor.Tensor[float64]) *tensor.Tensor[float64] { return d.ELU(t, 1) },
+	"SELU":     func(d cpu.CPU[float64], t *tensor.Tensor[float64]) *tensor.Tensor[float64] { return d.SELU(t) },
+	"Softplus": func(d cpu.CPU[float64], t *tensor.Tensor[float64]) *tensor.Tensor[float64] { return d.Softplus(t) },
+	"Mish":     func(d cpu.CPU[float64], t *tensor.Tensor[float64]) *tensor.Tensor[float64] { return d.Mish(t) },
+	"Hardtanh": func(d cpu.CPU[float64], t *tensor.Tensor[float64]) *tensor.Tensor[float64] {
+		return d.Hardtanh(t, -1, 1)
+	},
+}
+
+func TestActivations(t *testing.T) {
+	d := cpu.New[float64]()
+	t1 := tensor.New(tensor.Shape{3}, []float64{-2, 0, 1})
+
+	expected := map[string][]float64{
+		"GELU":      {-0.04550026389635842, 0, 0.8413447460685429},
+		"GELUTanh":  {-0.04540230591222, 0, 0.8411919906082768},
+		"SiLU":      {-0.23840584404423515, 0, 0.7310585786300049},
+		"LeakyReLU": {-0.2, 0, 1},
+		"ELU":       {-0.8646647167633873, 0, 1},
+		"SELU":      {-1.5201664685956096, 0, 1.0507009873554805},
+		"Softplus":  {0.1269280110429725, 0.6931471805599453, 1.3132616875182228},
+		"Mish":      {-0.2525014826957912, 0, 0.8650983882673103},
+		"Hardtanh":  {-1, 0, 1},
+	}
+	for name, f := range activations {
+		actual := f(d, t1).Elements()
+		for i, e := range expected[name] {
+			if math.Abs(actual[i]-e) > 1e-9 {
+				t.Errorf("%s: %s failed expected=%v got=%v", t.Name(), name, expected[name], actual)
+				break
+			}
+		}
+	}
+}
+
+func TestActivationsGrad(t *testing.T) {
+	d := cpu.New[float64](cpu.WithGrad(true))
+	elements := []float64{-2.5, -0.7, -0.1, 0.3, 0.8, 2.2}
+	h := 1e-6
+
+	for name, f := range activations {
+		t1 := tensor.New(tensor.Shape{6}, append([]float64(nil), elements...))
+		f(d, t1).Backward()
+
+		for i, e := range elements {
+			plus := append([]float64(nil), elements...)
+			minus := append([]float64(nil), elements...)
+			plus[i] = e + h
+			minus[i] = e - h
+			fPlus := f(d, tensor.New(tensor.Shape{6}, plus)).Elements()[i]
+			fMinus := f(d, tensor.New(tensor.Shape{6}, minus)).Elements()[i]
+			numeric := (fPlus - fMinus) / (2 * h)
+			if math.Abs(t1.Grad()[i]-numeric) > 1e-5 {
+				t.Errorf("%s: %s gradient failed at %f expected=%f got=%f", t.Name(), name, e, numeric, t1.Grad()[i])
+			}
+		}
+	}
+}
+
+func TestPReLU(t *testing.T) {
+	d := cpu.New[float64](cpu.WithGrad(true))
+	// 2 channels along axis 1
+	t1 := tensor.New(tensor.Shape{2, 2}, []float64{-1, 2, -3, -4})
+	slope := tensor.New(tensor.Shape{2}, []float64{0.1, 0.5})
+
+	t2 := d.PReLU(t1, slope, 1)
+	expected := tensor.New(tensor.Shape{2, 2}, []float64{-0.1, 2, -1.5, -2})
+	if !tensor.Equal(t2, expected) {
+		t.Errorf("%s: PReLU failed expected=%v got=%v", t.Name(), expected, t2)
+	}
+
+	t2.Backward()
+	expectedGrad := []float64{0.1, 1, 0.5, 0.5}
+	for i, g := range t1.Grad() {
+		if g != expectedGrad[i] {
+			t.Errorf("%s: gradient failed expected=%v got=%v", t.Name(), expectedGrad, t1.Grad())
+			break
+		}
+	}
+	expectedGrad = []float64{-1, -7}
+	for i, g := range slope.Grad() {
+		if g != expectedGrad[i] {
+			t.Errorf("%s: slope gradient failed expected=%v got=%v", t.Name(), expectedGrad, slope.Grad())
+			break
+		}
+	}
+}
+
+func TestPReLUInvalidSlope(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("%s: should have failed due to invalid slope", t.Name())
+		}
+	}()
+	cpu.New[float64]().PReLU(tensor.Zeros[float64](tensor.Shape{2, 3}), tensor.Zeros[float64](tensor.Shape{2}), 1)
+}
+
+func TestSoftmax(t *testing.T) {
+	d := cpu.New[float64]()
+	inf := math.Inf(-1)
+	// pairs of elements along axis 1, one of them fully masked
+	t1 := tensor.New(tensor.Shape{3, 2, 2}, []float64{
+		1, 2, 3, inf, 0, inf,
+		0, inf, 1, 0, inf, 1,
+	})
+	y := d.Softmax(t1, 1)
+
+	s := 1 / (1 + math.Exp(-2))
+	expected := []float64{
+		1, s, 1, 0, 1 - s, 0,
+		0.5, 0, 0.5, 0.5, 0, 0.5,
+	}
+	for i, a := range y.Elements() {
+		if math.Abs(a-expected[i]) > 1e-12 {
+			t.Errorf("%s: Softmax failed expected=%v got=%v", t.Name(), expected, y.Elements())
+			break
+		}
+	}
+}
+
+func TestSoftmaxGrad(t *testing.T) {
+	d := cpu.New[float64](cpu.WithGrad(true))
+	elements := []float64{0.3, -1.2, 2.1, 0.5, -0.7, 1.4}
+	weights := tensor.New(tensor.Shape{3, 2}, []float64{1, -2, 0.5, 3, 1.5, -1})
+	loss := func(x *tensor.Tensor[float64]) float64 {
+		s := 0.0
+		for _, e := range d.Hadamard(d.Softmax(x, 0), weights).Elements() {
+			s += e
+		}
+		return s
+	}
+
+	t1 := tensor.New(tensor.Shape{3, 2}, append([]float64(nil), elements...))
+	d.Hadamard(d.Softmax(t1, 0), weights).Backward()
+
+	h := 1e-6
+	for i, e := range elements {
+		plus := append([]float64(nil), elements...)
+		minus := append([]float64(nil), elements...)
+		plus[i] = e + h
+		minus[i] = e - h
+		numeric := (loss(tensor.New(tensor.Shape{3, 2}, plus)) - loss(tensor.New(tensor.Shape{3, 2}, minus))) / (2 * h)
+		if math.Abs(t1.Grad()[i]-numeric) > 1e-6 {
+			t.Errorf("%s: gradient failed at %d expected=%f got=%f", t.Name(), i, numeric, t1.Grad()[i])
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package cpu
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/tensor"
+)
+
+// attentionTile is the number of queries and keys processed together by
+// Attention, the scores of a tile are the only ones stored at any time.
+const attentionTile = 32
+
+// Attention returns softmax(q k^T / sqrt(dk)) v for every element of the
+// batch without storing the attention matrix. q has shape {dk, lq, batch...},
+// k {dk, lk, batch...} and v {dv, lk, batch...}, the result has shape
+// {dv, lq, batch...}.
+//
+// mask, when not nil, has shape {lk, lq, batch...}, or {lk, 1, batch...} to
+// share the mask among all the queries, with ones where a query can attend to
+// a key and zeros elsewhere. When causal is true every query i can only attend
+// to the keys up to i. Queries that can't attend to any key get a zero output.
+//
+// Queries and keys are processed in tiles and the softmax is computed online,
+// rescaling the partial results when the maximum score of a query grows. Only
+// the logsumexp of the scores of every query is kept for the backward pass,
+// which computes the scores of every tile again. Panics if the shapes are
+// incompatible.
+func (c CPU[T]) Attention(q, k, v, mask *tensor.Tensor[T], causal bool) *tensor.Tensor[T] {
+	a := newAttention(q, k, v, mask, causal)
+
+	shape := tensor.CopyShape(q.Shape())
+	shape[0] = uint(a.dv)
+
+	parents := []*tensor.Tensor[T]{q, k, v}
+	// logsumexp of the scores of every query, set by the forward pass
+	var lse []float64
+	forward := func() []T {
+		a.load()
+		var elements []T
+		elements, lse = a.forward()
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[T]) {
+			a.load()
+			a.backward(tOut.Elements(), tOut.Grad(), lse, q.Grad(), k.Grad(), v.Grad())
+		}
+	}
+
+	return tensor.Op(shape, parents, forward, backward)
+}
+
+// attention holds the operands of a fused attention. The elements of query i
+// of batch b start at (b*lq+i)*dk, the ones of key j at (b*lk+j)*dk.
+type attention[T constraints.Number] struct {
+	q, k, v, mask         *tensor.Tensor[T]
+	qs, ks, vs, masks     []T
+	batch, dk, dv, lq, lk int
+	maskQueries           int
+	causal                bool
+	scale                 float64
+}
+
+func newAttention[T constraints.Number](q, k, v, mask *tensor.Tensor[T], causal bool) *attention[T] {
+	qShape, kShape, vShape := q.Shape(), k.Shape(), v.Shape()
+	if len(qShape) < 2 || len(kShape) != len(qShape) || len(vShape) != len(qShape) ||
+		qShape[0] != kShape[0] || kShape[1] != vShape[1] ||
+		!tensor.SameShape(qShape[2:], kShape[2:]) || !tensor.SameShape(qShape[2:], vShape[2:]) {
+		panic(fmt.Sprintf("incompatible shapes for attention %v, %v and %v", qShape, kShape, vShape))
+	}
+
+	a := &attention[T]{
+		q:           q,
+		k:           k,
+		v:           v,
+		mask:        mask,
+		batch:       tensor.Size(qShape[2:]),
+		dk:          int(qShape[0]),
+		dv:          int(vShape[0]),
+		lq:          int(qShape[1]),
+		lk:          int(kShape[1]),
+		maskQueries: int(qShape[1]),
+		causal:      causal,
+		scale:       1 / math.Sqrt(float64(qShape[0])),
+	}
+	if mask != nil {
+		maskShape := mask.Shape()
+		if len(maskShape) != len(qShape) || maskShape[0] != kShape[1] ||
+			(maskShape[1] != qShape[1] && maskShape[1] != 1) || !tensor.SameShape(maskShape[2:], qShape[2:]) {
+			panic(fmt.Sprintf("invalid mask of shape %v for queries %v and keys %v", maskShape, qShape, kShape))
+		}
+		a.maskQueries = int(maskShape[1])
+	}
+	return a
+}
+
+// load reads the elements of the operands.
+func (a *attention[T]) load() {
+	a.qs, a.ks, a.vs = a.q.Elements(), a.k.Elements(), a.v.Elements()
+	if a.mask != nil {
+		a.masks = a.mask.Elements()
+	}
+}
+
+// scores sets the scaled scores of queries [i0, i1) and keys [j0, j1) of
+// batch b in s, the score of query i and key j at (i-i0)*attentionTile+j-j0.
+// Masked scores are minus infinity.
+func (a *attention[T]) scores(s []float64, b, i0, i1, j0, j1 int) {
+	for i := i0; i < i1; i++ {
+		query := a.qs[(b*a.lq+i)*a.dk : (b*a.lq+i+1)*a.dk]
+		row := s[(i-i0)*attentionTile : (i-i0+1)*attentionTile]
+		maskRow := b * a.maskQueries
+		if a.maskQueries != 1 {
+			maskRow += i
+		}
+		for j := j0; j < j1; j++ {
+			if (a.causal && j > i) || (a.masks != nil && a.masks[maskRow*a.lk+j] == 0) {
+				row[j-j0] = math.Inf(-1)
+				continue
+			}
+			key := a.ks[(b*a.lk+j)*a.dk : (b*a.lk+j+1)*a.dk]
+			dot := 0.0
+			for d, e := range query {
+				dot += float64(e) * float64(key[d])
+			}
+			row[j-j0] = dot * a.scale
+		}
+	}
+}
+
+// keys returns the end of the keys that queries up to i1 can attend to.
+func (a *attention[T]) keys(i1 int) int {
+	if a.causal && i1 < a.lk {
+		return i1
+	}
+	return a.lk
+}
+
+// forward returns the output of the attention and the logsumexp of the
+// scores of every query, minus infinity for queries without keys.
+func (a *attention[T]) forward() ([]T, []float64) {
+	out := make([]T, a.batch*a.lq*a.dv)
+	lse := make([]float64, a.batch*a.lq)
+	s := make([]float64, attentionTile*attentionTile)
+	// running maximum, sum of exponentials and weighted sum of values of
+	// every query of the tile
+	max := make([]float64, attentionTile)
+	sum := make([]float64, attentionTile)
+	acc := make([]float64, attentionTile*a.dv)
+
+	for b := 0; b < a.batch; b++ {
+		for i0 := 0; i0 < a.lq; i0 += attentionTile {
+			i1 := i0 + attentionTile
+			if i1 > a.lq {
+				i1 = a.lq
+			}
+			for i := range max {
+				max[i], sum[i] = math.Inf(-1), 0
+			}
+			for i := range acc {
+				acc[i] = 0
+			}
+
+			end := a.keys(i1)
+			for j0 := 0; j0 < end; j0 += attentionTile {
+				j1 := j0 + attentionTile
+				if j1 > end {
+					j1 = end
+				}
+				a.scores(s, b, i0, i1, j0, j1)
+
+				for i := 0; i < i1-i0; i++ {
+					row := s[i*attentionTile : i*attentionTile+j1-j0]
+					m := max[i]
+					for _, e := range row {
+						m = math.Max(m, e)
+					}
+					if math.IsInf(m, -1) {
+						continue
+					}
+
+					// rescale the partial results to the new maximum
+					rescale := math.Exp(max[i] - m)
+					sum[i] *= rescale
+					values := acc[i*a.dv : (i+1)*a.dv]
+					for d := range values {
+						values[d] *= rescale
+					}
+					for j, e := range row {
+						p := math.Exp(e - m)
+						sum[i] += p
+						value := a.vs[(b*a.lk+j0+j)*a.dv : (b*a.lk+j0+j+1)*a.dv]
+						for d, x := range value {
+							values[d] += p * float64(x)
+						}
+					}
+					max[i] = m
+				}
+			}
+
+			for i := 0; i < i1-i0; i++ {
+				query := b*a.lq + i0 + i
+				if sum[i] == 0 {
+					lse[query] = math.Inf(-1)
+					continue
+				}
+				lse[query] = max[i] + math.Log(sum[i])
+				for d, e := range acc[i*a.dv : (i+1)*a.dv] {
+					out[query*a.dv+d] = T(e / sum[i])
+				}
+			}
+		}
+	}
+	return out, lse
+}
+
+// backward adds the gradients of the queries, keys and values given the
+// output of the attention, its gradient and the logsumexp of the scores.
+func (a *attention[T]) backward(out, outGrad []T, lse []float64, qGrad, kGrad, vGrad []T) {
+	dq := make([]float64, len(qGrad))
+	dk := make([]float64, len(kGrad))
+	dv := make([]float64, len(vGrad))
+	s := make([]float64, attentionTile*attentionTile)
+	// dot product of the output of every query of the tile and its gradient
+	dots := make([]float64, attentionTile)
+
+	for b := 0; b < a.batch; b++ {
+		for i0 := 0; i0 < a.lq; i0 += attentionTile {
+			i1 := i0 + attentionTile
+			if i1 > a.lq {
+				i1 = a.lq
+			}
+			for i := 0; i < i1-i0; i++ {
+				query := b*a.lq + i0 + i
+				dots[i] = 0
+				for d := 0; d < a.dv; d++ {
+					dots[i] += float64(outGrad[query*a.dv+d]) * float64(out[query*a.dv+d])
+				}
+			}
+
+			end := a.keys(i1)
+			for j0 := 0; j0 < end; j0 += attentionTile {
+				j1 := j0 + attentionTile
+				if j1 > end {
+					j1 = end
+				}
+				a.scores(s, b, i0, i1, j0, j1)
+
+				for i := 0; i < i1-i0; i++ {
+					query := b*a.lq + i0 + i
+					if math.IsInf(lse[query], -1) {
+						continue
+					}
+					g := outGrad[query*a.dv : (query+1)*a.dv]
+					for j, e := range s[i*attentionTile : i*attentionTile+j1-j0] {
+						if math.IsInf(e, -1) {
+							continue
+						}
+						key := b*a.lk + j0 + j
+						p := math.Exp(e - lse[query])
+
+						// dV = P^T dO, dP = dO V^T and dS = P * (dP - rowsum(dO * O))
+						dp := 0.0
+						for d, x := range g {
+							dv[key*a.dv+d] += p * float64(x)
+							dp += float64(x) * float64(a.vs[key*a.dv+d])
+						}
+						ds := p * (dp - dots[i]) * a.scale
+						for d := 0; d < a.dk; d++ {
+							dq[query*a.dk+d] += ds * float64(a.ks[key*a.dk+d])
+							dk[key*a.dk+d] += ds * float64(a.qs[query*a.dk+d])
+						}
+					}
+				}
+			}
+		}
+	}
+
+	for i, g := range dq {
+		qGrad[i] += T(g)
+	}
+	for i, g := range dk {
+		kGrad[i] += T(g)
+	}
+	for i, g := range dv {
+		vGrad[i] += T(g)
+	}
+}
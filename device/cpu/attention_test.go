@@ -0,0 +1,127 @@
+package cpu_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/tensor"
+)
+
+// composedAttention is the attention computed storing the attention matrix.
+func composedAttention(d cpu.CPU[float64], q, k, v, mask *tensor.Tensor[float64]) *tensor.Tensor[float64] {
+	scores := d.Mul(d.BatchMatMul(q, d.Permute(k, 1, 0, 2)), 1/math.Sqrt(float64(q.Shape()[0])))
+	if mask != nil {
+		scores = d.MaskedFill(scores, d.Not(mask), math.Inf(-1))
+	}
+	return d.BatchMatMul(d.Softmax(scores, 0), v)
+}
+
+func TestAttention(t *testing.T) {
+	d := cpu.New[float64](cpu.WithGrad(true))
+	rng := rand.New(rand.NewSource(1))
+	random := func(shape tensor.Shape, p float64) *tensor.Tensor[float64] {
+		x := tensor.Zeros[float64](shape)
+		elements := x.Elements()
+		for i := range elements {
+			if p == 0 {
+				elements[i] = rng.NormFloat64()
+			} else if rng.Float64() >= p {
+				elements[i] = 1
+			}
+		}
+		return x
+	}
+
+	// several tiles of queries and keys
+	lq, lk := uint(40), uint(70)
+	q := random(tensor.Shape{3, lq, 2}, 0)
+	k := random(tensor.Shape{3, lk, 2}, 0)
+	v := random(tensor.Shape{2, lk, 2}, 0)
+	weights := random(tensor.Shape{2, lq, 2}, 0)
+
+	// the first query can't attend to any key
+	mask := random(tensor.Shape{lk, lq, 2}, 0.3)
+	for j := uint(0); j < lk; j++ {
+		mask.Set(0, j, 0, 0)
+	}
+	keyMask := random(tensor.Shape{lk, 1, 2}, 0.3)
+	causal := tensor.Zeros[float64](tensor.Shape{lk, lq, 2})
+	for b := uint(0); b < 2; b++ {
+		for i := uint(0); i < lq; i++ {
+			for j := uint(0); j <= i; j++ {
+				causal.Set(1, j, i, b)
+			}
+		}
+	}
+
+	cases := map[string]struct {
+		fused    func() *tensor.Tensor[float64]
+		composed func() *tensor.Tensor[float64]
+	}{
+		"NoMask": {
+			func() *tensor.Tensor[float64] { return d.Attention(q, k, v, nil, false) },
+			func() *tensor.Tensor[float64] { return composedAttention(d, q, k, v, nil) },
+		},
+		"Mask": {
+			func() *tensor.Tensor[float64] { return d.Attention(q, k, v, mask, false) },
+			func() *tensor.Tensor[float64] { return composedAttention(d, q, k, v, mask) },
+		},
+		"KeyMask": {
+			func() *tensor.Tensor[float64] { return d.Attention(q, k, v, keyMask, false) },
+			func() *tensor.Tensor[float64] {
+				return composedAttention(d, q, k, v, d.Broadcast(keyMask, tensor.Shape{lk, lq, 2}))
+			},
+		},
+		"Causal": {
+			func() *tensor.Tensor[float64] { return d.Attention(q, k, v, nil, true) },
+			func() *tensor.Tensor[float64] { return composedAttention(d, q, k, v, causal) },
+		},
+	}
+
+	for name, c := range cases {
+		grads := map[string][][]float64{}
+		outputs := map[string][]float64{}
+		for _, op := range []string{"fused", "composed"} {
+			f := c.fused
+			if op == "composed" {
+				f = c.composed
+			}
+			q.ZeroGrad()
+			k.ZeroGrad()
+			v.ZeroGrad()
+			y := f()
+			d.Hadamard(y, weights).Backward()
+			outputs[op] = y.Elements()
+			for _, p := range []*tensor.Tensor[float64]{q, k, v} {
+				grads[op] = append(grads[op], append([]float64(nil), p.Grad()...))
+			}
+		}
+
+		for i, e := range outputs["composed"] {
+			if math.Abs(outputs["fused"][i]-e) > 1e-12 {
+				t.Errorf("%s: %s output failed at %d expected=%f got=%f", t.Name(), name, i, e, outputs["fused"][i])
+				break
+			}
+		}
+		for p, input := range []string{"q", "k", "v"} {
+			for i, e := range grads["composed"][p] {
+				if math.Abs(grads["fused"][p][i]-e) > 1e-12 {
+					t.Errorf("%s: %s gradient of %s failed at %d expected=%f got=%f", t.Name(), name, input, i, e, grads["fused"][p][i])
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestAttentionInvalidMask(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("%s: should panic with a mask of an invalid shape", t.Name())
+		}
+	}()
+	q := tensor.Zeros[float64](tensor.Shape{2, 3, 1})
+	cpu.New[float64]().Attention(q, q, q, tensor.Zeros[float64](tensor.Shape{3, 2, 1}), false)
+}
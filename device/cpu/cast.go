@@ -0,0 +1,231 @@
+package cpu
+
+import (
+	"math"
+	"reflect"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/tensor"
+)
+
+// Rounding is the way Cast converts floating point numbers to integers.
+type Rounding int
+
+const (
+	// Truncate rounds toward zero like Go conversions.
+	Truncate Rounding = iota
+	// Round rounds to the nearest integer, ties away from zero.
+	Round
+	// RoundEven rounds to the nearest integer, ties to even.
+	RoundEven
+	// Floor rounds toward minus infinity.
+	Floor
+	// Ceil rounds toward plus infinity.
+	Ceil
+)
+
+type castOption func(*castOptions)
+
+type castOptions struct {
+	rounding Rounding
+	saturate bool
+}
+
+// WithRounding sets how Cast converts floating point numbers to integers, by
+// default they are truncated.
+func WithRounding(r Rounding) castOption {
+	return func(o *castOptions) {
+		o.rounding = r
+	}
+}
+
+// WithSaturation makes Cast clamp the numbers out of the range of the result
+// type to its minimum or maximum, NaN becoming zero for integers. Otherwise
+// integers wrap around like Go conversions, with infinities and NaN becoming
+// zero, and floating point numbers too large become infinities.
+func WithSaturation(v bool) castOption {
+	return func(o *castOptions) {
+		o.saturate = v
+	}
+}
+
+// Cast returns a tensor with the elements of t converted to the type of the
+// device, for instance to turn an uint8 image into floats. Floating point
+// numbers are rounded to nearest even when converted to a floating point type
+// of less precision, and the options set how they are rounded to integers
+// and what happens to the numbers out of the range of the result type.
+// Tensors of the half precision types are converted with Upcast and Downcast.
+//
+// Gradients flow through casts between floating point types, rounded to the
+// type of t, and don't flow through casts from or to integers.
+func Cast[To, From constraints.Number](c CPU[To], t *tensor.Tensor[From], opts ...castOption) *tensor.Tensor[To] {
+	var cfg castOptions
+	for _, o := range opts {
+		o(&cfg)
+	}
+	convert := converter[To, From](cfg)
+
+	forward := func() []To {
+		tElements := t.Elements()
+		elements := make([]To, len(tElements))
+		for i, e := range tElements {
+			elements[i] = convert(e)
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[To]
+	from, to := numberTypeOf[From](), numberTypeOf[To]()
+	if c.grad && from.kind >= floatKind && to.kind >= floatKind {
+		backward = func(tOut *tensor.Tensor[To]) {
+			tGrad := t.Grad()
+			for i, g := range tOut.Grad() {
+				tGrad[i] = fromFloat[From](float64(tGrad[i])+float64(g), from, castOptions{})
+			}
+		}
+	}
+
+	return tensor.MixedOp(tensor.CopyShape(t.Shape()), []tensor.Node{t}, forward, backward)
+}
+
+type numberKind int
+
+const (
+	signedKind numberKind = iota
+	unsignedKind
+	floatKind
+)
+
+// numberType describes the representation of a numeric type.
+type numberType struct {
+	kind numberKind
+	bits int
+	// max is the largest finite value of the floating point types.
+	max float64
+}
+
+func numberTypeOf[T constraints.Number]() numberType {
+	var zero T
+	typ := reflect.TypeOf(zero)
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return numberType{kind: signedKind, bits: typ.Bits()}
+	case reflect.Float32:
+		return numberType{kind: floatKind, bits: 32, max: math.MaxFloat32}
+	case reflect.Float64:
+		return numberType{kind: floatKind, bits: 64, max: math.MaxFloat64}
+	default:
+		return numberType{kind: unsignedKind, bits: typ.Bits()}
+	}
+}
+
+// converter returns the function that converts a number of type From to To.
+// Integers are read as int64 or uint64 so they keep their precision.
+func converter[To, From constraints.Number](o castOptions) func(From) To {
+	to := numberTypeOf[To]()
+	switch numberTypeOf[From]().kind {
+	case signedKind:
+		return func(x From) To { return fromInt[To](int64(x), to, o) }
+	case unsignedKind:
+		return func(x From) To { return fromUint[To](uint64(x), to, o) }
+	default:
+		return func(x From) To { return fromFloat[To](float64(x), to, o) }
+	}
+}
+
+func fromInt[To constraints.Number](v int64, to numberType, o castOptions) To {
+	switch to.kind {
+	case signedKind:
+		if o.saturate && to.bits < 64 {
+			limit := int64(1) << (to.bits - 1)
+			if v < -limit {
+				v = -limit
+			} else if v >= limit {
+				v = limit - 1
+			}
+		}
+	case unsignedKind:
+		if o.saturate {
+			if v < 0 {
+				v = 0
+			} else if to.bits < 64 && v >= int64(1)<<to.bits {
+				v = int64(1)<<to.bits - 1
+			}
+		}
+	case floatKind:
+		return To(v)
+	}
+	return To(v)
+}
+
+func fromUint[To constraints.Number](v uint64, to numberType, o castOptions) To {
+	switch to.kind {
+	case signedKind:
+		if limit := uint64(1)<<(to.bits-1) - 1; o.saturate && v > limit {
+			v = limit
+		}
+	case unsignedKind:
+		if limit := uint64(1)<<to.bits - 1; o.saturate && to.bits < 64 && v > limit {
+			v = limit
+		}
+	case floatKind:
+		return To(v)
+	}
+	return To(v)
+}
+
+func fromFloat[To constraints.Number](f float64, to numberType, o castOptions) To {
+	if to.kind == floatKind {
+		if o.saturate && math.Abs(f) > to.max && !math.IsInf(f, 0) {
+			f = math.Copysign(to.max, f)
+		}
+		return To(f)
+	}
+
+	if math.IsNaN(f) {
+		return 0
+	}
+	r := round(f, o.rounding)
+	if o.saturate {
+		// the limits are powers of two, exact in a float64
+		low, high := -math.Ldexp(1, to.bits-1), math.Ldexp(1, to.bits-1)
+		if to.kind == unsignedKind {
+			low, high = 0, math.Ldexp(1, to.bits)
+		}
+		if r < low {
+			r = low
+		} else if r >= high {
+			if to.kind == unsignedKind {
+				return To(uint64(math.MaxUint64) >> (64 - to.bits))
+			}
+			return To(uint64(1)<<(to.bits-1) - 1)
+		}
+	} else if math.IsInf(r, 0) {
+		return 0
+	}
+
+	if math.Abs(r) < 1<<63 {
+		return To(int64(r))
+	}
+	// r is a multiple of 2^11 so its remainder modulo 2^64 is exact
+	m := math.Mod(r, 1<<64)
+	if m < 0 {
+		m += 1 << 64
+	}
+	return To(uint64(m))
+}
+
+func round(f float64, r Rounding) float64 {
+	switch r {
+	case Round:
+		return math.Round(f)
+	case RoundEven:
+		return math.RoundToEven(f)
+	case Floor:
+		return math.Floor(f)
+	case Ceil:
+		return math.Ceil(f)
+	default:
+		return math.Trunc(f)
+	}
+}
@@ -0,0 +1,87 @@
+package cpu_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/half"
+	"github.com/blast-go/blast/tensor"
+)
+
+func TestCast(t *testing.T) {
+	image := tensor.New(tensor.Shape{4}, []uint8{0, 1, 128, 255})
+	floats := cpu.Cast(cpu.New[float32](), image)
+	if expected := "[0 1 128 255]"; floats.String() != expected {
+		t.Errorf("%s: uint8 to float32 expected=%s got=%s", t.Name(), expected, floats)
+	}
+
+	x := tensor.New(tensor.Shape{6}, []float64{-2.5, -1.5, -0.5, 0.5, 1.5, 2.7})
+	d := cpu.New[int8]()
+	cases := map[cpu.Rounding]string{
+		cpu.Truncate:  "[-2 -1 0 0 1 2]",
+		cpu.Round:     "[-3 -2 -1 1 2 3]",
+		cpu.RoundEven: "[-2 -2 0 0 2 3]",
+		cpu.Floor:     "[-3 -2 -1 0 1 2]",
+		cpu.Ceil:      "[-2 -1 0 1 2 3]",
+	}
+	for rounding, expected := range cases {
+		if actual := cpu.Cast(d, x, cpu.WithRounding(rounding)); actual.String() != expected {
+			t.Errorf("%s: rounding %d expected=%s got=%s", t.Name(), rounding, expected, actual)
+		}
+	}
+}
+
+func TestCastRange(t *testing.T) {
+	floats := tensor.New(tensor.Shape{5}, []float64{300, -5, math.NaN(), math.Inf(1), math.Ldexp(1, 63)})
+	ints := tensor.New(tensor.Shape{3}, []int16{-1, 300, 255})
+	unsigned := tensor.New(tensor.Shape{2}, []uint64{math.MaxUint64, 7})
+
+	cases := map[string]struct {
+		expected string
+		actual   fmt.Stringer
+	}{
+		"float to uint8 saturated":  {"[255 0 0 255 255]", cpu.Cast(cpu.New[uint8](), floats, cpu.WithSaturation(true))},
+		"float to uint8 wrapped":    {"[44 251 0 0 0]", cpu.Cast(cpu.New[uint8](), floats)},
+		"float to int64 saturated":  {"[300 -5 0 9223372036854775807 9223372036854775807]", cpu.Cast(cpu.New[int64](), floats, cpu.WithSaturation(true))},
+		"float to int64 wrapped":    {"[300 -5 0 0 -9223372036854775808]", cpu.Cast(cpu.New[int64](), floats)},
+		"int16 to uint8 saturated":  {"[0 255 255]", cpu.Cast(cpu.New[uint8](), ints, cpu.WithSaturation(true))},
+		"int16 to uint8 wrapped":    {"[255 44 255]", cpu.Cast(cpu.New[uint8](), ints)},
+		"int16 to int8 saturated":   {"[-1 127 127]", cpu.Cast(cpu.New[int8](), ints, cpu.WithSaturation(true))},
+		"uint64 to int64 saturated": {"[9223372036854775807 7]", cpu.Cast(cpu.New[int64](), unsigned, cpu.WithSaturation(true))},
+		"uint64 to int64 wrapped":   {"[-1 7]", cpu.Cast(cpu.New[int64](), unsigned)},
+		"uint64 to float64":         {"[1.8446744073709552e+19 7]", cpu.Cast(cpu.New[float64](), unsigned)},
+		"float to float32 saturated": {"[300 -5 NaN +Inf 3.4028235e+38]", cpu.Cast(cpu.New[float32](),
+			tensor.New(tensor.Shape{5}, []float64{300, -5, math.NaN(), math.Inf(1), 1e40}), cpu.WithSaturation(true))},
+		"float16 to int8": {"[-2 127]", cpu.Cast(cpu.New[int8](), cpu.Upcast(
+			tensor.New(tensor.Shape{2}, []half.Float16{half.NewFloat16(-2.5), half.NewFloat16(200)})), cpu.WithSaturation(true))},
+	}
+	for name, c := range cases {
+		if actual := c.actual.String(); actual != c.expected {
+			t.Errorf("%s: %s expected=%s got=%s", t.Name(), name, c.expected, actual)
+		}
+	}
+}
+
+func TestCastGrad(t *testing.T) {
+	d64 := cpu.New[float64](cpu.WithGrad(true))
+	d32 := cpu.New[float32](cpu.WithGrad(true))
+	x := tensor.New(tensor.Shape{3}, []float64{0.5, -1, 2})
+	counts := tensor.New(tensor.Shape{3}, []uint8{1, 2, 3})
+
+	// x cast to float32 and back, times counts cast to float64
+	y := d64.Hadamard(cpu.Cast(d64, d32.Mul(cpu.Cast(d32, x), 2)), cpu.Cast(d64, counts))
+	y.Backward()
+	for i, g := range x.Grad() {
+		if expected := 2 * float64(counts.Elements()[i]); g != expected {
+			t.Errorf("%s: gradient failed at %d expected=%v got=%v", t.Name(), i, expected, g)
+		}
+	}
+	for i, g := range counts.Grad() {
+		if g != 0 {
+			t.Errorf("%s: gradient of integers at %d expected=0 got=%v", t.Name(), i, g)
+		}
+	}
+
+}
@@ -0,0 +1,134 @@
+package cpu
+
+import (
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/tensor"
+)
+
+// Eq returns a new tensor with ones where the elements of t1 and t2 are equal
+// and zeros elsewhere. Comparisons don't propagate gradients. Panics if the
+// two tensors do not have the same shape.
+func (c CPU[T]) Eq(t1, t2 *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return compare(t1, t2, func(a, b T) bool { return a == b })
+}
+
+// Ne returns a new tensor with ones where the elements of t1 and t2 are not
+// equal and zeros elsewhere. Panics if the two tensors do not have the same
+// shape.
+func (c CPU[T]) Ne(t1, t2 *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return compare(t1, t2, func(a, b T) bool { return a != b })
+}
+
+// Lt returns a new tensor with ones where the elements of t1 are less than
+// the ones of t2 and zeros elsewhere. Panics if the two tensors do not have
+// the same shape.
+func (c CPU[T]) Lt(t1, t2 *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return compare(t1, t2, func(a, b T) bool { return a < b })
+}
+
+// Le returns a new tensor with ones where the elements of t1 are less than or
+// equal to the ones of t2 and zeros elsewhere. Panics if the two tensors do
+// not have the same shape.
+func (c CPU[T]) Le(t1, t2 *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return compare(t1, t2, func(a, b T) bool { return a <= b })
+}
+
+// Gt returns a new tensor with ones where the elements of t1 are greater than
+// the ones of t2 and zeros elsewhere. Panics if the two tensors do not have
+// the same shape.
+func (c CPU[T]) Gt(t1, t2 *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return compare(t1, t2, func(a, b T) bool { return a > b })
+}
+
+// Ge returns a new tensor with ones where the elements of t1 are greater than
+// or equal to the ones of t2 and zeros elsewhere. Panics if the two tensors do
+// not have the same shape.
+func (c CPU[T]) Ge(t1, t2 *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return compare(t1, t2, func(a, b T) bool { return a >= b })
+}
+
+// And returns a new tensor with ones where the elements of both t1 and t2 are
+// not zero and zeros elsewhere. Panics if the two tensors do not have the
+// same shape.
+func (c CPU[T]) And(t1, t2 *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return compare(t1, t2, func(a, b T) bool { return a != 0 && b != 0 })
+}
+
+// Or returns a new tensor with ones where the elements of t1 or t2 are not
+// zero and zeros elsewhere. Panics if the two tensors do not have the same
+// shape.
+func (c CPU[T]) Or(t1, t2 *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return compare(t1, t2, func(a, b T) bool { return a != 0 || b != 0 })
+}
+
+// Not returns a new tensor with ones where the elements of t are zero and
+// zeros elsewhere.
+func (c CPU[T]) Not(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return compare(t, t, func(a, _ T) bool { return a == 0 })
+}
+
+// Where returns a new tensor with the elements of t1 where cond is not zero
+// and the elements of t2 elsewhere. Gradients flow to the tensor the element
+// was selected from. Panics if the three tensors do not have the same shape.
+func (c CPU[T]) Where(cond, t1, t2 *tensor.Tensor[T]) *tensor.Tensor[T] {
+	if !tensor.EqualShape(cond, t1) || !tensor.EqualShape(t1, t2) {
+		panic("tensors must have the same shape")
+	}
+
+	parents := []*tensor.Tensor[T]{t1, t2}
+	forward := func() []T {
+		condElements := cond.Elements()
+		t1Elements := t1.Elements()
+		t2Elements := t2.Elements()
+		elements := make([]T, len(condElements))
+		for i, e := range condElements {
+			if e != 0 {
+				elements[i] = t1Elements[i]
+			} else {
+				elements[i] = t2Elements[i]
+			}
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[T]) {
+			condElements := cond.Elements()
+			t1Grad := t1.Grad()
+			t2Grad := t2.Grad()
+			for i, g := range tOut.Grad() {
+				if condElements[i] != 0 {
+					t1Grad[i] += g
+				} else {
+					t2Grad[i] += g
+				}
+			}
+		}
+	}
+
+	return tensor.Op(tensor.CopyShape(t1.Shape()), parents, forward, backward)
+}
+
+// compare returns a new tensor with ones where f is true for the elements of
+// t1 and t2 and zeros elsewhere. The result is not differentiable so it has
+// no backward function.
+func compare[T constraints.Number](t1, t2 *tensor.Tensor[T], f func(a, b T) bool) *tensor.Tensor[T] {
+	if !tensor.EqualShape(t1, t2) {
+		panic("tensors must have the same shape")
+	}
+
+	forward := func() []T {
+		t1Elements := t1.Elements()
+		t2Elements := t2.Elements()
+		elements := make([]T, len(t1Elements))
+		for i := range elements {
+			if f(t1Elements[i], t2Elements[i]) {
+				elements[i] = 1
+			}
+		}
+		return elements
+	}
+
+	return tensor.Op(tensor.CopyShape(t1.Shape()), nil, forward, nil)
+}
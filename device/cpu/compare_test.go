@@ -0,0 +1,88 @@
+package cpu_test
+
+import (
+	"testing"
+
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/tensor"
+)
+
+func TestCompare(t *testing.T) {
+	d := cpu.New[float32]()
+	t1 := tensor.New(tensor.Shape{3}, []float32{1, 2, 3})
+	t2 := tensor.New(tensor.Shape{3}, []float32{2, 2, 2})
+
+	cases := []struct {
+		name     string
+		actual   *tensor.Tensor[float32]
+		expected []float32
+	}{
+		{"Eq", d.Eq(t1, t2), []float32{0, 1, 0}},
+		{"Ne", d.Ne(t1, t2), []float32{1, 0, 1}},
+		{"Lt", d.Lt(t1, t2), []float32{1, 0, 0}},
+		{"Le", d.Le(t1, t2), []float32{1, 1, 0}},
+		{"Gt", d.Gt(t1, t2), []float32{0, 0, 1}},
+		{"Ge", d.Ge(t1, t2), []float32{0, 1, 1}},
+	}
+	for _, c := range cases {
+		expected := tensor.New(tensor.Shape{3}, c.expected)
+		if !tensor.Equal(c.actual, expected) {
+			t.Errorf("%s: %s failed expected=%v got=%v", t.Name(), c.name, expected, c.actual)
+		}
+	}
+}
+
+func TestLogical(t *testing.T) {
+	d := cpu.New[int]()
+	t1 := tensor.New(tensor.Shape{4}, []int{0, 0, 3, -1})
+	t2 := tensor.New(tensor.Shape{4}, []int{0, 2, 0, 5})
+
+	cases := []struct {
+		name     string
+		actual   *tensor.Tensor[int]
+		expected []int
+	}{
+		{"And", d.And(t1, t2), []int{0, 0, 0, 1}},
+		{"Or", d.Or(t1, t2), []int{0, 1, 1, 1}},
+		{"Not", d.Not(t1), []int{1, 1, 0, 0}},
+	}
+	for _, c := range cases {
+		expected := tensor.New(tensor.Shape{4}, c.expected)
+		if !tensor.Equal(c.actual, expected) {
+			t.Errorf("%s: %s failed expected=%v got=%v", t.Name(), c.name, expected, c.actual)
+		}
+	}
+}
+
+func TestCompareInvalidShape(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("%s: should have failed due to different shapes", t.Name())
+		}
+	}()
+	cpu.New[int]().Lt(tensor.Zeros[int](tensor.Shape{2}), tensor.Zeros[int](tensor.Shape{3}))
+}
+
+func TestWhere(t *testing.T) {
+	d := cpu.New[float32](cpu.WithGrad(true))
+	t1 := tensor.New(tensor.Shape{4}, []float32{-1, 2, -3, 4})
+	t2 := tensor.New(tensor.Shape{4}, []float32{10, 20, 30, 40})
+
+	// leaky relu like selection
+	cond := d.Gt(t1, tensor.Zeros[float32](tensor.Shape{4}))
+	t3 := d.Where(cond, t1, t2)
+	expected := tensor.New(tensor.Shape{4}, []float32{10, 2, 30, 4})
+	if !tensor.Equal(t3, expected) {
+		t.Errorf("%s: Where failed expected=%v got=%v", t.Name(), expected, t3)
+	}
+
+	d.Mul(t3, 2).Backward()
+	expected1 := []float32{0, 2, 0, 2}
+	expected2 := []float32{2, 0, 2, 0}
+	for i := range expected1 {
+		if t1.Grad()[i] != expected1[i] || t2.Grad()[i] != expected2[i] {
+			t.Errorf("%s: gradient failed expected=%v,%v got=%v,%v", t.Name(), expected1, expected2, t1.Grad(), t2.Grad())
+			break
+		}
+	}
+}
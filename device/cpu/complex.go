@@ -0,0 +1,304 @@
+package cpu
+
+import (
+	"fmt"
+	"math/cmplx"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/tensor"
+)
+
+// ComplexCPU device performs computations on tensors of complex numbers using
+// the processor's ALU. It only has the operations that don't depend on an
+// order of the elements, with ConjTranspose besides Transpose. The gradient of
+// an element z is the conjugate of the derivative with respect to z, so the
+// gradient of a product a b with respect to a is the gradient of the product
+// times the conjugate of b.
+type ComplexCPU[T constraints.Complex] struct {
+	grad bool
+}
+
+// NewComplex returns a device for tensors of complex numbers configured with
+// the same options as New.
+func NewComplex[T constraints.Complex](opts ...option) ComplexCPU[T] {
+	cfg := newOptions(opts)
+	return ComplexCPU[T]{grad: cfg.grad}
+}
+
+// GradEnabled returns whether the operations of the device compute gradients
+// in a backward pass.
+func (c ComplexCPU[T]) GradEnabled() bool {
+	return c.grad
+}
+
+// Add returns a new Tensor that is the result of adding the two tensors
+// element by element. Panics if the two tensors do not have the same shape.
+func (c ComplexCPU[T]) Add(t1, t2 *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return c.elementwise(t1, t2, func(a, b T) T { return a + b }, func(g, a, b T) (T, T) { return g, g })
+}
+
+// Sub returns a new Tensor that is the result of subtracting the two tensors
+// element by element. Panics if the two tensors do not have the same shape.
+func (c ComplexCPU[T]) Sub(t1, t2 *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return c.elementwise(t1, t2, func(a, b T) T { return a - b }, func(g, a, b T) (T, T) { return g, -g })
+}
+
+// Hadamard returns a new tensor that is the result of multiplying the two
+// tensors element by element. Panics if the two tensors do not have the same
+// shape.
+func (c ComplexCPU[T]) Hadamard(t1, t2 *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return c.elementwise(t1, t2, func(a, b T) T { return a * b }, func(g, a, b T) (T, T) {
+		return g * conj(b), g * conj(a)
+	})
+}
+
+// Mul returns a new tensor multiplied by scale element-wise.
+func (c ComplexCPU[T]) Mul(t *tensor.Tensor[T], scale T) *tensor.Tensor[T] {
+	shape := t.Shape()
+	parents := []*tensor.Tensor[T]{t}
+	forward := func() []T {
+		tElements := t.Elements()
+		elements := make([]T, len(tElements))
+		for i, e := range tElements {
+			elements[i] = e * scale
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[T]) {
+			tGrad := t.Grad()
+			for i, g := range tOut.Grad() {
+				tGrad[i] += g * conj(scale)
+			}
+		}
+	}
+
+	return tensor.Op(shape, parents, forward, backward)
+}
+
+// Conj returns a new tensor with the conjugate of every element.
+func (c ComplexCPU[T]) Conj(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+	shape := t.Shape()
+	parents := []*tensor.Tensor[T]{t}
+	forward := func() []T {
+		return conjugate(t.Elements())
+	}
+
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[T]) {
+			tGrad := t.Grad()
+			for i, g := range tOut.Grad() {
+				tGrad[i] += conj(g)
+			}
+		}
+	}
+
+	return tensor.Op(shape, parents, forward, backward)
+}
+
+// MatMul returns a new Tensor that is the result of matrix multiplication of
+// the two input tensors. Panics if the shape of the two tensors is
+// incompatible or if any of the input tensors are of order different than 2.
+func (c ComplexCPU[T]) MatMul(t1, t2 *tensor.Tensor[T]) *tensor.Tensor[T] {
+	t1Shape := t1.Shape()
+	t2Shape := t2.Shape()
+	if len(t1Shape) != 2 || len(t2Shape) != 2 {
+		panic("cannot do matrix multiplication on tensor of higher order")
+	}
+	if t1Shape[0] != t2Shape[1] {
+		panic(fmt.Sprintf("incompatible shapes for matrix multiplication %v and %v", t1Shape, t2Shape))
+	}
+
+	w1, h1 := t1Shape[0], t1Shape[1]
+	w2, h2 := t2Shape[0], t2Shape[1]
+	shape := tensor.Shape{w2, h1}
+
+	forward := func() []T {
+		m := make([]T, w2*h1)
+		matmul(m, t1.Elements(), transpose(t2.Elements(), w2, h2), w2, h1, w1)
+		return m
+	}
+
+	parents := []*tensor.Tensor[T]{t1, t2}
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(t *tensor.Tensor[T]) {
+			tGrad := t.Grad()
+
+			//dL/dA = dL/dC @ B^H
+			matmul(t1.Grad(), tGrad, conjugate(t2.Elements()), h2, h1, w2)
+
+			//dL/dB = A^H @ dL/dC
+			tTGrad := transpose(tGrad, w2, h1)
+			t1HElements := conjugate(transpose(t1.Elements(), w1, h1))
+			matmul(t2.Grad(), t1HElements, tTGrad, w2, w1, h1)
+		}
+	}
+
+	return tensor.Op(shape, parents, forward, backward)
+}
+
+// Transpose returns a new transposed Tensor over the first two dimensions
+// without conjugating its elements. If the tensor has more than two
+// dimensions would panic.
+func (c ComplexCPU[T]) Transpose(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return c.transpose(t, false)
+}
+
+// ConjTranspose returns a new Tensor with the conjugate transpose over the
+// first two dimensions. If the tensor has more than two dimensions would
+// panic.
+func (c ComplexCPU[T]) ConjTranspose(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return c.transpose(t, true)
+}
+
+func (c ComplexCPU[T]) transpose(t *tensor.Tensor[T], conjugated bool) *tensor.Tensor[T] {
+	oldShape := t.Shape()
+	if len(oldShape) != 2 {
+		panic("transpose only work for two dimensional tensors")
+	}
+
+	w, h := oldShape[0], oldShape[1]
+	shape := tensor.Shape{h, w}
+	parents := []*tensor.Tensor[T]{t}
+
+	forward := func() []T {
+		elements := transpose(t.Elements(), w, h)
+		if conjugated {
+			return conjugate(elements)
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(tT *tensor.Tensor[T]) {
+			tGrad := t.Grad()
+			for i, g := range transpose(tT.Grad(), h, w) {
+				if conjugated {
+					g = conj(g)
+				}
+				tGrad[i] += g
+			}
+		}
+	}
+
+	return tensor.Op(shape, parents, forward, backward)
+}
+
+// elementwise returns the tensor with f applied to the elements of the two
+// tensors, df returns the gradients of both elements given the gradient of
+// the result.
+func (c ComplexCPU[T]) elementwise(t1, t2 *tensor.Tensor[T], f func(a, b T) T, df func(g, a, b T) (T, T)) *tensor.Tensor[T] {
+	if !tensor.EqualShape(t1, t2) {
+		panic("tensors must have the same shape")
+	}
+
+	shape := t1.Shape()
+	parents := []*tensor.Tensor[T]{t1, t2}
+	forward := func() []T {
+		t1Elements := t1.Elements()
+		t2Elements := t2.Elements()
+		elements := make([]T, len(t1Elements))
+		for i := range elements {
+			elements[i] = f(t1Elements[i], t2Elements[i])
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[T]) {
+			t1Elements := t1.Elements()
+			t2Elements := t2.Elements()
+			t1Grad := t1.Grad()
+			t2Grad := t2.Grad()
+			for i, g := range tOut.Grad() {
+				g1, g2 := df(g, t1Elements[i], t2Elements[i])
+				t1Grad[i] += g1
+				t2Grad[i] += g2
+			}
+		}
+	}
+
+	return tensor.Op(shape, parents, forward, backward)
+}
+
+// ComplexFromPairs returns the complex tensor of shape {n...} whose real and
+// imaginary parts are stored in the first dimension of t, of shape {2, n...}.
+// The gradients of the real and imaginary parts flow to t. Panics if the first
+// dimension of t is not of size two.
+func ComplexFromPairs[C constraints.Complex, R constraints.Number](c ComplexCPU[C], t *tensor.Tensor[R]) *tensor.Tensor[C] {
+	tShape := t.Shape()
+	if len(tShape) < 2 || tShape[0] != 2 {
+		panic(fmt.Sprintf("tensor of shape %v doesn't hold pairs of real and imaginary parts", tShape))
+	}
+
+	forward := func() []C {
+		tElements := t.Elements()
+		elements := make([]C, len(tElements)/2)
+		for i := range elements {
+			elements[i] = C(complex(float64(tElements[2*i]), float64(tElements[2*i+1])))
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[C]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[C]) {
+			tGrad := t.Grad()
+			for i, g := range tOut.Grad() {
+				z := complex128(g)
+				tGrad[2*i] += R(real(z))
+				tGrad[2*i+1] += R(imag(z))
+			}
+		}
+	}
+
+	return tensor.MixedOp(tensor.CopyShape(tShape[1:]), []tensor.Node{t}, forward, backward)
+}
+
+// PairsFromComplex returns the real tensor of shape {2, n...} with the real
+// and imaginary parts of the complex tensor t of shape {n...} in its first
+// dimension, so real losses can be computed from complex results. The
+// gradients of the parts flow to t.
+func PairsFromComplex[R constraints.Number, C constraints.Complex](c CPU[R], t *tensor.Tensor[C]) *tensor.Tensor[R] {
+	forward := func() []R {
+		tElements := t.Elements()
+		elements := make([]R, 2*len(tElements))
+		for i, e := range tElements {
+			z := complex128(e)
+			elements[2*i], elements[2*i+1] = R(real(z)), R(imag(z))
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[R]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[R]) {
+			tGrad := t.Grad()
+			grad := tOut.Grad()
+			for i := range tGrad {
+				tGrad[i] += C(complex(float64(grad[2*i]), float64(grad[2*i+1])))
+			}
+		}
+	}
+
+	return tensor.MixedOp(append(tensor.Shape{2}, t.Shape()...), []tensor.Node{t}, forward, backward)
+}
+
+func conj[T constraints.Complex](z T) T {
+	return T(cmplx.Conj(complex128(z)))
+}
+
+// conjugate returns a new slice with the conjugates of the elements.
+func conjugate[T constraints.Complex](elements []T) []T {
+	out := make([]T, len(elements))
+	for i, e := range elements {
+		out[i] = conj(e)
+	}
+	return out
+}
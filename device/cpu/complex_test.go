@@ -0,0 +1,130 @@
+package cpu_test
+
+import (
+	"math/cmplx"
+	"testing"
+
+	"github.com/blast-go/blast/device"
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/tensor"
+)
+
+var _ device.Device[complex128] = cpu.NewComplex[complex128]()
+
+func equalComplex(expected, actual []complex128) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	for i, e := range expected {
+		if cmplx.Abs(actual[i]-e) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestComplexOps(t *testing.T) {
+	d := cpu.NewComplex[complex128]()
+	a := tensor.New(tensor.Shape{2, 2}, []complex128{1 + 2i, 3, -1i, 2 - 1i})
+	b := tensor.New(tensor.Shape{2, 2}, []complex128{2, 1i, 1 + 1i, -3})
+
+	cases := map[string]struct {
+		expected []complex128
+		actual   *tensor.Tensor[complex128]
+	}{
+		"Add":           {[]complex128{3 + 2i, 3 + 1i, 1, -1 - 1i}, d.Add(a, b)},
+		"Sub":           {[]complex128{-1 + 2i, 3 - 1i, -1 - 2i, 5 - 1i}, d.Sub(a, b)},
+		"Hadamard":      {[]complex128{2 + 4i, 3i, 1 - 1i, -6 + 3i}, d.Hadamard(a, b)},
+		"Mul":           {[]complex128{-2 + 1i, 3i, 1, 1 + 2i}, d.Mul(a, 1i)},
+		"Conj":          {[]complex128{1 - 2i, 3, 1i, 2 + 1i}, d.Conj(a)},
+		"MatMul":        {[]complex128{5 + 7i, -11 + 1i, 3 - 1i, -5 + 3i}, d.MatMul(a, b)},
+		"Transpose":     {[]complex128{1 + 2i, -1i, 3, 2 - 1i}, d.Transpose(a)},
+		"ConjTranspose": {[]complex128{1 - 2i, 1i, 3, 2 + 1i}, d.ConjTranspose(a)},
+	}
+	for name, c := range cases {
+		if !equalComplex(c.expected, c.actual.Elements()) {
+			t.Errorf("%s: %s failed expected=%v got=%v", t.Name(), name, c.expected, c.actual.Elements())
+		}
+	}
+}
+
+func TestComplexGrad(t *testing.T) {
+	d := cpu.NewComplex[complex128](cpu.WithGrad(true))
+	a := tensor.RandComplex[complex128](tensor.Shape{3, 2})
+	b := tensor.RandComplex[complex128](tensor.Shape{2, 3})
+	c := tensor.RandComplex[complex128](tensor.Shape{3, 2})
+
+	// the gradients of holomorphic functions are the conjugates of their
+	// derivatives
+	forwards := map[string]func() *tensor.Tensor[complex128]{
+		"Add":       func() *tensor.Tensor[complex128] { return d.Add(a, c) },
+		"Sub":       func() *tensor.Tensor[complex128] { return d.Sub(a, c) },
+		"Hadamard":  func() *tensor.Tensor[complex128] { return d.Hadamard(a, c) },
+		"Mul":       func() *tensor.Tensor[complex128] { return d.Mul(a, 2-3i) },
+		"MatMul":    func() *tensor.Tensor[complex128] { return d.MatMul(d.Hadamard(a, c), b) },
+		"Transpose": func() *tensor.Tensor[complex128] { return d.MatMul(d.Transpose(a), d.Transpose(b)) },
+	}
+	for name, forward := range forwards {
+		sum := func() complex128 {
+			s := complex128(0)
+			for _, e := range forward().Elements() {
+				s += e
+			}
+			return s
+		}
+		for _, p := range []*tensor.Tensor[complex128]{a, b, c} {
+			p.ZeroGrad()
+		}
+		forward().Backward()
+
+		h := complex(1e-6, 0)
+		for k, p := range []*tensor.Tensor[complex128]{a, b, c} {
+			elements := p.Elements()
+			for i, e := range elements {
+				elements[i] = e + h
+				plus := sum()
+				elements[i] = e - h
+				minus := sum()
+				elements[i] = e
+
+				numeric := cmplx.Conj((plus - minus) / (2 * h))
+				if cmplx.Abs(p.Grad()[i]-numeric) > 1e-5 {
+					t.Errorf("%s: %s gradient of input %d failed at %d expected=%v got=%v", t.Name(), name, k, i, numeric, p.Grad()[i])
+				}
+			}
+		}
+	}
+}
+
+func TestComplexPairs(t *testing.T) {
+	d := cpu.New[float64](cpu.WithGrad(true))
+	dc := cpu.NewComplex[complex128](cpu.WithGrad(true))
+	x := tensor.New(tensor.Shape{2, 2, 1}, []float64{1, 2, 3, -4})
+
+	z := cpu.ComplexFromPairs(dc, x)
+	if !tensor.SameShape(z.Shape(), tensor.Shape{2, 1}) {
+		t.Fatalf("%s: invalid shape %v", t.Name(), z.Shape())
+	}
+	if expected := []complex128{1 + 2i, 3 - 4i}; !equalComplex(expected, z.Elements()) {
+		t.Errorf("%s: ComplexFromPairs failed expected=%v got=%v", t.Name(), expected, z.Elements())
+	}
+
+	// the gradients go through the complex tensor back to the pairs: the
+	// derivatives of 3 re - im with respect to the parts are 3 and -1
+	pairs := cpu.PairsFromComplex(d, dc.Mul(z, 1i))
+	if expected := []float64{-2, 1, 4, 3}; !equalFloats(expected, pairs.Elements()) {
+		t.Errorf("%s: PairsFromComplex failed expected=%v got=%v", t.Name(), expected, pairs.Elements())
+	}
+	d.Hadamard(pairs, tensor.New(tensor.Shape{2, 2, 1}, []float64{1, 3, 1, 3})).Backward()
+	// re(i z) = -im(z) and im(i z) = re(z)
+	if expected := []float64{3, -1, 3, -1}; !equalFloats(expected, x.Grad()) {
+		t.Errorf("%s: gradient failed expected=%v got=%v", t.Name(), expected, x.Grad())
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("%s: should panic without pairs", t.Name())
+		}
+	}()
+	cpu.ComplexFromPairs(dc, tensor.Zeros[float64](tensor.Shape{3, 2}))
+}
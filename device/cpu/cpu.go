@@ -1,6 +1,14 @@
+// Package cpu implements the devices that perform computations using the
+// processor's ALU. CPU computes on tensors of real numbers and ComplexCPU on
+// tensors of complex numbers. They are separate types because most operations
+// of CPU, like comparisons, Max, ReLU or Softmax, rely on the order of real
+// numbers, which complex numbers lack, and because the gradients of complex
+// operations are conjugated. Both implement device.Device, so code written
+// against that interface runs on either.
 package cpu
 
 import (
+	"fmt"
 	"math"
 
 	"github.com/blast-go/blast/constraints"
@@ -30,19 +38,31 @@ var defaultOptions *options = &options{
 	grad: false,
 }
 
-func New[T constraints.Number](opts ...option) CPU[T] {
-	c := CPU[T]{}
-
-	cfg := defaultOptions
+// newOptions returns a copy of the default options with opts applied, shared
+// by the constructors of the devices.
+func newOptions(opts []option) options {
+	cfg := *defaultOptions
 	for _, o := range opts {
-		o(cfg)
+		o(&cfg)
 	}
+	return cfg
+}
 
+func New[T constraints.Number](opts ...option) CPU[T] {
+	c := CPU[T]{}
+
+	cfg := newOptions(opts)
 	c.grad = cfg.grad
 
 	return c
 }
 
+// GradEnabled returns whether the operations of the device compute gradients
+// in a backward pass.
+func (c CPU[T]) GradEnabled() bool {
+	return c.grad
+}
+
 // Returns a new Tensor that is the result of adding the two tensors on element
 // by element. Panics if the two tensors do not have the same shape.
 func (c CPU[T]) Add(t1, t2 *tensor.Tensor[T]) *tensor.Tensor[T] {
@@ -125,6 +145,9 @@ func (c CPU[T]) MatMul(t1, t2 *tensor.Tensor[T]) *tensor.Tensor[T] {
 	if len(t1.Shape()) != 2 || len(t2.Shape()) != 2 {
 		panic("cannot do matrix multiplication on tensor of higher order")
 	}
+	if t1.Shape()[0] != t2.Shape()[1] {
+		panic(fmt.Sprintf("incompatible shapes for matrix multiplication %v and %v", t1.Shape(), t2.Shape()))
+	}
 
 	shape := tensor.Shape{t2.Shape()[0], t1.Shape()[1]}
 	t1Shape := t1.Shape()
@@ -158,7 +181,64 @@ func (c CPU[T]) MatMul(t1, t2 *tensor.Tensor[T]) *tensor.Tensor[T] {
 			tTGrad := transpose(tGrad, w2, h1)
 			t1TElements := transpose(t1.Elements(), w1, h1)
 			t2Grad := t2.Grad()
-			matmul(t2Grad, t1TElements, tTGrad, h1, w1, h1)
+			matmul(t2Grad, t1TElements, tTGrad, w2, w1, h1)
+		}
+	}
+
+	return tensor.Op(shape, parents, forward, backward)
+}
+
+// BatchMatMul returns a new Tensor with the matrix multiplication of every
+// pair of matrices of the two tensors. The first two dimensions of every
+// tensor are the matrices as in MatMul and the rest are the batch, which must
+// be the same for both tensors. Panics if the shapes are incompatible.
+func (c CPU[T]) BatchMatMul(t1, t2 *tensor.Tensor[T]) *tensor.Tensor[T] {
+	t1Shape := t1.Shape()
+	t2Shape := t2.Shape()
+	if len(t1Shape) < 2 || len(t1Shape) != len(t2Shape) || !tensor.SameShape(t1Shape[2:], t2Shape[2:]) || t1Shape[0] != t2Shape[1] {
+		panic(fmt.Sprintf("incompatible shapes for batch matrix multiplication %v and %v", t1Shape, t2Shape))
+	}
+
+	w1, h1 := t1Shape[0], t1Shape[1]
+	w2, h2 := t2Shape[0], t2Shape[1]
+	batch := tensor.Size(t1Shape[2:])
+	size1, size2, size := int(w1*h1), int(w2*h2), int(w2*h1)
+
+	shape := make(tensor.Shape, 0, len(t1Shape))
+	shape = append(shape, w2, h1)
+	shape = append(shape, t1Shape[2:]...)
+
+	forward := func() []T {
+		t1Elements := t1.Elements()
+		t2Elements := t2.Elements()
+		m := make([]T, size*batch)
+		for b := 0; b < batch; b++ {
+			m2 := transpose(t2Elements[b*size2:(b+1)*size2], w2, h2)
+			matmul(m[b*size:(b+1)*size], t1Elements[b*size1:(b+1)*size1], m2, w2, h1, w1)
+		}
+		return m
+	}
+
+	parents := []*tensor.Tensor[T]{t1, t2}
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(t *tensor.Tensor[T]) {
+			tGrad := t.Grad()
+			t1Elements := t1.Elements()
+			t2Elements := t2.Elements()
+			t1Grad := t1.Grad()
+			t2Grad := t2.Grad()
+			for b := 0; b < batch; b++ {
+				g := tGrad[b*size : (b+1)*size]
+
+				//dL/dA = dL/dC @ B^T
+				matmul(t1Grad[b*size1:(b+1)*size1], g, t2Elements[b*size2:(b+1)*size2], h2, h1, w2)
+
+				//dL/dB = A^T @ dL/dC
+				gT := transpose(g, w2, h1)
+				t1T := transpose(t1Elements[b*size1:(b+1)*size1], w1, h1)
+				matmul(t2Grad[b*size2:(b+1)*size2], t1T, gT, w2, w1, h1)
+			}
 		}
 	}
 
@@ -355,6 +435,43 @@ func (c CPU[T]) Mul(t *tensor.Tensor[T], scale T) *tensor.Tensor[T] {
 	return tensor.Op(shape, parents, forward, backward)
 }
 
+// Hadamard returns a new tensor that is the result of multiplying the two
+// tensors element by element. Panics if the two tensors do not have the same
+// shape.
+func (c CPU[T]) Hadamard(t1, t2 *tensor.Tensor[T]) *tensor.Tensor[T] {
+	if !tensor.EqualShape(t1, t2) {
+		panic("tensors must have the same shape")
+	}
+
+	shape := t1.Shape()
+	parents := []*tensor.Tensor[T]{t1, t2}
+	forward := func() []T {
+		t1Elements := t1.Elements()
+		t2Elements := t2.Elements()
+		elements := make([]T, len(t1Elements))
+		for i := range elements {
+			elements[i] = t1Elements[i] * t2Elements[i]
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[T]) {
+			t1Elements := t1.Elements()
+			t2Elements := t2.Elements()
+			t1Grad := t1.Grad()
+			t2Grad := t2.Grad()
+			for i, g := range tOut.Grad() {
+				t1Grad[i] += g * t2Elements[i]
+				t2Grad[i] += g * t1Elements[i]
+			}
+		}
+	}
+
+	return tensor.Op(shape, parents, forward, backward)
+}
+
 func powInt[T constraints.Number](n T, exponent uint) T {
 	switch exponent {
 	case 0:
@@ -370,7 +487,7 @@ func powInt[T constraints.Number](n T, exponent uint) T {
 	}
 }
 
-func transpose[T constraints.Number](elements []T, w, h uint) []T {
+func transpose[T constraints.Element](elements []T, w, h uint) []T {
 	tElements := make([]T, len(elements))
 
 	for i := uint(0); i < w; i++ {
@@ -381,7 +498,7 @@ func transpose[T constraints.Number](elements []T, w, h uint) []T {
 	return tElements
 }
 
-func matmul[T constraints.Number](m, m1, m2 []T, w, h, l uint) {
+func matmul[T constraints.Element](m, m1, m2 []T, w, h, l uint) {
 	// this function expect m2 to be transposed
 	// w and h are width and height of the output matrix
 	// l is the common dimension
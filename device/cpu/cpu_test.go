@@ -113,6 +113,61 @@ func TestMatMulGrad2D(t *testing.T) {
 	}
 }
 
+func TestMatMulGradNonSquare(t *testing.T) {
+	d := cpu.New[int16](cpu.WithGrad(true))
+	t1 := tensor.New(tensor.Shape{2, 3}, []int16{1, 2, 3, 4, 5, 6})
+	t2 := tensor.New(tensor.Shape{4, 2}, []int16{1, 2, 3, 4, 5, 6, 7, 8})
+	t3 := d.MatMul(t1, t2)
+	t3.Backward()
+
+	expected := []int16{10, 26, 10, 26, 10, 26}
+	for i, g := range t1.Grad() {
+		if g != expected[i] {
+			t.Errorf("%s: gradient failed. expected=%d got=%d", t.Name(), expected[i], g)
+		}
+	}
+
+	expected = []int16{9, 9, 9, 9, 12, 12, 12, 12}
+	for i, g := range t2.Grad() {
+		if g != expected[i] {
+			t.Errorf("%s: gradient failed. expected=%d got=%d", t.Name(), expected[i], g)
+		}
+	}
+}
+
+func TestBatchMatMul(t *testing.T) {
+	d := cpu.New[int16](cpu.WithGrad(true))
+	// two batches of the matrices of TestMatMulGradNonSquare, the elements of
+	// the first one reordered in the second batch
+	t1 := tensor.New(tensor.Shape{2, 3, 2}, []int16{1, 2, 3, 4, 5, 6, 1, 4, 2, 5, 3, 6})
+	t2 := tensor.New(tensor.Shape{4, 2, 2}, []int16{1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8})
+	t3 := d.BatchMatMul(t1, t2)
+
+	for b := uint(0); b < 2; b++ {
+		m1 := d.Slice(t1, tensor.All(), tensor.All(), tensor.Range{Start: int(b), Stop: int(b) + 1, Step: 1})
+		m2 := d.Slice(t2, tensor.All(), tensor.All(), tensor.Range{Start: int(b), Stop: int(b) + 1, Step: 1})
+		expected := d.MatMul(d.Reshape(m1, tensor.Shape{2, 3}), d.Reshape(m2, tensor.Shape{4, 2}))
+		actual := d.Reshape(d.Slice(t3, tensor.All(), tensor.All(), tensor.Range{Start: int(b), Stop: int(b) + 1, Step: 1}), tensor.Shape{4, 3})
+		if !tensor.Equal(actual, expected) {
+			t.Errorf("%s: batch %d failed expected=%v got=%v", t.Name(), b, expected, actual)
+		}
+	}
+
+	t3.Backward()
+	expected := []int16{10, 26, 10, 26, 10, 26, 10, 26, 10, 26, 10, 26}
+	for i, g := range t1.Grad() {
+		if g != expected[i] {
+			t.Errorf("%s: gradient failed. expected=%d got=%d", t.Name(), expected[i], g)
+		}
+	}
+	expected = []int16{9, 9, 9, 9, 12, 12, 12, 12, 6, 6, 6, 6, 15, 15, 15, 15}
+	for i, g := range t2.Grad() {
+		if g != expected[i] {
+			t.Errorf("%s: gradient failed. expected=%d got=%d", t.Name(), expected[i], g)
+		}
+	}
+}
+
 func TestTranspose(t *testing.T) {
 	d := cpu.New[float32]()
 
@@ -212,3 +267,23 @@ func TestMulGrad(t *testing.T) {
 		}
 	}
 }
+
+func TestHadamard(t *testing.T) {
+	d := cpu.New[int](cpu.WithGrad(true))
+	t1 := tensor.New(tensor.Shape{3}, []int{1, 2, 3})
+	t2 := tensor.New(tensor.Shape{3}, []int{4, 5, 6})
+	t3 := d.Hadamard(t1, t2)
+
+	expected := tensor.New(tensor.Shape{3}, []int{4, 10, 18})
+	if !tensor.Equal(t3, expected) {
+		t.Errorf("%s: Hadamard failed expected=%v got=%v", t.Name(), expected, t3)
+	}
+
+	t3.Backward()
+	for i := range t1.Grad() {
+		if t1.Grad()[i] != t2.Elements()[i] || t2.Grad()[i] != t1.Elements()[i] {
+			t.Errorf("%s: gradient failed got=%v,%v", t.Name(), t1.Grad(), t2.Grad())
+			break
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package cpu
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/blast-go/blast/tensor"
+)
+
+// Dropout returns a new tensor in which every element of t is zeroed with
+// probability p and the rest are scaled by 1/(1-p) so the expected value is
+// unchanged. The mask is sampled from rng when the operation is created and
+// kept for the backward pass. When training is false t is returned as is.
+// Panics if p is not in [0, 1).
+func (c CPU[T]) Dropout(t *tensor.Tensor[T], p float64, training bool, rng *rand.Rand) *tensor.Tensor[T] {
+	checkProbability(p)
+	if !training || p == 0 {
+		return t
+	}
+
+	mask := make([]bool, tensor.Size(t.Shape()))
+	for i := range mask {
+		mask[i] = rng.Float64() >= p
+	}
+	return c.dropout(t, mask, 1/(1-p), 0, 0)
+}
+
+// Dropout2D returns a new tensor in which every channel of every sample of t
+// is zeroed with probability p and the rest are scaled by 1/(1-p). The
+// channels are the second to last dimension, so t has shape {w, h, c, n}.
+// The mask is sampled from rng when the operation is created. When training
+// is false t is returned as is. Panics if p is not in [0, 1) or t has less
+// than 2 dimensions.
+func (c CPU[T]) Dropout2D(t *tensor.Tensor[T], p float64, training bool, rng *rand.Rand) *tensor.Tensor[T] {
+	checkProbability(p)
+	shape := t.Shape()
+	if len(shape) < 2 {
+		panic(fmt.Sprintf("channel dropout requires at least 2 dimensions got shape %v", shape))
+	}
+	if !training || p == 0 {
+		return t
+	}
+
+	inner := tensor.Size(shape[:len(shape)-2])
+	kept := make([]bool, tensor.Size(shape)/inner)
+	for i := range kept {
+		kept[i] = rng.Float64() >= p
+	}
+	mask := make([]bool, tensor.Size(shape))
+	for i := range mask {
+		mask[i] = kept[i/inner]
+	}
+	return c.dropout(t, mask, 1/(1-p), 0, 0)
+}
+
+// AlphaDropout returns a new tensor in which every element of t is set with
+// probability p to the negative saturation value of SELU, then all elements
+// are scaled and shifted so that the mean and variance of inputs with zero
+// mean and unit variance are kept. It is meant to be used together with SELU.
+// The mask is sampled from rng when the operation is created. When training
+// is false t is returned as is. Panics if p is not in [0, 1).
+func (c CPU[T]) AlphaDropout(t *tensor.Tensor[T], p float64, training bool, rng *rand.Rand) *tensor.Tensor[T] {
+	checkProbability(p)
+	if !training || p == 0 {
+		return t
+	}
+
+	mask := make([]bool, tensor.Size(t.Shape()))
+	for i := range mask {
+		mask[i] = rng.Float64() >= p
+	}
+
+	saturation := -seluScale * seluAlpha
+	a := 1 / math.Sqrt((1-p)*(1+p*saturation*saturation))
+	b := -a * saturation * p
+	return c.dropout(t, mask, a, b, a*saturation+b)
+}
+
+// dropout returns a new tensor in which the elements of t where mask is true
+// become scale*x + shift and the rest become dropped. Gradients only flow to
+// the kept elements.
+func (c CPU[T]) dropout(t *tensor.Tensor[T], mask []bool, scale, shift, dropped float64) *tensor.Tensor[T] {
+	parents := []*tensor.Tensor[T]{t}
+	forward := func() []T {
+		tElements := t.Elements()
+		elements := make([]T, len(tElements))
+		for i, e := range tElements {
+			if mask[i] {
+				elements[i] = T(scale*float64(e) + shift)
+			} else {
+				elements[i] = T(dropped)
+			}
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[T]) {
+			tGrad := t.Grad()
+			for i, g := range tOut.Grad() {
+				if mask[i] {
+					tGrad[i] += T(float64(g) * scale)
+				}
+			}
+		}
+	}
+
+	return tensor.Op(tensor.CopyShape(t.Shape()), parents, forward, backward)
+}
+
+func checkProbability(p float64) {
+	if p < 0 || p >= 1 {
+		panic(fmt.Sprintf("invalid probability %f must be in [0, 1)", p))
+	}
+}
@@ -0,0 +1,97 @@
+package cpu_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/tensor"
+)
+
+func TestDropout(t *testing.T) {
+	d := cpu.New[float64](cpu.WithGrad(true))
+	t1 := tensor.Ones[float64](tensor.Shape{100, 10})
+
+	t2 := d.Dropout(t1, 0.25, true, rand.New(rand.NewSource(1)))
+	kept := 0
+	for i, e := range t2.Elements() {
+		switch {
+		case e == 0:
+		case math.Abs(e-1/0.75) < 1e-12:
+			kept++
+		default:
+			t.Fatalf("%s: invalid element %d=%f", t.Name(), i, e)
+		}
+	}
+	if kept < 700 || kept > 800 {
+		t.Errorf("%s: expected about 750 kept elements got=%d", t.Name(), kept)
+	}
+
+	t3 := d.Dropout(t1, 0.25, true, rand.New(rand.NewSource(1)))
+	if !tensor.Equal(t2, t3) {
+		t.Errorf("%s: masks with the same seed should be equal", t.Name())
+	}
+
+	t2.Backward()
+	for i, g := range t1.Grad() {
+		if g != t2.Elements()[i] {
+			t.Errorf("%s: gradient failed expected=%f got=%f", t.Name(), t2.Elements()[i], g)
+			break
+		}
+	}
+}
+
+func TestDropoutEval(t *testing.T) {
+	d := cpu.New[float64]()
+	t1 := tensor.Rand[float64](tensor.Shape{4, 4})
+	if d.Dropout(t1, 0.5, false, nil) != t1 || d.AlphaDropout(t1, 0.5, false, nil) != t1 {
+		t.Errorf("%s: dropout should be the identity in evaluation", t.Name())
+	}
+}
+
+func TestDropout2D(t *testing.T) {
+	d := cpu.New[float64]()
+	// 3x2 images with 8 channels and 4 samples
+	t1 := tensor.Ones[float64](tensor.Shape{3, 2, 8, 4})
+
+	elements := d.Dropout2D(t1, 0.5, true, rand.New(rand.NewSource(2))).Elements()
+	dropped := 0
+	for c := 0; c < len(elements); c += 6 {
+		for _, e := range elements[c : c+6] {
+			if e != elements[c] {
+				t.Fatalf("%s: channel %d partially dropped %v", t.Name(), c/6, elements[c:c+6])
+			}
+		}
+		if elements[c] == 0 {
+			dropped++
+		}
+	}
+	if dropped == 0 || dropped == 32 {
+		t.Errorf("%s: expected some channels dropped got=%d", t.Name(), dropped)
+	}
+}
+
+func TestAlphaDropout(t *testing.T) {
+	d := cpu.New[float64]()
+	rng := rand.New(rand.NewSource(3))
+	n := 100000
+	elements := make([]float64, n)
+	for i := range elements {
+		elements[i] = rng.NormFloat64()
+	}
+
+	out := d.AlphaDropout(tensor.New(tensor.Shape{uint(n)}, elements), 0.2, true, rng).Elements()
+	mean, variance := 0.0, 0.0
+	for _, e := range out {
+		mean += e
+	}
+	mean /= float64(n)
+	for _, e := range out {
+		variance += (e - mean) * (e - mean)
+	}
+	variance /= float64(n)
+	if math.Abs(mean) > 0.02 || math.Abs(variance-1) > 0.03 {
+		t.Errorf("%s: expected zero mean and unit variance got mean=%f variance=%f", t.Name(), mean, variance)
+	}
+}
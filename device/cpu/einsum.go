@@ -0,0 +1,307 @@
+package cpu
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/tensor"
+)
+
+// Einsum returns the contraction of the tensors described by spec in
+// Einstein summation notation, like "ij,jk->ik". Every operand is labelled by
+// one letter per dimension, in the order of its shape, and the letters after
+// the arrow label the dimensions of the result. Letters missing from the
+// result are summed over, repeated letters in an operand take its diagonal.
+// Without an arrow the result has the letters that appear only once, in
+// alphabetical order. A result without letters has shape {1}.
+//
+// Operands are contracted by pairs, choosing every time the pair with the
+// smallest result, and every contraction is computed with BatchMatMul so the
+// result supports autograd. Panics if spec is invalid or doesn't match the
+// shapes of the tensors.
+func (c CPU[T]) Einsum(spec string, ts ...*tensor.Tensor[T]) *tensor.Tensor[T] {
+	inputs, output := parseEinsum(spec, len(ts))
+
+	dims := make(map[rune]uint)
+	operands := make([]einsumOperand[T], len(ts))
+	for i, t := range ts {
+		shape := t.Shape()
+		if len(inputs[i]) != len(shape) {
+			panic(fmt.Sprintf("subscripts %q don't match shape %v", string(inputs[i]), shape))
+		}
+		for d, l := range inputs[i] {
+			if n, ok := dims[l]; ok && n != shape[d] {
+				panic(fmt.Sprintf("dimension %q has sizes %d and %d", l, n, shape[d]))
+			}
+			dims[l] = shape[d]
+		}
+		operands[i] = c.diagonal(t, inputs[i])
+	}
+	for _, l := range output {
+		if _, ok := dims[l]; !ok {
+			panic(fmt.Sprintf("output subscript %q is not in the inputs", l))
+		}
+	}
+
+	// needed returns whether the letter is used by the result or by an
+	// operand other than the excluded ones
+	needed := func(l rune, excluded ...int) bool {
+		if containsRune(output, l) {
+			return true
+		}
+	operands:
+		for i, o := range operands {
+			for _, e := range excluded {
+				if i == e {
+					continue operands
+				}
+			}
+			if o.has(l) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// letters only used by a single operand are summed before contracting
+	for i, o := range operands {
+		var sum []rune
+		for _, l := range o.letters {
+			if !needed(l, i) {
+				sum = append(sum, l)
+			}
+		}
+		operands[i] = c.sumLetters(o, sum)
+	}
+
+	for len(operands) > 1 {
+		// the pair with the smallest result
+		best, bestI, bestJ := -1, 0, 0
+		for i := range operands {
+			for j := i + 1; j < len(operands); j++ {
+				n := 1
+				for _, l := range union(operands[i].letters, operands[j].letters) {
+					if needed(l, i, j) {
+						n *= int(dims[l])
+					}
+				}
+				if best == -1 || n < best {
+					best, bestI, bestJ = n, i, j
+				}
+			}
+		}
+
+		var keep []rune
+		for _, l := range union(operands[bestI].letters, operands[bestJ].letters) {
+			if needed(l, bestI, bestJ) {
+				keep = append(keep, l)
+			}
+		}
+		result := c.contract(operands[bestI], operands[bestJ], keep)
+		operands = append(operands[:bestJ], operands[bestJ+1:]...)
+		operands[bestI] = result
+	}
+
+	result := operands[0]
+	if len(output) == 0 {
+		return c.Reshape(result.t, tensor.Shape{1})
+	}
+	return result.permute(c, output)
+}
+
+// einsumOperand is a tensor with a letter for every dimension. Operands
+// without letters have shape {1}.
+type einsumOperand[T constraints.Number] struct {
+	t       *tensor.Tensor[T]
+	letters []rune
+}
+
+func (o einsumOperand[T]) has(l rune) bool {
+	return containsRune(o.letters, l)
+}
+
+// permute returns the tensor of the operand with its dimensions in the order
+// of letters, which must be a permutation of the letters of the operand.
+func (o einsumOperand[T]) permute(c CPU[T], letters []rune) *tensor.Tensor[T] {
+	if len(letters) == 0 {
+		return o.t
+	}
+	return c.Permute(o.t, o.axes(letters)...)
+}
+
+// axes returns the positions of the letters in the operand.
+func (o einsumOperand[T]) axes(letters []rune) []int {
+	axes := make([]int, len(letters))
+	for i, l := range letters {
+		axes[i] = strings.IndexRune(string(o.letters), l)
+	}
+	return axes
+}
+
+// shape returns the dimensions of the given letters.
+func (o einsumOperand[T]) shape(letters []rune) tensor.Shape {
+	shape := make(tensor.Shape, len(letters))
+	for i, axis := range o.axes(letters) {
+		shape[i] = o.t.Shape()[axis]
+	}
+	return shape
+}
+
+// parseEinsum returns the letters of each of the n inputs and of the output
+// of spec.
+func parseEinsum(spec string, n int) ([][]rune, []rune) {
+	spec = strings.ReplaceAll(spec, " ", "")
+	parts := strings.Split(spec, "->")
+	if len(parts) > 2 {
+		panic(fmt.Sprintf("invalid einsum %q", spec))
+	}
+
+	var inputs [][]rune
+	for _, input := range strings.Split(parts[0], ",") {
+		inputs = append(inputs, []rune(input))
+	}
+	if len(inputs) != n {
+		panic(fmt.Sprintf("einsum %q expects %d tensors got %d", spec, len(inputs), n))
+	}
+
+	counts := make(map[rune]int)
+	for _, input := range inputs {
+		for _, l := range input {
+			if !(l >= 'a' && l <= 'z' || l >= 'A' && l <= 'Z') {
+				panic(fmt.Sprintf("invalid subscript %q in einsum %q", l, spec))
+			}
+			counts[l]++
+		}
+	}
+
+	var output []rune
+	if len(parts) == 2 {
+		output = []rune(parts[1])
+		for i, l := range output {
+			if containsRune(output[:i], l) {
+				panic(fmt.Sprintf("output subscript %q repeated in einsum %q", l, spec))
+			}
+		}
+	} else {
+		for l, count := range counts {
+			if count == 1 {
+				output = append(output, l)
+			}
+		}
+		sort.Slice(output, func(i, j int) bool { return output[i] < output[j] })
+	}
+	return inputs, output
+}
+
+// diagonal returns the operand for t labelled with letters in which the
+// repeated letters are replaced by a single dimension with their diagonal.
+func (c CPU[T]) diagonal(t *tensor.Tensor[T], letters []rune) einsumOperand[T] {
+	var unique []rune
+	var shape tensor.Shape
+	for d, l := range letters {
+		if !containsRune(unique, l) {
+			unique = append(unique, l)
+			shape = append(shape, t.Shape()[d])
+		}
+	}
+	if len(unique) == len(letters) {
+		return einsumOperand[T]{t: t, letters: letters}
+	}
+
+	// every dimension of t takes the coordinate of its letter
+	tStrides := strides(t.Shape())
+	index := make([]int, tensor.Size(shape))
+	cords := make([]uint, len(shape))
+	for i := range index {
+		for d, l := range letters {
+			index[i] += int(cords[strings.IndexRune(string(unique), l)]) * tStrides[d]
+		}
+		increment(cords, shape)
+	}
+	return einsumOperand[T]{t: c.gather(t, shape, index), letters: unique}
+}
+
+// sumLetters returns the operand with the dimensions of the given letters
+// summed, computed as the product with a vector of ones.
+func (c CPU[T]) sumLetters(o einsumOperand[T], sum []rune) einsumOperand[T] {
+	if len(sum) == 0 {
+		return o
+	}
+	var keep []rune
+	for _, l := range o.letters {
+		if !containsRune(sum, l) {
+			keep = append(keep, l)
+		}
+	}
+
+	k, m := tensor.Size(o.shape(sum)), tensor.Size(o.shape(keep))
+	t := c.Reshape(o.permute(c, concatRunes(sum, keep)), tensor.Shape{uint(k), uint(m)})
+	t = c.MatMul(t, tensor.Ones[T](tensor.Shape{1, uint(k)}))
+	if len(keep) == 0 {
+		return einsumOperand[T]{t: c.Reshape(t, tensor.Shape{1})}
+	}
+	return einsumOperand[T]{t: c.Reshape(t, o.shape(keep)), letters: keep}
+}
+
+// contract returns the operand with the contraction of a and b keeping the
+// given letters. Letters of both operands that are kept are a batch of the
+// matrix multiplication and the rest are contracted.
+func (c CPU[T]) contract(a, b einsumOperand[T], keep []rune) einsumOperand[T] {
+	var batch, contracted, freeA, freeB []rune
+	for _, l := range a.letters {
+		switch {
+		case b.has(l) && containsRune(keep, l):
+			batch = append(batch, l)
+		case b.has(l):
+			contracted = append(contracted, l)
+		default:
+			freeA = append(freeA, l)
+		}
+	}
+	for _, l := range b.letters {
+		if !a.has(l) {
+			freeB = append(freeB, l)
+		}
+	}
+
+	// a as {contracted, freeA, batch} and b as {freeB, contracted, batch}
+	k, m, n := tensor.Size(a.shape(contracted)), tensor.Size(a.shape(freeA)), tensor.Size(b.shape(freeB))
+	nBatch := tensor.Size(a.shape(batch))
+	at := a.permute(c, concatRunes(contracted, freeA, batch))
+	bt := b.permute(c, concatRunes(freeB, contracted, batch))
+	at = c.Reshape(at, tensor.Shape{uint(k), uint(m), uint(nBatch)})
+	bt = c.Reshape(bt, tensor.Shape{uint(n), uint(k), uint(nBatch)})
+	t := c.BatchMatMul(at, bt)
+
+	letters := concatRunes(freeB, freeA, batch)
+	if len(letters) == 0 {
+		return einsumOperand[T]{t: c.Reshape(t, tensor.Shape{1})}
+	}
+	shape := append(append(b.shape(freeB), a.shape(freeA)...), a.shape(batch)...)
+	return einsumOperand[T]{t: c.Reshape(t, shape), letters: letters}
+}
+
+// union returns the letters of a followed by the ones of b not in a.
+func union(a, b []rune) []rune {
+	letters := append([]rune(nil), a...)
+	for _, l := range b {
+		if !containsRune(a, l) {
+			letters = append(letters, l)
+		}
+	}
+	return letters
+}
+
+func containsRune(letters []rune, l rune) bool {
+	return strings.ContainsRune(string(letters), l)
+}
+
+func concatRunes(letters ...[]rune) []rune {
+	var all []rune
+	for _, l := range letters {
+		all = append(all, l...)
+	}
+	return all
+}
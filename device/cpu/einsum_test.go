@@ -0,0 +1,165 @@
+package cpu_test
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/tensor"
+)
+
+// naiveEinsum computes an einsum with explicit output iterating over every
+// assignment of the letters.
+func naiveEinsum(spec string, ts ...*tensor.Tensor[float64]) []float64 {
+	parts := strings.Split(spec, "->")
+	inputs := strings.Split(parts[0], ",")
+	dims := map[rune]uint{}
+	for i, input := range inputs {
+		for d, l := range input {
+			dims[l] = ts[i].Shape()[d]
+		}
+	}
+	var letters []rune
+	for l := range dims {
+		letters = append(letters, l)
+	}
+	sort.Slice(letters, func(i, j int) bool { return letters[i] < letters[j] })
+
+	outSize := uint(1)
+	for _, l := range parts[1] {
+		outSize *= dims[l]
+	}
+	out := make([]float64, outSize)
+
+	values := map[rune]uint{}
+	var loop func(n int)
+	loop = func(n int) {
+		if n < len(letters) {
+			for i := uint(0); i < dims[letters[n]]; i++ {
+				values[letters[n]] = i
+				loop(n + 1)
+			}
+			return
+		}
+		p := 1.0
+		for i, input := range inputs {
+			cords := make([]uint, 0, len(input))
+			for _, l := range input {
+				cords = append(cords, values[l])
+			}
+			p *= ts[i].Get(cords...)
+		}
+		index, stride := uint(0), uint(1)
+		for _, l := range parts[1] {
+			index += values[l] * stride
+			stride *= dims[l]
+		}
+		out[index] += p
+	}
+	loop(0)
+	return out
+}
+
+func TestEinsum(t *testing.T) {
+	d := cpu.New[float64]()
+	a := tensor.Rand[float64](tensor.Shape{3, 4})
+	b := tensor.Rand[float64](tensor.Shape{4, 5})
+	c := tensor.Rand[float64](tensor.Shape{5, 2})
+	batch1 := tensor.Rand[float64](tensor.Shape{3, 4, 2})
+	batch2 := tensor.Rand[float64](tensor.Shape{4, 5, 2})
+	square := tensor.Rand[float64](tensor.Shape{3, 3})
+	cube := tensor.Rand[float64](tensor.Shape{3, 3, 4})
+
+	cases := []struct {
+		spec, explicit string
+		ts             []*tensor.Tensor[float64]
+	}{
+		{"ij,jk->ik", "", []*tensor.Tensor[float64]{a, b}},
+		{"ij,jk", "ij,jk->ik", []*tensor.Tensor[float64]{a, b}},
+		{"ij,jk,kl->li", "", []*tensor.Tensor[float64]{a, b, c}},
+		{"ijb,jkb->kib", "", []*tensor.Tensor[float64]{batch1, batch2}},
+		{"ij->ji", "", []*tensor.Tensor[float64]{a}},
+		{"ij->j", "", []*tensor.Tensor[float64]{a}},
+		{"ij->", "", []*tensor.Tensor[float64]{a}},
+		{"ii->i", "", []*tensor.Tensor[float64]{square}},
+		{"ii", "ii->", []*tensor.Tensor[float64]{square}},
+		{"iij,jk->ik", "", []*tensor.Tensor[float64]{cube, b}},
+		{"ij,ij->ij", "", []*tensor.Tensor[float64]{a, a}},
+		{"ij,ij->", "", []*tensor.Tensor[float64]{a, a}},
+		{"i,k->ik", "", []*tensor.Tensor[float64]{d.Reshape(a, tensor.Shape{12}), d.Reshape(c, tensor.Shape{10})}},
+		{"ijb,jkb,kl -> lib", "", []*tensor.Tensor[float64]{batch1, batch2, c}},
+	}
+
+	for _, tc := range cases {
+		explicit := tc.explicit
+		if explicit == "" {
+			explicit = strings.ReplaceAll(tc.spec, " ", "")
+		}
+		expected := naiveEinsum(explicit, tc.ts...)
+		actual := d.Einsum(tc.spec, tc.ts...).Elements()
+		if len(actual) != len(expected) {
+			t.Errorf("%s: %s invalid number of elements expected=%d got=%d", t.Name(), tc.spec, len(expected), len(actual))
+			continue
+		}
+		for i, e := range expected {
+			if math.Abs(actual[i]-e) > 1e-12 {
+				t.Errorf("%s: %s failed expected=%v got=%v", t.Name(), tc.spec, expected, actual)
+				break
+			}
+		}
+	}
+
+	if !tensor.EqualShape(d.Einsum("ijb,jkb->kib", batch1, batch2), tensor.Zeros[float64](tensor.Shape{5, 3, 2})) {
+		t.Errorf("%s: invalid shape", t.Name())
+	}
+}
+
+func TestEinsumGrad(t *testing.T) {
+	d := cpu.New[float64](cpu.WithGrad(true))
+	a := tensor.Rand[float64](tensor.Shape{3, 3, 2})
+	b := tensor.Rand[float64](tensor.Shape{2, 4})
+	c := tensor.Rand[float64](tensor.Shape{4, 3})
+	spec := "iij,jk,kl->il"
+	loss := func() float64 {
+		s := 0.0
+		for _, e := range d.PowInt(d.Einsum(spec, a, b, c), 2).Elements() {
+			s += e
+		}
+		return s
+	}
+	d.PowInt(d.Einsum(spec, a, b, c), 2).Backward()
+
+	h := 1e-6
+	for name, p := range map[string]*tensor.Tensor[float64]{"a": a, "b": b, "c": c} {
+		elements := p.Elements()
+		for i, e := range elements {
+			elements[i] = e + h
+			plus := loss()
+			elements[i] = e - h
+			minus := loss()
+			elements[i] = e
+
+			numeric := (plus - minus) / (2 * h)
+			if math.Abs(p.Grad()[i]-numeric) > 1e-5 {
+				t.Errorf("%s: gradient of %s failed at %d expected=%f got=%f", t.Name(), name, i, numeric, p.Grad()[i])
+			}
+		}
+	}
+}
+
+func TestEinsumInvalid(t *testing.T) {
+	d := cpu.New[float64]()
+	a := tensor.Zeros[float64](tensor.Shape{2, 3})
+	for _, spec := range []string{"ij,jk->ik", "ijk,ij->i", "ij,ij->ii", "ij,ij->k", "i1,ij->i", "ij,ji", "ij->i"} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("%s: %s should panic", t.Name(), spec)
+				}
+			}()
+			d.Einsum(spec, a, a)
+		}()
+	}
+}
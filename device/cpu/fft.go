@@ -0,0 +1,383 @@
+package cpu
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"math/cmplx"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/tensor"
+)
+
+// The spectral operations are computed in complex128 on tensors of complex
+// numbers. The transforms of real signals, RFFT and STFT, and IRFFT convert
+// between real and complex tensors, so they are functions of the device of
+// their result and gradients flow between both.
+
+// FFT returns the discrete Fourier transform of the signals of shape
+// {n, batch...} along their first dimension. The transform is not
+// normalized.
+func (c ComplexCPU[T]) FFT(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return c.fftAxis(t, 0, false)
+}
+
+// IFFT returns the inverse discrete Fourier transform, normalized by 1/n, of
+// the signals of shape {n, batch...}.
+func (c ComplexCPU[T]) IFFT(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return c.fftAxis(t, 0, true)
+}
+
+// FFT2 returns the two dimensional discrete Fourier transform of the signals
+// of shape {w, h, batch...}. Panics if the tensor has less than two
+// dimensions.
+func (c ComplexCPU[T]) FFT2(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+	checkAxis(1, len(t.Shape()))
+	return c.fftAxis(c.fftAxis(t, 0, false), 1, false)
+}
+
+// IFFT2 returns the inverse of FFT2, normalized by 1/(w*h).
+func (c ComplexCPU[T]) IFFT2(t *tensor.Tensor[T]) *tensor.Tensor[T] {
+	checkAxis(1, len(t.Shape()))
+	return c.fftAxis(c.fftAxis(t, 0, true), 1, true)
+}
+
+// RFFT returns the discrete Fourier transform of the real signals of shape
+// {n, batch...}. Only the n/2+1 non negative frequencies are computed, the
+// others are their conjugates, so the result has shape {n/2+1, batch...}.
+func RFFT[C constraints.Complex, R constraints.Number](c ComplexCPU[C], t *tensor.Tensor[R]) *tensor.Tensor[C] {
+	tShape := t.Shape()
+	n := int(tShape[0])
+	h := n/2 + 1
+	batch := tensor.Size(tShape[1:])
+	shape := append(tensor.Shape{uint(h)}, tShape[1:]...)
+
+	forward := func() []C {
+		tElements := t.Elements()
+		elements := make([]C, h*batch)
+		x := make([]float64, n)
+		for b := 0; b < batch; b++ {
+			for j := range x {
+				x[j] = float64(tElements[b*n+j])
+			}
+			for k, e := range rfft(x) {
+				elements[b*h+k] = C(e)
+			}
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[C]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[C]) {
+			tGrad := t.Grad()
+			grad := tOut.Grad()
+			for b := 0; b < batch; b++ {
+				for j, g := range rfftGrad(widen(grad[b*h:(b+1)*h]), n) {
+					tGrad[b*n+j] += R(g)
+				}
+			}
+		}
+	}
+
+	return tensor.MixedOp(shape, []tensor.Node{t}, forward, backward)
+}
+
+// IRFFT returns the real signals of length n of shape {n, batch...} whose
+// RFFT is t, of shape {n/2+1, batch...}. The imaginary parts of the
+// frequencies that must be real are ignored. Panics if the shape of t doesn't
+// match n.
+func IRFFT[R constraints.Number, C constraints.Complex](c CPU[R], t *tensor.Tensor[C], n int) *tensor.Tensor[R] {
+	tShape := t.Shape()
+	h := n/2 + 1
+	if n <= 0 || int(tShape[0]) != h {
+		panic(fmt.Sprintf("invalid length %d for the transform of shape %v", n, tShape))
+	}
+	batch := tensor.Size(tShape[1:])
+	shape := append(tensor.Shape{uint(n)}, tShape[1:]...)
+
+	forward := func() []R {
+		tElements := t.Elements()
+		elements := make([]R, n*batch)
+		x := make([]complex128, n)
+		for b := 0; b < batch; b++ {
+			y := widen(tElements[b*h : (b+1)*h])
+			// the negative frequencies are the conjugates of the positive
+			copy(x, y)
+			for k := h; k < n; k++ {
+				x[k] = cmplx.Conj(y[n-k])
+			}
+			dft(x, true)
+			for j, e := range x {
+				elements[b*n+j] = R(real(e) / float64(n))
+			}
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[R]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[R]) {
+			tGrad := t.Grad()
+			grad := tOut.Grad()
+			g := make([]float64, n)
+			for b := 0; b < batch; b++ {
+				for j := range g {
+					g[j] = float64(grad[b*n+j])
+				}
+				// every positive frequency but the first and the one of
+				// n/2 appears twice in the signal
+				for k, e := range rfft(g) {
+					w := 2.0
+					if k == 0 || 2*k == n {
+						w = 1
+					}
+					tGrad[b*h+k] += C(e * complex(w/float64(n), 0))
+				}
+			}
+		}
+	}
+
+	return tensor.MixedOp(shape, []tensor.Node{t}, forward, backward)
+}
+
+// STFT returns the short-time Fourier transform of the real signals of shape
+// {length, batch...}. The signals are split in frames of nfft elements
+// starting every hop elements, and the RFFT of every frame multiplied by the
+// window of shape {nfft} is computed, a nil window being a rectangular one.
+// The result has shape {nfft/2+1, frames, batch...} with
+// frames = 1 + (length-nfft)/hop. Gradients flow to the signals and the
+// window. Panics if the signals are shorter than a frame, hop is not positive
+// or the window doesn't match the frames.
+func STFT[C constraints.Complex, R constraints.Number](c ComplexCPU[C], t, window *tensor.Tensor[R], nfft, hop int) *tensor.Tensor[C] {
+	tShape := t.Shape()
+	length := int(tShape[0])
+	if nfft <= 0 || hop <= 0 || nfft > length {
+		panic(fmt.Sprintf("invalid frames of length %d and hop %d for signals of shape %v", nfft, hop, tShape))
+	}
+	if window != nil && (len(window.Shape()) != 1 || int(window.Shape()[0]) != nfft) {
+		panic(fmt.Sprintf("invalid window of shape %v for frames of length %d", window.Shape(), nfft))
+	}
+	h := nfft/2 + 1
+	frames := 1 + (length-nfft)/hop
+	batch := tensor.Size(tShape[1:])
+	shape := append(tensor.Shape{uint(h), uint(frames)}, tShape[1:]...)
+
+	windowAt := func(j int) float64 {
+		if window == nil {
+			return 1
+		}
+		return float64(window.Elements()[j])
+	}
+
+	forward := func() []C {
+		tElements := t.Elements()
+		elements := make([]C, h*frames*batch)
+		x := make([]float64, nfft)
+		for b := 0; b < batch; b++ {
+			for f := 0; f < frames; f++ {
+				start := b*length + f*hop
+				for j := range x {
+					x[j] = float64(tElements[start+j]) * windowAt(j)
+				}
+				for k, e := range rfft(x) {
+					elements[h*(b*frames+f)+k] = C(e)
+				}
+			}
+		}
+		return elements
+	}
+
+	parents := []tensor.Node{t}
+	if window != nil {
+		parents = append(parents, window)
+	}
+	var backward tensor.BackwardFunc[C]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[C]) {
+			tElements, tGrad := t.Elements(), t.Grad()
+			grad := tOut.Grad()
+			var windowGrad []R
+			if window != nil {
+				windowGrad = window.Grad()
+			}
+			for b := 0; b < batch; b++ {
+				for f := 0; f < frames; f++ {
+					start := b*length + f*hop
+					frame := h * (b*frames + f)
+					for j, e := range rfftGrad(widen(grad[frame:frame+h]), nfft) {
+						tGrad[start+j] += R(e * windowAt(j))
+						if windowGrad != nil {
+							windowGrad[j] += R(e * float64(tElements[start+j]))
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return tensor.MixedOp(shape, parents, forward, backward)
+}
+
+// fftAxis returns the discrete Fourier transform of a tensor along the given
+// axis.
+func (c ComplexCPU[T]) fftAxis(t *tensor.Tensor[T], axis int, inverse bool) *tensor.Tensor[T] {
+	shape := t.Shape()
+	checkAxis(axis, len(shape))
+
+	forward := func() []T {
+		elements := make([]T, len(t.Elements()))
+		transformAxis(elements, t.Elements(), shape, axis, inverse, inverse)
+		return elements
+	}
+
+	parents := []*tensor.Tensor[T]{t}
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		// the gradient is the conjugate transpose of the transform, a
+		// transform in the opposite direction with the same normalization
+		backward = func(tOut *tensor.Tensor[T]) {
+			transformAxis(t.Grad(), tOut.Grad(), shape, axis, !inverse, inverse)
+		}
+	}
+
+	return tensor.Op(tensor.CopyShape(shape), parents, forward, backward)
+}
+
+// transformAxis adds to dst the transform of the elements of src along the
+// given axis, divided by its length when normalize is true.
+func transformAxis[T constraints.Complex](dst, src []T, shape tensor.Shape, axis int, inverse, normalize bool) {
+	n := int(shape[axis])
+	// distance between consecutive elements of the axis
+	stride := tensor.Size(shape[:axis])
+	outer := tensor.Size(shape[axis+1:])
+	scale := complex(1, 0)
+	if normalize {
+		scale = complex(1/float64(n), 0)
+	}
+
+	x := make([]complex128, n)
+	for o := 0; o < outer; o++ {
+		for s := 0; s < stride; s++ {
+			for j := range x {
+				x[j] = complex128(src[(o*n+j)*stride+s])
+			}
+			dft(x, inverse)
+			for j, e := range x {
+				dst[(o*n+j)*stride+s] += T(e * scale)
+			}
+		}
+	}
+}
+
+// rfft returns the n/2+1 non negative frequencies of the transform of the
+// real signal x.
+func rfft(x []float64) []complex128 {
+	y := make([]complex128, len(x))
+	for j, e := range x {
+		y[j] = complex(e, 0)
+	}
+	dft(y, false)
+	return y[:len(x)/2+1]
+}
+
+// rfftGrad returns the gradient of the real signal of length n given the
+// gradient of its non negative frequencies, the real part of their inverse
+// transform as if the others were zero.
+func rfftGrad(grad []complex128, n int) []float64 {
+	y := make([]complex128, n)
+	copy(y, grad)
+	dft(y, true)
+	g := make([]float64, n)
+	for j, e := range y {
+		g[j] = real(e)
+	}
+	return g
+}
+
+// dft replaces x by its discrete Fourier transform without normalization,
+// the inverse transform when inverse is true. Lengths that are powers of two
+// use the radix-2 algorithm, the others the Bluestein algorithm.
+func dft(x []complex128, inverse bool) {
+	n := len(x)
+	if n <= 1 {
+		return
+	}
+	if n&(n-1) == 0 {
+		radix2(x, inverse)
+		return
+	}
+
+	// with jk = (j^2 + k^2 - (k-j)^2) / 2 the transform is the convolution
+	// of x_j w_j with the conjugate of w, w_j = exp(-i pi j^2 / n)
+	sign := -1.0
+	if inverse {
+		sign = 1
+	}
+	w := make([]complex128, n)
+	for j := range w {
+		// j^2 mod 2n keeps the angle accurate for large j
+		angle := sign * math.Pi * float64((j*j)%(2*n)) / float64(n)
+		w[j] = cmplx.Rect(1, angle)
+	}
+	m := 1 << bits.Len(uint(2*n-2))
+	a := make([]complex128, m)
+	b := make([]complex128, m)
+	for j := 0; j < n; j++ {
+		a[j] = x[j] * w[j]
+	}
+	b[0] = cmplx.Conj(w[0])
+	for j := 1; j < n; j++ {
+		b[j] = cmplx.Conj(w[j])
+		b[m-j] = b[j]
+	}
+	radix2(a, false)
+	radix2(b, false)
+	for j := range a {
+		a[j] *= b[j]
+	}
+	radix2(a, true)
+	for k := 0; k < n; k++ {
+		x[k] = w[k] * a[k] / complex(float64(m), 0)
+	}
+}
+
+// radix2 replaces x, whose length is a power of two, by its discrete Fourier
+// transform without normalization.
+func radix2(x []complex128, inverse bool) {
+	n := len(x)
+	shift := 64 - bits.Len(uint(n-1))
+	for i := 0; i < n; i++ {
+		j := int(bits.Reverse64(uint64(i)) >> shift)
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	sign := -1.0
+	if inverse {
+		sign = 1
+	}
+	for length := 2; length <= n; length <<= 1 {
+		step := cmplx.Rect(1, sign*2*math.Pi/float64(length))
+		for start := 0; start < n; start += length {
+			w := complex(1, 0)
+			for k := 0; k < length/2; k++ {
+				u := x[start+k]
+				v := x[start+k+length/2] * w
+				x[start+k] = u + v
+				x[start+k+length/2] = u - v
+				w *= step
+			}
+		}
+	}
+}
+
+// widen returns the elements as complex128 numbers.
+func widen[T constraints.Complex](elements []T) []complex128 {
+	x := make([]complex128, len(elements))
+	for j, e := range elements {
+		x[j] = complex128(e)
+	}
+	return x
+}
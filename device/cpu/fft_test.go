@@ -0,0 +1,253 @@
+package cpu_test
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/tensor"
+)
+
+// naiveDFT computes the transform of every signal of length n of a tensor of
+// shape {n, batch...} from its definition.
+func naiveDFT(elements []complex128, n int, inverse bool) []complex128 {
+	sign := -1.0
+	if inverse {
+		sign = 1
+	}
+	out := make([]complex128, len(elements))
+	for b := 0; b < len(elements)/n; b++ {
+		for k := 0; k < n; k++ {
+			s := complex(0, 0)
+			for j := 0; j < n; j++ {
+				s += elements[b*n+j] * cmplx.Rect(1, sign*2*math.Pi*float64(j*k)/float64(n))
+			}
+			if inverse {
+				s /= complex(float64(n), 0)
+			}
+			out[b*n+k] = s
+		}
+	}
+	return out
+}
+
+func equalFloats(expected, actual []float64) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	for i, e := range expected {
+		if math.Abs(actual[i]-e) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+// toComplex returns the real signals as complex numbers.
+func toComplex(elements []float64) []complex128 {
+	z := make([]complex128, len(elements))
+	for i, e := range elements {
+		z[i] = complex(e, 0)
+	}
+	return z
+}
+
+func TestFFT(t *testing.T) {
+	d := cpu.NewComplex[complex128]()
+	// radix-2 and Bluestein lengths
+	for _, n := range []uint{1, 2, 8, 5, 6, 12} {
+		x := tensor.RandComplex[complex128](tensor.Shape{n, 3})
+
+		y := d.FFT(x)
+		if expected := naiveDFT(x.Elements(), int(n), false); !equalComplex(expected, y.Elements()) {
+			t.Errorf("%s: FFT of length %d failed expected=%v got=%v", t.Name(), n, expected, y.Elements())
+		}
+		if expected := naiveDFT(x.Elements(), int(n), true); !equalComplex(expected, d.IFFT(x).Elements()) {
+			t.Errorf("%s: IFFT of length %d failed", t.Name(), n)
+		}
+		if !equalComplex(x.Elements(), d.IFFT(y).Elements()) {
+			t.Errorf("%s: IFFT of the FFT of length %d failed", t.Name(), n)
+		}
+	}
+}
+
+func TestFFT2(t *testing.T) {
+	d := cpu.NewComplex[complex128]()
+	x := tensor.RandComplex[complex128](tensor.Shape{4, 3, 2})
+
+	// transform of the rows followed by the columns
+	y := d.FFT2(x)
+	for b := 0; b < 2; b++ {
+		for v := 0; v < 3; v++ {
+			for u := 0; u < 4; u++ {
+				expected := complex(0, 0)
+				for j := 0; j < 3; j++ {
+					for i := 0; i < 4; i++ {
+						angle := -2 * math.Pi * (float64(u*i)/4 + float64(v*j)/3)
+						expected += x.Get(uint(i), uint(j), uint(b)) * cmplx.Rect(1, angle)
+					}
+				}
+				if actual := y.Get(uint(u), uint(v), uint(b)); cmplx.Abs(actual-expected) > 1e-9 {
+					t.Errorf("%s: FFT2 failed at %d,%d,%d expected=%v got=%v", t.Name(), u, v, b, expected, actual)
+				}
+			}
+		}
+	}
+	if !equalComplex(x.Elements(), d.IFFT2(y).Elements()) {
+		t.Errorf("%s: IFFT2 of the FFT2 failed", t.Name())
+	}
+}
+
+func TestRFFT(t *testing.T) {
+	d := cpu.New[float64]()
+	dc := cpu.NewComplex[complex128]()
+	for _, n := range []int{8, 7} {
+		x := tensor.Rand[float64](tensor.Shape{uint(n), 2})
+
+		h := n/2 + 1
+		full := naiveDFT(toComplex(x.Elements()), n, false)
+		expected := append(append([]complex128{}, full[:h]...), full[n:n+h]...)
+		y := cpu.RFFT(dc, x)
+		if !equalComplex(expected, y.Elements()) {
+			t.Errorf("%s: RFFT of length %d failed expected=%v got=%v", t.Name(), n, expected, y.Elements())
+		}
+		if !equalFloats(x.Elements(), cpu.IRFFT(d, y, n).Elements()) {
+			t.Errorf("%s: IRFFT of the RFFT of length %d failed", t.Name(), n)
+		}
+	}
+}
+
+func TestSTFT(t *testing.T) {
+	d := cpu.New[float64]()
+	dc := cpu.NewComplex[complex128]()
+	x := tensor.Rand[float64](tensor.Shape{10, 2})
+	window := tensor.New(tensor.Shape{4}, []float64{0, 0.75, 0.75, 0})
+
+	y := cpu.STFT(dc, x, window, 4, 3)
+	if !tensor.SameShape(y.Shape(), tensor.Shape{3, 3, 2}) {
+		t.Fatalf("%s: invalid shape %v", t.Name(), y.Shape())
+	}
+	for b := 0; b < 2; b++ {
+		for f := 0; f < 3; f++ {
+			frame := d.Hadamard(d.Slice(x, tensor.Range{Start: 3 * f, Stop: 3*f + 4, Step: 1}, tensor.Range{Start: b, Stop: b + 1, Step: 1}), d.Reshape(window, tensor.Shape{4, 1}))
+			expected := cpu.RFFT(dc, frame).Elements()
+			actual := dc.Transpose(frequencies(y, f, b)).Elements()
+			if !equalComplex(expected, actual) {
+				t.Errorf("%s: frame %d of signal %d failed expected=%v got=%v", t.Name(), f, b, expected, actual)
+			}
+		}
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("%s: should panic with frames longer than the signals", t.Name())
+		}
+	}()
+	cpu.STFT(dc, x, nil, 11, 1)
+}
+
+// frequencies returns the frequencies of frame f of signal b of a STFT of shape
+// {h, frames, batch} as a matrix of shape {1, h}.
+func frequencies(y *tensor.Tensor[complex128], f, b int) *tensor.Tensor[complex128] {
+	h := y.Shape()[0]
+	elements := make([]complex128, h)
+	for k := range elements {
+		elements[k] = y.Get(uint(k), uint(f), uint(b))
+	}
+	return tensor.New(tensor.Shape{h, 1}, elements)
+}
+
+func TestFFTGrad(t *testing.T) {
+	d := cpu.New[float64](cpu.WithGrad(true))
+	dc := cpu.NewComplex[complex128](cpu.WithGrad(true))
+	c := tensor.RandComplex[complex128](tensor.Shape{6, 2})
+	c2 := tensor.RandComplex[complex128](tensor.Shape{3, 2, 2})
+	r := tensor.Rand[float64](tensor.Shape{6, 2})
+	half := tensor.RandComplex[complex128](tensor.Shape{4, 2})
+	window := tensor.Rand[float64](tensor.Shape{4})
+
+	// the loss is the sum of the squares of the real and imaginary parts, so
+	// the gradients reach the inputs through PairsFromComplex
+	square := func(y *tensor.Tensor[complex128]) *tensor.Tensor[float64] {
+		return d.PowInt(cpu.PairsFromComplex(d, y), 2)
+	}
+	cases := map[string]struct {
+		complexInputs []*tensor.Tensor[complex128]
+		realInputs    []*tensor.Tensor[float64]
+		forward       func() *tensor.Tensor[float64]
+	}{
+		"FFT":   {complexInputs: []*tensor.Tensor[complex128]{c}, forward: func() *tensor.Tensor[float64] { return square(dc.FFT(c)) }},
+		"IFFT":  {complexInputs: []*tensor.Tensor[complex128]{c}, forward: func() *tensor.Tensor[float64] { return square(dc.IFFT(c)) }},
+		"FFT2":  {complexInputs: []*tensor.Tensor[complex128]{c2}, forward: func() *tensor.Tensor[float64] { return square(dc.FFT2(c2)) }},
+		"IFFT2": {complexInputs: []*tensor.Tensor[complex128]{c2}, forward: func() *tensor.Tensor[float64] { return square(dc.IFFT2(c2)) }},
+		"RFFT":  {realInputs: []*tensor.Tensor[float64]{r}, forward: func() *tensor.Tensor[float64] { return square(cpu.RFFT(dc, r)) }},
+		"IRFFT": {complexInputs: []*tensor.Tensor[complex128]{half}, forward: func() *tensor.Tensor[float64] {
+			return d.PowInt(cpu.IRFFT(d, half, 6), 2)
+		}},
+		"IRFFT odd": {complexInputs: []*tensor.Tensor[complex128]{half}, forward: func() *tensor.Tensor[float64] {
+			return d.PowInt(cpu.IRFFT(d, half, 7), 2)
+		}},
+		"STFT": {realInputs: []*tensor.Tensor[float64]{r, window}, forward: func() *tensor.Tensor[float64] {
+			return square(cpu.STFT(dc, r, window, 4, 2))
+		}},
+		"pairs": {complexInputs: []*tensor.Tensor[complex128]{c}, forward: func() *tensor.Tensor[float64] {
+			return square(dc.Hadamard(cpu.ComplexFromPairs(dc, cpu.PairsFromComplex(d, c)), c))
+		}},
+	}
+
+	for name, c := range cases {
+		loss := func() float64 {
+			s := 0.0
+			for _, e := range c.forward().Elements() {
+				s += e
+			}
+			return s
+		}
+		for _, input := range c.complexInputs {
+			input.ZeroGrad()
+		}
+		for _, input := range c.realInputs {
+			input.ZeroGrad()
+		}
+		c.forward().Backward()
+
+		h := 1e-6
+		derivative := func(elements []complex128, i int, step complex128) float64 {
+			e := elements[i]
+			elements[i] = e + step
+			plus := loss()
+			elements[i] = e - step
+			minus := loss()
+			elements[i] = e
+			return (plus - minus) / (2 * h)
+		}
+		// the gradient of a complex input is the derivative with respect to
+		// its real part plus i times the one with respect to its imaginary
+		// part
+		for k, input := range c.complexInputs {
+			elements := input.Elements()
+			for i := range elements {
+				numeric := complex(derivative(elements, i, complex(h, 0)), derivative(elements, i, complex(0, h)))
+				if cmplx.Abs(input.Grad()[i]-numeric) > 1e-5*math.Max(1, cmplx.Abs(numeric)) {
+					t.Errorf("%s: %s gradient of complex input %d failed at %d expected=%v got=%v", t.Name(), name, k, i, numeric, input.Grad()[i])
+				}
+			}
+		}
+		for k, input := range c.realInputs {
+			elements := input.Elements()
+			for i, e := range elements {
+				elements[i] = e + h
+				plus := loss()
+				elements[i] = e - h
+				minus := loss()
+				elements[i] = e
+
+				numeric := (plus - minus) / (2 * h)
+				if math.Abs(input.Grad()[i]-numeric) > 1e-5*math.Max(1, math.Abs(numeric)) {
+					t.Errorf("%s: %s gradient of real input %d failed at %d expected=%f got=%f", t.Name(), name, k, i, numeric, input.Grad()[i])
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package cpu
+
+import (
+	"math"
+
+	"github.com/blast-go/blast/half"
+	"github.com/blast-go/blast/tensor"
+)
+
+// Upcast returns a float32 tensor with the values of the half precision tensor
+// t, so the computations on half precision tensors run on a CPU[float32]
+// device. The result is a new tensor out of the computation graph of t: the
+// weights are trained as float32 tensors and stored back with Downcast.
+func Upcast[H half.Float](t *tensor.Tensor[H]) *tensor.Tensor[float32] {
+	tElements := t.Elements()
+	elements := make([]float32, len(tElements))
+	for i, e := range tElements {
+		elements[i] = e.Float32()
+	}
+	return tensor.New(tensor.CopyShape(t.Shape()), elements)
+}
+
+// Downcast returns a half precision tensor with the values of the float32
+// tensor t rounded to the nearest half precision number, ties to even, to
+// store the results of computations on a CPU[float32] device. Numbers too
+// large become infinities, or the largest half precision number with
+// WithSaturation, the other options of Cast don't apply. Like Upcast, the
+// result is out of the computation graph of t.
+func Downcast[H half.Float](t *tensor.Tensor[float32], opts ...castOption) *tensor.Tensor[H] {
+	var cfg castOptions
+	for _, o := range opts {
+		o(&cfg)
+	}
+	max := math.Inf(1)
+	if cfg.saturate {
+		max = largest[H]()
+	}
+
+	tElements := t.Elements()
+	elements := make([]H, len(tElements))
+	for i, e := range tElements {
+		f := float64(e)
+		if math.Abs(f) > max && !math.IsInf(f, 0) {
+			f = math.Copysign(max, f)
+		}
+		elements[i] = half.New[H](f)
+	}
+	return tensor.New(tensor.CopyShape(t.Shape()), elements)
+}
+
+// largest returns the largest finite number of the half precision type H.
+func largest[H half.Float]() float64 {
+	var h H
+	switch any(h).(type) {
+	case half.Float16:
+		return half.FromBits[H](0x7bff).Float64()
+	default:
+		return half.FromBits[H](0x7f7f).Float64()
+	}
+}
@@ -0,0 +1,64 @@
+package cpu_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/half"
+	"github.com/blast-go/blast/tensor"
+)
+
+func TestUpcast(t *testing.T) {
+	d := cpu.New[float32]()
+	w := tensor.New(tensor.Shape{3}, []half.Float16{half.NewFloat16(1), half.NewFloat16(-0.5), half.NewFloat16(2)})
+	x := tensor.New(tensor.Shape{3}, []float32{0.1, 3, -1})
+
+	y := d.Hadamard(cpu.Upcast(w), x)
+	expected := []float32{0.1, -1.5, -2}
+	for i, e := range y.Elements() {
+		if e != expected[i] {
+			t.Errorf("%s: Upcast failed expected=%v got=%v", t.Name(), expected, y.Elements())
+			break
+		}
+	}
+}
+
+func TestDowncast(t *testing.T) {
+	d := cpu.New[float32]()
+	x := tensor.New(tensor.Shape{2}, []float32{1.00390625, -300.5})
+
+	y := cpu.Downcast[half.BFloat16](d.Mul(x, 1))
+	expected := []half.BFloat16{half.NewBFloat16(1), half.NewBFloat16(-300)}
+	for i, e := range y.Elements() {
+		if e != expected[i] {
+			t.Errorf("%s: Downcast failed expected=%v got=%v", t.Name(), expected, y.Elements())
+			break
+		}
+	}
+
+	// a round trip keeps the values representable in half precision
+	z := cpu.Upcast(y)
+	for i, e := range z.Elements() {
+		if expected := expected[i].Float32(); e != expected {
+			t.Errorf("%s: round trip failed at %d expected=%v got=%v", t.Name(), i, expected, e)
+		}
+	}
+}
+
+func TestDowncastSaturation(t *testing.T) {
+	x := tensor.New(tensor.Shape{3}, []float32{300, -5, 1e6})
+	cases := map[string]struct {
+		expected string
+		actual   fmt.Stringer
+	}{
+		"float16 saturated":  {"[300 -5 65504]", cpu.Downcast[half.Float16](x, cpu.WithSaturation(true))},
+		"float16 wrapped":    {"[300 -5 +Inf]", cpu.Downcast[half.Float16](x)},
+		"bfloat16 saturated": {"[300 -5 3.3895314e+38]", cpu.Downcast[half.BFloat16](tensor.New(tensor.Shape{3}, []float32{300, -5, 3.4e38}), cpu.WithSaturation(true))},
+	}
+	for name, c := range cases {
+		if actual := c.actual.String(); actual != c.expected {
+			t.Errorf("%s: %s expected=%s got=%s", t.Name(), name, c.expected, actual)
+		}
+	}
+}
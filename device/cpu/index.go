@@ -0,0 +1,417 @@
+package cpu
+
+import (
+	"fmt"
+
+	"github.com/blast-go/blast/tensor"
+)
+
+// Slice returns a new tensor with the elements of t selected by a range on
+// every dimension, dimensions without a range are fully selected. For example
+// Slice(t, tensor.All(), tensor.Range{Start: -1, Stop: -3, Step: -1}) selects
+// the last two rows of a matrix in reverse order. Panics if there are more
+// ranges than dimensions or a range selects no elements.
+func (c CPU[T]) Slice(t *tensor.Tensor[T], ranges ...tensor.Range) *tensor.Tensor[T] {
+	shape, index := sliceIndex(t.Shape(), ranges)
+	return c.gather(t, shape, index)
+}
+
+// SetSlice returns a copy of t in which the elements selected by the ranges,
+// as described in Slice, are replaced by the elements of src. Gradients flow
+// to t for the elements not replaced and to src for the rest. Panics if the
+// shape of src doesn't match the shape of the slice.
+func (c CPU[T]) SetSlice(t, src *tensor.Tensor[T], ranges ...tensor.Range) *tensor.Tensor[T] {
+	shape, index := sliceIndex(t.Shape(), ranges)
+	if !tensor.SameShape(shape, src.Shape()) {
+		panic(fmt.Sprintf("source of shape %v doesn't match slice of shape %v", src.Shape(), shape))
+	}
+
+	parents := []*tensor.Tensor[T]{t, src}
+	forward := func() []T {
+		elements := make([]T, len(t.Elements()))
+		copy(elements, t.Elements())
+		srcElements := src.Elements()
+		for i, j := range index {
+			elements[j] = srcElements[i]
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[T]) {
+			tOutGrad := tOut.Grad()
+			tGrad := t.Grad()
+			srcGrad := src.Grad()
+
+			replaced := make([]bool, len(tOutGrad))
+			for i, j := range index {
+				replaced[j] = true
+				srcGrad[i] += tOutGrad[j]
+			}
+			for i, g := range tOutGrad {
+				if !replaced[i] {
+					tGrad[i] += g
+				}
+			}
+		}
+	}
+
+	return tensor.Op(tensor.CopyShape(t.Shape()), parents, forward, backward)
+}
+
+// Take returns a new tensor with the elements of t at the positions given by
+// indices along axis. The dimension of axis is replaced by the dimensions of
+// indices, so taking a {2, 3} tensor of indices over axis 1 of a tensor with
+// shape {4, 5, 6} results in a tensor of shape {4, 2, 3, 6}. Negative indices
+// count from the end. Panics if an index is out of range.
+func (c CPU[T]) Take(t *tensor.Tensor[T], axis int, indices *tensor.Tensor[int]) *tensor.Tensor[T] {
+	tShape := t.Shape()
+	checkAxis(axis, len(tShape))
+
+	n := int(tShape[axis])
+	positions := make([]int, len(indices.Elements()))
+	for i, p := range indices.Elements() {
+		if p < 0 {
+			p += n
+		}
+		if p < 0 || p >= n {
+			panic(fmt.Sprintf("index out of bounds %d for size %d", indices.Elements()[i], n))
+		}
+		positions[i] = p
+	}
+
+	shape := make(tensor.Shape, 0, len(tShape)-1+len(indices.Shape()))
+	shape = append(shape, tShape[:axis]...)
+	shape = append(shape, indices.Shape()...)
+	shape = append(shape, tShape[axis+1:]...)
+
+	inner := tensor.Size(tShape[:axis])
+	outer := tensor.Size(tShape[axis+1:])
+	index := make([]int, 0, tensor.Size(shape))
+	for o := 0; o < outer; o++ {
+		for _, p := range positions {
+			for i := 0; i < inner; i++ {
+				index = append(index, (o*n+p)*inner+i)
+			}
+		}
+	}
+
+	return c.gather(t, shape, index)
+}
+
+// IndexSelect returns a new tensor with the entries of t along axis at the
+// positions given by the one dimensional tensor of indices, the dimension of
+// axis becomes the number of indices. Negative indices count from the end.
+// Panics if indices is not one dimensional or an index is out of range.
+func (c CPU[T]) IndexSelect(t *tensor.Tensor[T], axis int, indices *tensor.Tensor[int]) *tensor.Tensor[T] {
+	if len(indices.Shape()) != 1 {
+		panic(fmt.Sprintf("indices must be one dimensional got shape %v", indices.Shape()))
+	}
+	return c.Take(t, axis, indices)
+}
+
+// Gather returns a new tensor with the shape of indices in which every
+// element is taken from t at the same coordinates except along axis, where
+// the coordinate is the value of indices. For a matrix and axis 0 this is
+// out[i, j] = t[indices[i, j], j]. Gradients are scattered back adding up
+// repeated indices. Panics if indices doesn't have the same number of
+// dimensions as t, is larger than t on any other axis or an index is out of
+// range.
+func (c CPU[T]) Gather(t *tensor.Tensor[T], axis int, indices *tensor.Tensor[int]) *tensor.Tensor[T] {
+	index := scatterIndex(t.Shape(), axis, indices)
+	return c.gather(t, tensor.CopyShape(indices.Shape()), index)
+}
+
+// Scatter returns a copy of t in which the elements of src are written at the
+// positions given by indices, as described in Gather: along axis the
+// coordinate is the value of indices. When several elements are written to
+// the same position the last one in memory order is kept and only it receives
+// the gradient. Panics if src doesn't have the shape of indices or the
+// indices are invalid for t.
+func (c CPU[T]) Scatter(t *tensor.Tensor[T], axis int, indices *tensor.Tensor[int], src *tensor.Tensor[T]) *tensor.Tensor[T] {
+	if !tensor.SameShape(indices.Shape(), src.Shape()) {
+		panic(fmt.Sprintf("source of shape %v doesn't match indices of shape %v", src.Shape(), indices.Shape()))
+	}
+	index := scatterIndex(t.Shape(), axis, indices)
+
+	parents := []*tensor.Tensor[T]{t, src}
+	forward := func() []T {
+		elements := make([]T, len(t.Elements()))
+		copy(elements, t.Elements())
+		srcElements := src.Elements()
+		for i, j := range index {
+			elements[j] = srcElements[i]
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[T]) {
+			tOutGrad := tOut.Grad()
+			tGrad := t.Grad()
+			srcGrad := src.Grad()
+
+			// element of src written last at every position
+			written := make([]int, len(tOutGrad))
+			for i := range written {
+				written[i] = -1
+			}
+			for i, j := range index {
+				written[j] = i
+			}
+			for i, g := range tOutGrad {
+				if written[i] < 0 {
+					tGrad[i] += g
+				} else {
+					srcGrad[written[i]] += g
+				}
+			}
+		}
+	}
+
+	return tensor.Op(tensor.CopyShape(t.Shape()), parents, forward, backward)
+}
+
+// ScatterAdd returns a copy of t in which the elements of src are added at
+// the positions given by indices, as described in Scatter. Elements written
+// to the same position are added up. Panics if src doesn't have the shape of
+// indices or the indices are invalid for t.
+func (c CPU[T]) ScatterAdd(t *tensor.Tensor[T], axis int, indices *tensor.Tensor[int], src *tensor.Tensor[T]) *tensor.Tensor[T] {
+	if !tensor.SameShape(indices.Shape(), src.Shape()) {
+		panic(fmt.Sprintf("source of shape %v doesn't match indices of shape %v", src.Shape(), indices.Shape()))
+	}
+	index := scatterIndex(t.Shape(), axis, indices)
+
+	parents := []*tensor.Tensor[T]{t, src}
+	forward := func() []T {
+		elements := make([]T, len(t.Elements()))
+		copy(elements, t.Elements())
+		for i, e := range src.Elements() {
+			elements[index[i]] += e
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[T]) {
+			tOutGrad := tOut.Grad()
+			tGrad := t.Grad()
+			for i, g := range tOutGrad {
+				tGrad[i] += g
+			}
+			srcGrad := src.Grad()
+			for i, j := range index {
+				srcGrad[i] += tOutGrad[j]
+			}
+		}
+	}
+
+	return tensor.Op(tensor.CopyShape(t.Shape()), parents, forward, backward)
+}
+
+// Embedding returns a new tensor with the rows of weight, the entries along
+// its last dimension, at the positions given by indices. For a weight of
+// shape {dim, vocab} and indices of shape {length, n} the result has shape
+// {dim, length, n}. Unlike Take the backward pass produces a sparse gradient
+// that only holds the selected rows, see tensor.SparseGrad, unless weight is
+// computed by an operation. Panics if an index is out of range.
+func (c CPU[T]) Embedding(weight *tensor.Tensor[T], indices *tensor.Tensor[int]) *tensor.Tensor[T] {
+	wShape := weight.Shape()
+	vocab := int(wShape[len(wShape)-1])
+	rowSize := tensor.Size(wShape[:len(wShape)-1])
+	for _, p := range indices.Elements() {
+		if p < 0 || p >= vocab {
+			panic(fmt.Sprintf("index out of bounds %d for size %d", p, vocab))
+		}
+	}
+
+	shape := make(tensor.Shape, 0, len(wShape)-1+len(indices.Shape()))
+	shape = append(shape, wShape[:len(wShape)-1]...)
+	shape = append(shape, indices.Shape()...)
+
+	parents := []*tensor.Tensor[T]{weight}
+	forward := func() []T {
+		wElements := weight.Elements()
+		elements := make([]T, 0, tensor.Size(shape))
+		for _, p := range indices.Elements() {
+			elements = append(elements, wElements[p*rowSize:(p+1)*rowSize]...)
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[T]) {
+			tOutGrad := tOut.Grad()
+			for i, p := range indices.Elements() {
+				row := weight.RowGrad(p)
+				for j, g := range tOutGrad[i*rowSize : (i+1)*rowSize] {
+					row[j] += g
+				}
+			}
+		}
+	}
+
+	return tensor.Op(shape, parents, forward, backward)
+}
+
+// MaskedSelect returns a new one dimensional tensor with the elements of t
+// where mask is not zero, in memory order. Panics if mask doesn't have the
+// same shape as t or if no element is selected.
+func (c CPU[T]) MaskedSelect(t, mask *tensor.Tensor[T]) *tensor.Tensor[T] {
+	if !tensor.EqualShape(t, mask) {
+		panic("tensors must have the same shape")
+	}
+
+	var index []int
+	for i, m := range mask.Elements() {
+		if m != 0 {
+			index = append(index, i)
+		}
+	}
+	if len(index) == 0 {
+		panic("mask doesn't select any element")
+	}
+
+	return c.gather(t, tensor.Shape{uint(len(index))}, index)
+}
+
+// MaskedFill returns a copy of t in which the elements where mask is not zero
+// are replaced by value. Gradients only flow to the elements not replaced.
+// Panics if mask doesn't have the same shape as t.
+func (c CPU[T]) MaskedFill(t, mask *tensor.Tensor[T], value T) *tensor.Tensor[T] {
+	if !tensor.EqualShape(t, mask) {
+		panic("tensors must have the same shape")
+	}
+
+	parents := []*tensor.Tensor[T]{t}
+	forward := func() []T {
+		maskElements := mask.Elements()
+		elements := make([]T, len(maskElements))
+		for i, e := range t.Elements() {
+			if maskElements[i] != 0 {
+				elements[i] = value
+			} else {
+				elements[i] = e
+			}
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[T]) {
+			maskElements := mask.Elements()
+			tGrad := t.Grad()
+			for i, g := range tOut.Grad() {
+				if maskElements[i] == 0 {
+					tGrad[i] += g
+				}
+			}
+		}
+	}
+
+	return tensor.Op(tensor.CopyShape(t.Shape()), parents, forward, backward)
+}
+
+// gather returns a new tensor with the given shape in which the element i is
+// the element index[i] of t. Gradients are accumulated back into the
+// gathered positions.
+func (c CPU[T]) gather(t *tensor.Tensor[T], shape tensor.Shape, index []int) *tensor.Tensor[T] {
+	parents := []*tensor.Tensor[T]{t}
+	forward := func() []T {
+		tElements := t.Elements()
+		elements := make([]T, len(index))
+		for i, j := range index {
+			elements[i] = tElements[j]
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[T]) {
+			tOutGrad := tOut.Grad()
+			tGrad := t.Grad()
+			for i, j := range index {
+				tGrad[j] += tOutGrad[i]
+			}
+		}
+	}
+
+	return tensor.Op(shape, parents, forward, backward)
+}
+
+// sliceIndex returns the shape of the slice of a tensor and the position of
+// every element of the slice in the tensor.
+func sliceIndex(tShape tensor.Shape, ranges []tensor.Range) (tensor.Shape, []int) {
+	if len(ranges) > len(tShape) {
+		panic(fmt.Sprintf("too many ranges %d for %d dimensions", len(ranges), len(tShape)))
+	}
+
+	selected := make([][]int, len(tShape))
+	shape := make(tensor.Shape, len(tShape))
+	for d, n := range tShape {
+		r := tensor.All()
+		if d < len(ranges) {
+			r = ranges[d]
+		}
+		selected[d] = r.Indices(n)
+		if len(selected[d]) == 0 {
+			panic(fmt.Sprintf("range %v selects no elements on axis %d of size %d", r, d, n))
+		}
+		shape[d] = uint(len(selected[d]))
+	}
+
+	tStrides := strides(tShape)
+	index := make([]int, tensor.Size(shape))
+	cords := make([]uint, len(shape))
+	for i := range index {
+		for d, cord := range cords {
+			index[i] += selected[d][cord] * tStrides[d]
+		}
+		increment(cords, shape)
+	}
+	return shape, index
+}
+
+// scatterIndex returns the position in a tensor of the given shape of every
+// element of indices, the coordinates of the element are used except along
+// axis where its value is used instead.
+func scatterIndex(tShape tensor.Shape, axis int, indices *tensor.Tensor[int]) []int {
+	checkAxis(axis, len(tShape))
+	shape := indices.Shape()
+	if len(shape) != len(tShape) {
+		panic(fmt.Sprintf("indices must have %d dimensions got shape %v", len(tShape), shape))
+	}
+	for d := range shape {
+		if d != axis && shape[d] > tShape[d] {
+			panic(fmt.Sprintf("indices of shape %v don't fit in tensor of shape %v", shape, tShape))
+		}
+	}
+
+	n := int(tShape[axis])
+	tStrides := strides(tShape)
+	index := make([]int, tensor.Size(shape))
+	cords := make([]uint, len(shape))
+	for i, p := range indices.Elements() {
+		if p < 0 {
+			p += n
+		}
+		if p < 0 || p >= n {
+			panic(fmt.Sprintf("index out of bounds %d for size %d", indices.Elements()[i], n))
+		}
+		for d, cord := range cords {
+			if d == axis {
+				index[i] += p * tStrides[d]
+			} else {
+				index[i] += int(cord) * tStrides[d]
+			}
+		}
+		increment(cords, shape)
+	}
+	return index
+}
@@ -0,0 +1,289 @@
+package cpu_test
+
+import (
+	"testing"
+
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/tensor"
+)
+
+func TestSlice(t *testing.T) {
+	d := cpu.New[int]()
+	// 3 rows of 4 columns
+	t1 := tensor.New(tensor.Shape{4, 3}, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12})
+
+	actual := d.Slice(t1, tensor.Range{Start: 1, Stop: 4, Step: 2}, tensor.Range{Start: -2, Stop: 3})
+	expected := tensor.New(tensor.Shape{2, 2}, []int{6, 8, 10, 12})
+	if !tensor.Equal(actual, expected) {
+		t.Errorf("%s: Slice failed expected=%v got=%v", t.Name(), expected, actual)
+	}
+
+	actual = d.Slice(t1, tensor.All(), tensor.Range{Start: -1, Stop: -4, Step: -1})
+	expected = tensor.New(tensor.Shape{4, 3}, []int{9, 10, 11, 12, 5, 6, 7, 8, 1, 2, 3, 4})
+	if !tensor.Equal(actual, expected) {
+		t.Errorf("%s: Slice failed expected=%v got=%v", t.Name(), expected, actual)
+	}
+}
+
+func TestSliceEmpty(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("%s: should have failed due to empty range", t.Name())
+		}
+	}()
+	cpu.New[int]().Slice(tensor.Zeros[int](tensor.Shape{4}), tensor.Range{Start: 2, Stop: 2})
+}
+
+func TestSliceGrad(t *testing.T) {
+	d := cpu.New[int](cpu.WithGrad(true))
+	t1 := tensor.New(tensor.Shape{4, 2}, []int{1, 2, 3, 4, 5, 6, 7, 8})
+	t2 := d.PowInt(d.Slice(t1, tensor.Range{Start: 0, Stop: 4, Step: 3}), 2)
+	t2.Backward()
+
+	expected := []int{2, 0, 0, 8, 10, 0, 0, 16}
+	for i, g := range t1.Grad() {
+		if g != expected[i] {
+			t.Errorf("%s: gradient failed expected=%v got=%v", t.Name(), expected, t1.Grad())
+			break
+		}
+	}
+}
+
+func TestSetSlice(t *testing.T) {
+	d := cpu.New[int](cpu.WithGrad(true))
+	t1 := tensor.New(tensor.Shape{3, 2}, []int{1, 2, 3, 4, 5, 6})
+	src := tensor.New(tensor.Shape{1, 2}, []int{-1, -2})
+
+	t2 := d.SetSlice(t1, src, tensor.Range{Start: 1, Stop: 2})
+	expected := tensor.New(tensor.Shape{3, 2}, []int{1, -1, 3, 4, -2, 6})
+	if !tensor.Equal(t2, expected) {
+		t.Errorf("%s: SetSlice failed expected=%v got=%v", t.Name(), expected, t2)
+	}
+	if t1.Get(1, 0) != 2 {
+		t.Errorf("%s: SetSlice should not modify its input", t.Name())
+	}
+
+	d.Mul(t2, 3).Backward()
+	expectedGrad := []int{3, 0, 3, 3, 0, 3}
+	for i, g := range t1.Grad() {
+		if g != expectedGrad[i] {
+			t.Errorf("%s: gradient failed expected=%v got=%v", t.Name(), expectedGrad, t1.Grad())
+			break
+		}
+	}
+	for _, g := range src.Grad() {
+		if g != 3 {
+			t.Errorf("%s: gradient failed expected=3 got=%v", t.Name(), src.Grad())
+			break
+		}
+	}
+}
+
+func TestTake(t *testing.T) {
+	d := cpu.New[int](cpu.WithGrad(true))
+	t1 := tensor.New(tensor.Shape{2, 3}, []int{1, 2, 3, 4, 5, 6})
+	indices := tensor.New(tensor.Shape{2, 2}, []int{2, 0, -1, 2})
+
+	t2 := d.Take(t1, 1, indices)
+	expected := tensor.New(tensor.Shape{2, 2, 2}, []int{5, 6, 1, 2, 5, 6, 5, 6})
+	if !tensor.Equal(t2, expected) {
+		t.Errorf("%s: Take failed expected=%v got=%v", t.Name(), expected, t2)
+	}
+
+	t2.Backward()
+	expectedGrad := []int{1, 1, 0, 0, 3, 3}
+	for i, g := range t1.Grad() {
+		if g != expectedGrad[i] {
+			t.Errorf("%s: gradient failed expected=%v got=%v", t.Name(), expectedGrad, t1.Grad())
+			break
+		}
+	}
+}
+
+func TestTakeOutOfBounds(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("%s: should have failed due to index out of bounds", t.Name())
+		}
+	}()
+	cpu.New[int]().Take(tensor.Zeros[int](tensor.Shape{2, 3}), 1, tensor.New(tensor.Shape{1}, []int{3}))
+}
+
+func TestMaskedSelect(t *testing.T) {
+	d := cpu.New[float32](cpu.WithGrad(true))
+	t1 := tensor.New(tensor.Shape{2, 2}, []float32{1, 2, 3, 4})
+	mask := tensor.New(tensor.Shape{2, 2}, []float32{0, 1, 1, 0})
+
+	t2 := d.MaskedSelect(t1, mask)
+	if !tensor.Equal(t2, tensor.New(tensor.Shape{2}, []float32{2, 3})) {
+		t.Errorf("%s: MaskedSelect failed got=%v", t.Name(), t2)
+	}
+
+	t2.Backward()
+	if !tensor.Equal(tensor.New(tensor.Shape{2, 2}, t1.Grad()), mask) {
+		t.Errorf("%s: gradient failed expected=%v got=%v", t.Name(), mask, t1.Grad())
+	}
+}
+
+func TestMaskedFill(t *testing.T) {
+	d := cpu.New[float32](cpu.WithGrad(true))
+	t1 := tensor.New(tensor.Shape{2, 2}, []float32{1, 2, 3, 4})
+	mask := tensor.New(tensor.Shape{2, 2}, []float32{0, 1, 1, 0})
+
+	t2 := d.MaskedFill(t1, mask, -10)
+	if !tensor.Equal(t2, tensor.New(tensor.Shape{2, 2}, []float32{1, -10, -10, 4})) {
+		t.Errorf("%s: MaskedFill failed got=%v", t.Name(), t2)
+	}
+
+	t2.Backward()
+	expected := []float32{1, 0, 0, 1}
+	for i, g := range t1.Grad() {
+		if g != expected[i] {
+			t.Errorf("%s: gradient failed expected=%v got=%v", t.Name(), expected, t1.Grad())
+			break
+		}
+	}
+}
+
+func TestIndexSelect(t *testing.T) {
+	d := cpu.New[int]()
+	t1 := tensor.New(tensor.Shape{2, 3}, []int{1, 2, 3, 4, 5, 6})
+
+	t2 := d.IndexSelect(t1, 1, tensor.New(tensor.Shape{2}, []int{2, 0}))
+	expected := tensor.New(tensor.Shape{2, 2}, []int{5, 6, 1, 2})
+	if !tensor.Equal(t2, expected) {
+		t.Errorf("%s: IndexSelect failed expected=%v got=%v", t.Name(), expected, t2)
+	}
+}
+
+func TestIndexSelectInvalidIndices(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("%s: should have failed due to indices not one dimensional", t.Name())
+		}
+	}()
+	cpu.New[int]().IndexSelect(tensor.Zeros[int](tensor.Shape{2, 3}), 1, tensor.Zeros[int](tensor.Shape{1, 1}))
+}
+
+func TestGather(t *testing.T) {
+	d := cpu.New[int](cpu.WithGrad(true))
+	t1 := tensor.New(tensor.Shape{3, 2}, []int{1, 2, 3, 4, 5, 6})
+	indices := tensor.New(tensor.Shape{2, 2}, []int{2, 0, 1, -2})
+
+	t2 := d.Gather(t1, 0, indices)
+	expected := tensor.New(tensor.Shape{2, 2}, []int{3, 1, 5, 5})
+	if !tensor.Equal(t2, expected) {
+		t.Errorf("%s: Gather failed expected=%v got=%v", t.Name(), expected, t2)
+	}
+
+	t2.Backward()
+	expectedGrad := []int{1, 0, 1, 0, 2, 0}
+	for i, g := range t1.Grad() {
+		if g != expectedGrad[i] {
+			t.Errorf("%s: gradient failed expected=%v got=%v", t.Name(), expectedGrad, t1.Grad())
+			break
+		}
+	}
+}
+
+func TestGatherInvalidShape(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("%s: should have failed due to indices larger than the tensor", t.Name())
+		}
+	}()
+	cpu.New[int]().Gather(tensor.Zeros[int](tensor.Shape{3, 2}), 0, tensor.Zeros[int](tensor.Shape{1, 3}))
+}
+
+func TestScatter(t *testing.T) {
+	d := cpu.New[int](cpu.WithGrad(true))
+	t1 := tensor.Zeros[int](tensor.Shape{3, 2})
+	indices := tensor.New(tensor.Shape{2, 1}, []int{2, 2})
+	src := tensor.New(tensor.Shape{2, 1}, []int{7, 8})
+
+	t2 := d.Scatter(t1, 0, indices, src)
+	expected := tensor.New(tensor.Shape{3, 2}, []int{0, 0, 8, 0, 0, 0})
+	if !tensor.Equal(t2, expected) {
+		t.Errorf("%s: Scatter failed expected=%v got=%v", t.Name(), expected, t2)
+	}
+
+	d.Mul(t2, 3).Backward()
+	expectedGrad := []int{3, 3, 0, 3, 3, 3}
+	for i, g := range t1.Grad() {
+		if g != expectedGrad[i] {
+			t.Errorf("%s: gradient failed expected=%v got=%v", t.Name(), expectedGrad, t1.Grad())
+			break
+		}
+	}
+	expectedGrad = []int{0, 3}
+	for i, g := range src.Grad() {
+		if g != expectedGrad[i] {
+			t.Errorf("%s: gradient failed expected=%v got=%v", t.Name(), expectedGrad, src.Grad())
+			break
+		}
+	}
+}
+
+func TestScatterAdd(t *testing.T) {
+	d := cpu.New[int](cpu.WithGrad(true))
+	t1 := tensor.New(tensor.Shape{3, 2}, []int{1, 1, 1, 1, 1, 1})
+	indices := tensor.New(tensor.Shape{2, 1}, []int{2, 2})
+	src := tensor.New(tensor.Shape{2, 1}, []int{7, 8})
+
+	t2 := d.ScatterAdd(t1, 0, indices, src)
+	expected := tensor.New(tensor.Shape{3, 2}, []int{1, 1, 16, 1, 1, 1})
+	if !tensor.Equal(t2, expected) {
+		t.Errorf("%s: ScatterAdd failed expected=%v got=%v", t.Name(), expected, t2)
+	}
+
+	d.Mul(t2, 3).Backward()
+	for _, g := range t1.Grad() {
+		if g != 3 {
+			t.Errorf("%s: gradient failed expected=3 got=%v", t.Name(), t1.Grad())
+			break
+		}
+	}
+	for _, g := range src.Grad() {
+		if g != 3 {
+			t.Errorf("%s: gradient failed expected=3 got=%v", t.Name(), src.Grad())
+			break
+		}
+	}
+}
+
+func TestEmbedding(t *testing.T) {
+	d := cpu.New[float32](cpu.WithGrad(true))
+	// vocabulary of 4 vectors of size 2
+	weight := tensor.New(tensor.Shape{2, 4}, []float32{1, 2, 3, 4, 5, 6, 7, 8})
+	indices := tensor.New(tensor.Shape{3}, []int{2, 0, 2})
+
+	t2 := d.Embedding(weight, indices)
+	expected := tensor.New(tensor.Shape{2, 3}, []float32{5, 6, 1, 2, 5, 6})
+	if !tensor.Equal(t2, expected) {
+		t.Errorf("%s: Embedding failed expected=%v got=%v", t.Name(), expected, t2)
+	}
+
+	d.Mul(t2, 2).Backward()
+	if weight.HasGrad() {
+		t.Errorf("%s: dense gradient should not be allocated", t.Name())
+	}
+	sparse := weight.SparseGrad()
+	if len(sparse.Rows()) != 2 || sparse.Row(2)[0] != 4 || sparse.Row(2)[1] != 4 || sparse.Row(0)[0] != 2 {
+		t.Errorf("%s: sparse gradient failed rows=%v", t.Name(), sparse.Rows())
+	}
+
+	// the gradient of a table computed by an operation is dense so it reaches
+	// the tensors it is computed from
+	flat := tensor.New(tensor.Shape{8}, []float32{1, 2, 3, 4, 5, 6, 7, 8})
+	d.Mul(d.Embedding(d.Reshape(flat, tensor.Shape{2, 4}), indices), 2).Backward()
+	if flat.SparseGrad() != nil {
+		t.Errorf("%s: sparse gradient should not be allocated", t.Name())
+	}
+	expectedGrad := []float32{2, 2, 0, 0, 4, 4, 0, 0}
+	for i, g := range flat.Grad() {
+		if g != expectedGrad[i] {
+			t.Errorf("%s: gradient through Reshape failed expected=%v got=%v", t.Name(), expectedGrad, flat.Grad())
+			break
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package cpu
+
+import (
+	"fmt"
+
+	"github.com/blast-go/blast/tensor"
+)
+
+// Concat returns a new tensor that joins the tensors along axis. Panics if
+// the tensors don't have the same shape in every dimension other than axis.
+func (c CPU[T]) Concat(axis int, ts ...*tensor.Tensor[T]) *tensor.Tensor[T] {
+	if len(ts) == 0 {
+		panic("at least one tensor is required")
+	}
+
+	first := ts[0].Shape()
+	checkAxis(axis, len(first))
+
+	shape := tensor.CopyShape(first)
+	shape[axis] = 0
+	for _, t := range ts {
+		tShape := t.Shape()
+		if len(tShape) != len(first) {
+			panic(fmt.Sprintf("tensors must have the same number of dimensions expected=%d got=%d", len(first), len(tShape)))
+		}
+		for d := range tShape {
+			if d != axis && tShape[d] != first[d] {
+				panic(fmt.Sprintf("tensors must have the same shape except on axis %d expected=%v got=%v", axis, first, tShape))
+			}
+		}
+		shape[axis] += tShape[axis]
+	}
+
+	// every tensor contributes with a block of contiguous elements for every
+	// position of the dimensions above axis
+	inner := tensor.Size(first[:axis])
+	outer := tensor.Size(first[axis+1:])
+	blocks := make([]int, len(ts))
+	for i, t := range ts {
+		blocks[i] = inner * int(t.Shape()[axis])
+	}
+
+	forward := func() []T {
+		elements := make([]T, 0, tensor.Size(shape))
+		for o := 0; o < outer; o++ {
+			for i, t := range ts {
+				elements = append(elements, t.Elements()[o*blocks[i]:(o+1)*blocks[i]]...)
+			}
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[T]) {
+			tOutGrad := tOut.Grad()
+			offset := 0
+			for o := 0; o < outer; o++ {
+				for i, t := range ts {
+					tGrad := t.Grad()[o*blocks[i] : (o+1)*blocks[i]]
+					for j, g := range tOutGrad[offset : offset+blocks[i]] {
+						tGrad[j] += g
+					}
+					offset += blocks[i]
+				}
+			}
+		}
+	}
+
+	parents := make([]*tensor.Tensor[T], len(ts))
+	copy(parents, ts)
+	return tensor.Op(shape, parents, forward, backward)
+}
+
+// Stack returns a new tensor that joins the tensors along a new dimension
+// inserted at axis. Panics if the tensors don't have the same shape.
+func (c CPU[T]) Stack(axis int, ts ...*tensor.Tensor[T]) *tensor.Tensor[T] {
+	if len(ts) == 0 {
+		panic("at least one tensor is required")
+	}
+
+	unsqueezed := make([]*tensor.Tensor[T], len(ts))
+	for i, t := range ts {
+		if !tensor.EqualShape(t, ts[0]) {
+			panic(fmt.Sprintf("tensors must have the same shape expected=%v got=%v", ts[0].Shape(), t.Shape()))
+		}
+		unsqueezed[i] = c.Unsqueeze(t, axis)
+	}
+	return c.Concat(axis, unsqueezed...)
+}
+
+// Split returns the parts of t along axis with the given sizes. Panics if the
+// sizes don't add up to the size of the dimension.
+func (c CPU[T]) Split(t *tensor.Tensor[T], axis int, sizes ...uint) []*tensor.Tensor[T] {
+	shape := t.Shape()
+	checkAxis(axis, len(shape))
+
+	total := uint(0)
+	for _, s := range sizes {
+		if s == 0 {
+			panic("sizes can't be zero")
+		}
+		total += s
+	}
+	if total != shape[axis] {
+		panic(fmt.Sprintf("sizes add up to %d instead of %d", total, shape[axis]))
+	}
+
+	parts := make([]*tensor.Tensor[T], len(sizes))
+	ranges := make([]tensor.Range, axis+1)
+	for i := 0; i < axis; i++ {
+		ranges[i] = tensor.All()
+	}
+
+	start := 0
+	for i, s := range sizes {
+		ranges[axis] = tensor.Range{Start: start, Stop: start + int(s), Step: 1}
+		parts[i] = c.Slice(t, ranges...)
+		start += int(s)
+	}
+	return parts
+}
+
+// Chunk splits t along axis into n parts of the same size, the last part is
+// smaller when the size of the dimension is not divisible by n. Fewer parts
+// are returned when there are not enough elements to fill n parts.
+func (c CPU[T]) Chunk(t *tensor.Tensor[T], axis int, n int) []*tensor.Tensor[T] {
+	shape := t.Shape()
+	checkAxis(axis, len(shape))
+	if n <= 0 {
+		panic(fmt.Sprintf("invalid number of chunks %d", n))
+	}
+
+	chunk := (shape[axis] + uint(n) - 1) / uint(n)
+	var sizes []uint
+	for left := shape[axis]; left > 0; left -= chunk {
+		if left < chunk {
+			chunk = left
+		}
+		sizes = append(sizes, chunk)
+	}
+	return c.Split(t, axis, sizes...)
+}
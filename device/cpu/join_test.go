@@ -0,0 +1,138 @@
+package cpu_test
+
+import (
+	"testing"
+
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/tensor"
+)
+
+func TestConcat(t *testing.T) {
+	d := cpu.New[int]()
+	t1 := tensor.New(tensor.Shape{2, 2}, []int{1, 2, 3, 4})
+	t2 := tensor.New(tensor.Shape{1, 2}, []int{5, 6})
+
+	actual := d.Concat(0, t1, t2)
+	expected := tensor.New(tensor.Shape{3, 2}, []int{1, 2, 5, 3, 4, 6})
+	if !tensor.Equal(actual, expected) {
+		t.Errorf("%s: Concat failed expected=%v got=%v", t.Name(), expected, actual)
+	}
+
+	t3 := tensor.New(tensor.Shape{2, 1}, []int{7, 8})
+	actual = d.Concat(1, t1, t3)
+	expected = tensor.New(tensor.Shape{2, 3}, []int{1, 2, 3, 4, 7, 8})
+	if !tensor.Equal(actual, expected) {
+		t.Errorf("%s: Concat failed expected=%v got=%v", t.Name(), expected, actual)
+	}
+}
+
+func TestConcatInvalidShape(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("%s: should have failed due to different shapes", t.Name())
+		}
+	}()
+	d := cpu.New[int]()
+	d.Concat(0, tensor.Zeros[int](tensor.Shape{2, 2}), tensor.Zeros[int](tensor.Shape{2, 3}))
+}
+
+func TestConcatGrad(t *testing.T) {
+	d := cpu.New[int](cpu.WithGrad(true))
+	t1 := tensor.New(tensor.Shape{2, 2}, []int{1, 2, 3, 4})
+	t2 := tensor.New(tensor.Shape{1, 2}, []int{5, 6})
+	d.PowInt(d.Concat(0, t1, t2), 2).Backward()
+
+	expected := [][]int{{2, 4, 6, 8}, {10, 12}}
+	for i, ti := range []*tensor.Tensor[int]{t1, t2} {
+		for j, g := range ti.Grad() {
+			if g != expected[i][j] {
+				t.Errorf("%s: gradient failed expected=%v got=%v", t.Name(), expected[i], ti.Grad())
+				break
+			}
+		}
+	}
+}
+
+func TestStack(t *testing.T) {
+	d := cpu.New[int]()
+	t1 := tensor.New(tensor.Shape{2}, []int{1, 2})
+	t2 := tensor.New(tensor.Shape{2}, []int{3, 4})
+
+	actual := d.Stack(1, t1, t2)
+	expected := tensor.New(tensor.Shape{2, 2}, []int{1, 2, 3, 4})
+	if !tensor.Equal(actual, expected) {
+		t.Errorf("%s: Stack failed expected=%v got=%v", t.Name(), expected, actual)
+	}
+
+	actual = d.Stack(0, t1, t2)
+	expected = tensor.New(tensor.Shape{2, 2}, []int{1, 3, 2, 4})
+	if !tensor.Equal(actual, expected) {
+		t.Errorf("%s: Stack failed expected=%v got=%v", t.Name(), expected, actual)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	d := cpu.New[int]()
+	t1 := tensor.New(tensor.Shape{2, 3}, []int{1, 2, 3, 4, 5, 6})
+
+	parts := d.Split(t1, 1, 1, 2)
+	expected := []*tensor.Tensor[int]{
+		tensor.New(tensor.Shape{2, 1}, []int{1, 2}),
+		tensor.New(tensor.Shape{2, 2}, []int{3, 4, 5, 6}),
+	}
+	if len(parts) != len(expected) {
+		t.Fatalf("%s: Split failed expected=%d parts got=%d", t.Name(), len(expected), len(parts))
+	}
+	for i := range parts {
+		if !tensor.Equal(parts[i], expected[i]) {
+			t.Errorf("%s: Split failed expected=%v got=%v", t.Name(), expected[i], parts[i])
+		}
+	}
+}
+
+func TestSplitInvalidSizes(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("%s: should have failed due to invalid sizes", t.Name())
+		}
+	}()
+	cpu.New[int]().Split(tensor.Zeros[int](tensor.Shape{5}), 0, 2, 2)
+}
+
+func TestChunk(t *testing.T) {
+	d := cpu.New[int]()
+	t1 := tensor.New(tensor.Shape{5}, []int{1, 2, 3, 4, 5})
+
+	parts := d.Chunk(t1, 0, 3)
+	expected := []*tensor.Tensor[int]{
+		tensor.New(tensor.Shape{2}, []int{1, 2}),
+		tensor.New(tensor.Shape{2}, []int{3, 4}),
+		tensor.New(tensor.Shape{1}, []int{5}),
+	}
+	if len(parts) != len(expected) {
+		t.Fatalf("%s: Chunk failed expected=%d parts got=%d", t.Name(), len(expected), len(parts))
+	}
+	for i := range parts {
+		if !tensor.Equal(parts[i], expected[i]) {
+			t.Errorf("%s: Chunk failed expected=%v got=%v", t.Name(), expected[i], parts[i])
+		}
+	}
+}
+
+func TestSplitGrad(t *testing.T) {
+	d := cpu.New[int](cpu.WithGrad(true))
+	t1 := tensor.New(tensor.Shape{4}, []int{1, 2, 3, 4})
+	// the parts are joined again so the gradients of both reach t1 through
+	// the same intermediate tensor
+	t2 := d.Mul(t1, 2)
+	parts := d.Chunk(t2, 0, 2)
+	d.Concat(0, d.PowInt(parts[0], 2), parts[1]).Backward()
+
+	expected := []int{8, 16, 2, 2}
+	for i, g := range t1.Grad() {
+		if g != expected[i] {
+			t.Errorf("%s: gradient failed expected=%v got=%v", t.Name(), expected, t1.Grad())
+			break
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package cpu
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/tensor"
+)
+
+// BatchNorm returns a new tensor with the elements of t normalized per
+// channel using the mean and variance over the batch and the rest of the
+// dimensions, then scaled by weight and shifted by bias. The channels are the
+// second to last dimension, so t has shape {features, n} or {w, h, c, n}.
+// weight and bias have one element per channel and can be nil.
+//
+// When training the statistics of t are used and, if mean and variance are not
+// nil, they are updated as running = (1-momentum)*running + momentum*batch
+// with the unbiased variance once the result is computed. Otherwise the
+// running mean and variance are used. Panics if the shapes are invalid or
+// the running statistics are missing outside of training.
+func (c CPU[T]) BatchNorm(t, weight, bias, mean, variance *tensor.Tensor[T], training bool, momentum, eps float64) *tensor.Tensor[T] {
+	shape := t.Shape()
+	if len(shape) < 2 {
+		panic(fmt.Sprintf("batch normalization requires at least 2 dimensions got shape %v", shape))
+	}
+	channels := shape[len(shape)-2]
+	for _, p := range []*tensor.Tensor[T]{weight, bias, mean, variance} {
+		checkParam(p, tensor.Shape{channels})
+	}
+	if (mean == nil) != (variance == nil) {
+		panic("running mean and variance must be both set or nil")
+	}
+	if !training && mean == nil {
+		panic("running statistics are required outside of training")
+	}
+
+	inner := tensor.Size(shape[:len(shape)-2])
+	channel := make([]int, tensor.Size(shape))
+	for i := range channel {
+		channel[i] = i / inner % int(channels)
+	}
+
+	n := normalization[T]{groups: int(channels), group: channel, param: channel, center: true}
+	if !training {
+		n.mean = mean
+		n.variance = variance
+	} else if mean != nil {
+		n.update = func(batchMean, batchVariance []float64, count int) {
+			meanElements := mean.Elements()
+			varianceElements := variance.Elements()
+			correction := 1.0
+			if count > 1 {
+				correction = float64(count) / float64(count-1)
+			}
+			for g := range batchMean {
+				meanElements[g] = T((1-momentum)*float64(meanElements[g]) + momentum*batchMean[g])
+				varianceElements[g] = T((1-momentum)*float64(varianceElements[g]) + momentum*batchVariance[g]*correction)
+			}
+		}
+	}
+
+	return c.normalize(t, weight, bias, n, eps)
+}
+
+// LayerNorm returns a new tensor with the elements of t normalized over the
+// first dims dimensions, for example the features of every sample in a tensor
+// of shape {features, n} with dims 1, then scaled by weight and shifted by
+// bias. weight and bias have the shape of the normalized dimensions and can
+// be nil. Panics if dims is out of range or the shapes are invalid.
+func (c CPU[T]) LayerNorm(t, weight, bias *tensor.Tensor[T], dims int, eps float64) *tensor.Tensor[T] {
+	n := c.layerNormalization(t, weight, bias, dims)
+	n.center = true
+	return c.normalize(t, weight, bias, n, eps)
+}
+
+// GroupNorm returns a new tensor with the channels of every sample of t
+// divided into groups and the elements of every group normalized, then
+// scaled by weight and shifted by bias. The channels are the second to last
+// dimension as described in BatchNorm. weight and bias have one element per
+// channel and can be nil. Panics if the number of channels is not divisible
+// by groups or the shapes are invalid.
+func (c CPU[T]) GroupNorm(t, weight, bias *tensor.Tensor[T], groups int, eps float64) *tensor.Tensor[T] {
+	shape := t.Shape()
+	if len(shape) < 2 {
+		panic(fmt.Sprintf("group normalization requires at least 2 dimensions got shape %v", shape))
+	}
+	channels := int(shape[len(shape)-2])
+	if groups <= 0 || channels%groups != 0 {
+		panic(fmt.Sprintf("%d channels can't be divided into %d groups", channels, groups))
+	}
+	checkParam(weight, tensor.Shape{uint(channels)})
+	checkParam(bias, tensor.Shape{uint(channels)})
+
+	inner := tensor.Size(shape[:len(shape)-2])
+	perGroup := channels / groups
+	samples := int(shape[len(shape)-1])
+	n := normalization[T]{
+		groups: groups * samples,
+		group:  make([]int, tensor.Size(shape)),
+		param:  make([]int, tensor.Size(shape)),
+		center: true,
+	}
+	for i := range n.group {
+		ch := i / inner % channels
+		sample := i / (inner * channels)
+		n.group[i] = sample*groups + ch/perGroup
+		n.param[i] = ch
+	}
+
+	return c.normalize(t, weight, bias, n, eps)
+}
+
+// RMSNorm returns a new tensor with the elements of t divided by their root
+// mean square over the first dims dimensions, then scaled by weight. Unlike
+// LayerNorm the mean is not subtracted. weight has the shape of the
+// normalized dimensions and can be nil. Panics if dims is out of range or the
+// shape of weight is invalid.
+func (c CPU[T]) RMSNorm(t, weight *tensor.Tensor[T], dims int, eps float64) *tensor.Tensor[T] {
+	n := c.layerNormalization(t, weight, nil, dims)
+	return c.normalize(t, weight, nil, n, eps)
+}
+
+// normalization describes how the elements of a tensor are grouped to be
+// normalized.
+type normalization[T constraints.Number] struct {
+	groups int
+	// group holds the group of the statistics of every element and param the
+	// position of its affine parameters.
+	group, param []int
+	// center subtracts the mean, otherwise elements are only divided by
+	// their root mean square.
+	center bool
+	// mean and variance of every group used instead of the statistics of the
+	// tensor when set.
+	mean, variance *tensor.Tensor[T]
+	// update is called with the statistics of the tensor and the number of
+	// elements of every group.
+	update func(mean, variance []float64, count int)
+}
+
+func (c CPU[T]) layerNormalization(t, weight, bias *tensor.Tensor[T], dims int) normalization[T] {
+	shape := t.Shape()
+	if dims <= 0 || dims > len(shape) {
+		panic(fmt.Sprintf("invalid number of normalized dimensions %d for shape %v", dims, shape))
+	}
+	checkParam(weight, shape[:dims])
+	checkParam(bias, shape[:dims])
+
+	inner := tensor.Size(shape[:dims])
+	n := normalization[T]{
+		groups: tensor.Size(shape) / inner,
+		group:  make([]int, tensor.Size(shape)),
+		param:  make([]int, tensor.Size(shape)),
+	}
+	for i := range n.group {
+		n.group[i] = i / inner
+		n.param[i] = i % inner
+	}
+	return n
+}
+
+// normalize returns a new tensor with the elements of t normalized as
+// described by n, then scaled by weight and shifted by bias when they are not
+// nil. The backward pass computes the gradients of the whole normalization at
+// once.
+func (c CPU[T]) normalize(t, weight, bias *tensor.Tensor[T], n normalization[T], eps float64) *tensor.Tensor[T] {
+	parents := []*tensor.Tensor[T]{t}
+	for _, p := range []*tensor.Tensor[T]{weight, bias} {
+		if p != nil {
+			parents = append(parents, p)
+		}
+	}
+
+	// normalized elements and inverse of the standard deviation of every
+	// group, kept for the backward pass
+	var normalized, invStd []float64
+	counts := make([]int, n.groups)
+	for _, g := range n.group {
+		counts[g]++
+	}
+
+	forward := func() []T {
+		tElements := t.Elements()
+		mean := make([]float64, n.groups)
+		variance := make([]float64, n.groups)
+		if n.mean != nil {
+			for g, e := range n.mean.Elements() {
+				mean[g] = float64(e)
+			}
+			for g, e := range n.variance.Elements() {
+				variance[g] = float64(e)
+			}
+		} else {
+			if n.center {
+				for i, e := range tElements {
+					mean[n.group[i]] += float64(e)
+				}
+				for g := range mean {
+					mean[g] /= float64(counts[g])
+				}
+			}
+			for i, e := range tElements {
+				d := float64(e) - mean[n.group[i]]
+				variance[n.group[i]] += d * d
+			}
+			for g := range variance {
+				variance[g] /= float64(counts[g])
+			}
+			if n.update != nil {
+				n.update(mean, variance, counts[0])
+			}
+		}
+
+		invStd = make([]float64, n.groups)
+		for g, v := range variance {
+			invStd[g] = 1 / math.Sqrt(v+eps)
+		}
+
+		normalized = make([]float64, len(tElements))
+		elements := make([]T, len(tElements))
+		for i, e := range tElements {
+			g := n.group[i]
+			normalized[i] = (float64(e) - mean[g]) * invStd[g]
+			y := normalized[i]
+			if weight != nil {
+				y *= float64(weight.Elements()[n.param[i]])
+			}
+			if bias != nil {
+				y += float64(bias.Elements()[n.param[i]])
+			}
+			elements[i] = T(y)
+		}
+		return elements
+	}
+
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[T]) {
+			// make sure the statistics were computed
+			tOut.Elements()
+			tOutGrad := tOut.Grad()
+
+			// gradient with respect to the normalized elements
+			grad := make([]float64, len(tOutGrad))
+			for i, g := range tOutGrad {
+				grad[i] = float64(g)
+				if weight != nil {
+					weight.Grad()[n.param[i]] += T(float64(g) * normalized[i])
+					grad[i] *= float64(weight.Elements()[n.param[i]])
+				}
+				if bias != nil {
+					bias.Grad()[n.param[i]] += g
+				}
+			}
+
+			tGrad := t.Grad()
+			if n.mean != nil {
+				// the running statistics don't depend on t
+				for i, g := range grad {
+					tGrad[i] += T(g * invStd[n.group[i]])
+				}
+				return
+			}
+
+			gradMean := make([]float64, n.groups)
+			gradDot := make([]float64, n.groups)
+			for i, g := range grad {
+				gradMean[n.group[i]] += g
+				gradDot[n.group[i]] += g * normalized[i]
+			}
+			for g := range gradMean {
+				gradMean[g] /= float64(counts[g])
+				gradDot[g] /= float64(counts[g])
+			}
+			for i, g := range grad {
+				k := n.group[i]
+				if n.center {
+					g -= gradMean[k]
+				}
+				tGrad[i] += T(invStd[k] * (g - normalized[i]*gradDot[k]))
+			}
+		}
+	}
+
+	return tensor.Op(tensor.CopyShape(t.Shape()), parents, forward, backward)
+}
+
+// checkParam panics if p is not nil and doesn't have the given shape.
+func checkParam[T constraints.Number](p *tensor.Tensor[T], shape tensor.Shape) {
+	if p != nil && !tensor.SameShape(p.Shape(), shape) {
+		panic(fmt.Sprintf("parameter of shape %v must have shape %v", p.Shape(), shape))
+	}
+}
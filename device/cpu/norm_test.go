@@ -0,0 +1,185 @@
+package cpu_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/tensor"
+)
+
+func TestBatchNorm(t *testing.T) {
+	d := cpu.New[float64]()
+	// 2 features and 2 samples
+	t1 := tensor.New(tensor.Shape{2, 2}, []float64{1, 2, 3, 6})
+	mean := tensor.Zeros[float64](tensor.Shape{2})
+	variance := tensor.Ones[float64](tensor.Shape{2})
+
+	t2 := d.BatchNorm(t1, nil, nil, mean, variance, true, 0.5, 0)
+	expected := []float64{-1, -1, 1, 1}
+	for i, e := range t2.Elements() {
+		if math.Abs(e-expected[i]) > 1e-9 {
+			t.Errorf("%s: BatchNorm failed expected=%v got=%v", t.Name(), expected, t2)
+			break
+		}
+	}
+
+	expectedMean := []float64{1, 2}
+	expectedVariance := []float64{1.5, 4.5}
+	for i := range expectedMean {
+		if mean.Elements()[i] != expectedMean[i] || variance.Elements()[i] != expectedVariance[i] {
+			t.Errorf("%s: running statistics failed expected=%v,%v got=%v,%v", t.Name(), expectedMean, expectedVariance, mean, variance)
+			break
+		}
+	}
+
+	t3 := d.BatchNorm(t1, nil, nil, mean, variance, false, 0.5, 0)
+	expected = []float64{0, 0, 2 / math.Sqrt(1.5), 4 / math.Sqrt(4.5)}
+	for i, e := range t3.Elements() {
+		if math.Abs(e-expected[i]) > 1e-9 {
+			t.Errorf("%s: BatchNorm evaluation failed expected=%v got=%v", t.Name(), expected, t3)
+			break
+		}
+	}
+	if mean.Elements()[0] != 1 {
+		t.Errorf("%s: running statistics should not change in evaluation", t.Name())
+	}
+}
+
+func TestLayerNorm(t *testing.T) {
+	d := cpu.New[float64]()
+	t1 := tensor.New(tensor.Shape{2, 2}, []float64{1, 3, 2, 6})
+	weight := tensor.New(tensor.Shape{2}, []float64{2, 1})
+	bias := tensor.New(tensor.Shape{2}, []float64{0, 1})
+
+	t2 := d.LayerNorm(t1, weight, bias, 1, 0)
+	expected := []float64{-2, 2, -2, 2}
+	for i, e := range t2.Elements() {
+		if math.Abs(e-expected[i]) > 1e-9 {
+			t.Errorf("%s: LayerNorm failed expected=%v got=%v", t.Name(), expected, t2)
+			break
+		}
+	}
+}
+
+func TestRMSNorm(t *testing.T) {
+	d := cpu.New[float64]()
+	t1 := tensor.New(tensor.Shape{2, 1}, []float64{3, 4})
+
+	t2 := d.RMSNorm(t1, nil, 1, 0)
+	rms := math.Sqrt(12.5)
+	expected := []float64{3 / rms, 4 / rms}
+	for i, e := range t2.Elements() {
+		if math.Abs(e-expected[i]) > 1e-9 {
+			t.Errorf("%s: RMSNorm failed expected=%v got=%v", t.Name(), expected, t2)
+			break
+		}
+	}
+}
+
+func TestGroupNormInvalidGroups(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("%s: should have failed due to invalid groups", t.Name())
+		}
+	}()
+	cpu.New[float64]().GroupNorm(tensor.Zeros[float64](tensor.Shape{2, 3, 1}), nil, nil, 2, 1e-5)
+}
+
+func TestNormGrad(t *testing.T) {
+	d := cpu.New[float64](cpu.WithGrad(true))
+	shape := tensor.Shape{2, 4, 3}
+	x := []float64{0.3, -1.2, 2.1, 0.5, -0.7, 1.4, 0.9, -0.2, 1.1, 0.6, -1.5, 0.8,
+		0.1, 2.4, -0.9, 0.4, 1.7, -0.3, -1.1, 0.2, 0.7, 1.3, -0.6, 0.05}
+	channels := []float64{0.5, 1.5, -1.0, 2.0}
+	bias := []float64{0.1, -0.2, 0.3, 0.4}
+	features := []float64{0.7, 1.3, 0.4, -0.5, 1.1, 0.9, 0.6, 1.2}
+	featuresBias := []float64{0.2, -0.1, 0.5, 0.3, -0.4, 0.1, 0.6, 0.0}
+
+	cases := map[string]struct {
+		params []([]float64)
+		shapes []tensor.Shape
+		op     func(x *tensor.Tensor[float64], params []*tensor.Tensor[float64]) *tensor.Tensor[float64]
+	}{
+		"BatchNorm": {
+			params: [][]float64{channels, bias},
+			shapes: []tensor.Shape{{4}, {4}},
+			op: func(x *tensor.Tensor[float64], p []*tensor.Tensor[float64]) *tensor.Tensor[float64] {
+				return d.BatchNorm(x, p[0], p[1], nil, nil, true, 0.1, 1e-5)
+			},
+		},
+		"BatchNormEval": {
+			params: [][]float64{channels, bias},
+			shapes: []tensor.Shape{{4}, {4}},
+			op: func(x *tensor.Tensor[float64], p []*tensor.Tensor[float64]) *tensor.Tensor[float64] {
+				mean := tensor.New(tensor.Shape{4}, []float64{0.1, 0.2, -0.3, 0.4})
+				variance := tensor.New(tensor.Shape{4}, []float64{1.5, 0.5, 2, 1})
+				return d.BatchNorm(x, p[0], p[1], mean, variance, false, 0.1, 1e-5)
+			},
+		},
+		"LayerNorm": {
+			params: [][]float64{features, featuresBias},
+			shapes: []tensor.Shape{{2, 4}, {2, 4}},
+			op: func(x *tensor.Tensor[float64], p []*tensor.Tensor[float64]) *tensor.Tensor[float64] {
+				return d.LayerNorm(x, p[0], p[1], 2, 1e-5)
+			},
+		},
+		"GroupNorm": {
+			params: [][]float64{channels, bias},
+			shapes: []tensor.Shape{{4}, {4}},
+			op: func(x *tensor.Tensor[float64], p []*tensor.Tensor[float64]) *tensor.Tensor[float64] {
+				return d.GroupNorm(x, p[0], p[1], 2, 1e-5)
+			},
+		},
+		"RMSNorm": {
+			params: [][]float64{features},
+			shapes: []tensor.Shape{{2, 4}},
+			op: func(x *tensor.Tensor[float64], p []*tensor.Tensor[float64]) *tensor.Tensor[float64] {
+				return d.RMSNorm(x, p[0], 2, 1e-5)
+			},
+		},
+	}
+
+	for name, c := range cases {
+		// all the inputs of the operation, x first
+		inputs := append([][]float64{x}, c.params...)
+		shapes := append([]tensor.Shape{shape}, c.shapes...)
+		loss := func(values [][]float64) ([]*tensor.Tensor[float64], *tensor.Tensor[float64]) {
+			ts := make([]*tensor.Tensor[float64], len(values))
+			for i, v := range values {
+				ts[i] = tensor.New(shapes[i], append([]float64(nil), v...))
+			}
+			return ts, d.PowInt(c.op(ts[0], ts[1:]), 3)
+		}
+		sum := func(t *tensor.Tensor[float64]) float64 {
+			s := 0.0
+			for _, e := range t.Elements() {
+				s += e
+			}
+			return s
+		}
+
+		ts, out := loss(inputs)
+		out.Backward()
+
+		h := 1e-6
+		for k, values := range inputs {
+			for i := range values {
+				plus := make([][]float64, len(inputs))
+				minus := make([][]float64, len(inputs))
+				copy(plus, inputs)
+				copy(minus, inputs)
+				plus[k] = append([]float64(nil), values...)
+				minus[k] = append([]float64(nil), values...)
+				plus[k][i] += h
+				minus[k][i] -= h
+				_, outPlus := loss(plus)
+				_, outMinus := loss(minus)
+				numeric := (sum(outPlus) - sum(outMinus)) / (2 * h)
+				if math.Abs(ts[k].Grad()[i]-numeric) > 1e-4*math.Max(1, math.Abs(numeric)) {
+					t.Errorf("%s: %s gradient of input %d failed at %d expected=%f got=%f", t.Name(), name, k, i, numeric, ts[k].Grad()[i])
+				}
+			}
+		}
+	}
+}
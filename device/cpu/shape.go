@@ -0,0 +1,206 @@
+package cpu
+
+import (
+	"fmt"
+
+	"github.com/blast-go/blast/tensor"
+)
+
+// Reshape returns a new tensor with the same elements as t but with the given
+// shape. The elements are shared with t. Panics if the number of elements of
+// the new shape doesn't match the one of t.
+func (c CPU[T]) Reshape(t *tensor.Tensor[T], shape tensor.Shape) *tensor.Tensor[T] {
+	for i, d := range shape {
+		if d == 0 {
+			panic(fmt.Sprintf("dimension %d can't be zero", i))
+		}
+	}
+	if tensor.Size(shape) != tensor.Size(t.Shape()) {
+		panic(fmt.Sprintf("can't reshape tensor of shape %v into %v", t.Shape(), shape))
+	}
+
+	return c.view(t, tensor.CopyShape(shape))
+}
+
+// Squeeze returns a new tensor with the dimensions of size one at the given
+// axes removed. If no axes are given all dimensions of size one are removed.
+// Panics if an axis is out of range or its dimension is not of size one.
+func (c CPU[T]) Squeeze(t *tensor.Tensor[T], axes ...int) *tensor.Tensor[T] {
+	oldShape := t.Shape()
+
+	remove := make([]bool, len(oldShape))
+	if len(axes) == 0 {
+		for i, d := range oldShape {
+			remove[i] = d == 1
+		}
+	}
+	for _, axis := range axes {
+		checkAxis(axis, len(oldShape))
+		if oldShape[axis] != 1 {
+			panic(fmt.Sprintf("can't squeeze axis %d of size %d", axis, oldShape[axis]))
+		}
+		remove[axis] = true
+	}
+
+	shape := tensor.Shape{}
+	for i, d := range oldShape {
+		if !remove[i] {
+			shape = append(shape, d)
+		}
+	}
+
+	return c.view(t, shape)
+}
+
+// Unsqueeze returns a new tensor with a dimension of size one inserted at
+// axis. Panics if axis is greater than the number of dimensions of t.
+func (c CPU[T]) Unsqueeze(t *tensor.Tensor[T], axis int) *tensor.Tensor[T] {
+	oldShape := t.Shape()
+	checkAxis(axis, len(oldShape)+1)
+
+	shape := make(tensor.Shape, 0, len(oldShape)+1)
+	shape = append(shape, oldShape[:axis]...)
+	shape = append(shape, 1)
+	shape = append(shape, oldShape[axis:]...)
+
+	return c.view(t, shape)
+}
+
+// Flatten returns a new tensor in which the dimensions from start to end,
+// both included, are merged into a single one. For example a batch of images
+// with shape {w, h, c, n} is flattened into {w*h*c, n} with Flatten(t, 0, 2).
+// Panics if the axes are out of range or start is greater than end.
+func (c CPU[T]) Flatten(t *tensor.Tensor[T], start, end int) *tensor.Tensor[T] {
+	oldShape := t.Shape()
+	checkAxis(start, len(oldShape))
+	checkAxis(end, len(oldShape))
+	if start > end {
+		panic(fmt.Sprintf("start axis %d can't be greater than end axis %d", start, end))
+	}
+
+	shape := make(tensor.Shape, 0, len(oldShape)-(end-start))
+	shape = append(shape, oldShape[:start]...)
+	shape = append(shape, uint(tensor.Size(oldShape[start:end+1])))
+	shape = append(shape, oldShape[end+1:]...)
+
+	return c.view(t, shape)
+}
+
+// Permute returns a new tensor with the dimensions of t reordered, dimension
+// i of the result is dimension axes[i] of t. Panics if axes is not a
+// permutation of the dimensions of t.
+func (c CPU[T]) Permute(t *tensor.Tensor[T], axes ...int) *tensor.Tensor[T] {
+	oldShape := t.Shape()
+	if len(axes) != len(oldShape) {
+		panic(fmt.Sprintf("invalid number of axes expected=%d got=%d", len(oldShape), len(axes)))
+	}
+
+	seen := make([]bool, len(axes))
+	shape := make(tensor.Shape, len(axes))
+	for i, axis := range axes {
+		checkAxis(axis, len(oldShape))
+		if seen[axis] {
+			panic(fmt.Sprintf("axis %d repeated in permutation %v", axis, axes))
+		}
+		seen[axis] = true
+		shape[i] = oldShape[axis]
+	}
+
+	// position of every element of the result in t
+	oldStrides := strides(oldShape)
+	index := make([]int, tensor.Size(shape))
+	cords := make([]uint, len(shape))
+	for i := range index {
+		offset := 0
+		for d, cord := range cords {
+			offset += int(cord) * oldStrides[axes[d]]
+		}
+		index[i] = offset
+		increment(cords, shape)
+	}
+
+	return c.gather(t, shape, index)
+}
+
+// Broadcast returns a new tensor with the given shape in which the
+// dimensions of size one of t are repeated to match shape. Missing outer
+// dimensions are treated as dimensions of size one, so a bias of shape
+// {features} can be broadcast to a batch of shape {features, n}. Gradients
+// are summed over the repeated elements. Panics if t can't be broadcast to
+// shape.
+func (c CPU[T]) Broadcast(t *tensor.Tensor[T], shape tensor.Shape) *tensor.Tensor[T] {
+	oldShape := t.Shape()
+	if len(oldShape) > len(shape) {
+		panic(fmt.Sprintf("can't broadcast tensor of shape %v into %v", oldShape, shape))
+	}
+	for d, n := range oldShape {
+		if n != shape[d] && n != 1 {
+			panic(fmt.Sprintf("can't broadcast tensor of shape %v into %v", oldShape, shape))
+		}
+	}
+
+	oldStrides := strides(oldShape)
+	index := make([]int, tensor.Size(shape))
+	cords := make([]uint, len(shape))
+	for i := range index {
+		for d, n := range oldShape {
+			if n != 1 {
+				index[i] += int(cords[d]) * oldStrides[d]
+			}
+		}
+		increment(cords, shape)
+	}
+
+	return c.gather(t, tensor.CopyShape(shape), index)
+}
+
+// view returns a new tensor sharing the elements of t with a different shape
+// with the same number of elements.
+func (c CPU[T]) view(t *tensor.Tensor[T], shape tensor.Shape) *tensor.Tensor[T] {
+	parents := []*tensor.Tensor[T]{t}
+	forward := func() []T {
+		return t.Elements()
+	}
+
+	var backward tensor.BackwardFunc[T]
+	if c.grad {
+		backward = func(tOut *tensor.Tensor[T]) {
+			tOutGrad := tOut.Grad()
+			tGrad := t.Grad()
+			for i, g := range tOutGrad {
+				tGrad[i] += g
+			}
+		}
+	}
+
+	return tensor.Op(shape, parents, forward, backward)
+}
+
+// strides returns the distance between consecutive elements of every
+// dimension, the first dimension is contiguous.
+func strides(shape tensor.Shape) []int {
+	s := make([]int, len(shape))
+	stride := 1
+	for i, d := range shape {
+		s[i] = stride
+		stride *= int(d)
+	}
+	return s
+}
+
+// increment advances the coordinates to the next element in memory order.
+func increment(cords []uint, shape tensor.Shape) {
+	for d := range cords {
+		cords[d]++
+		if cords[d] < shape[d] {
+			return
+		}
+		cords[d] = 0
+	}
+}
+
+func checkAxis(axis, dims int) {
+	if axis < 0 || axis >= dims {
+		panic(fmt.Sprintf("axis %d out of range for %d dimensions", axis, dims))
+	}
+}
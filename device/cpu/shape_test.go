@@ -0,0 +1,154 @@
+package cpu_test
+
+import (
+	"testing"
+
+	"github.com/blast-go/blast/device"
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/tensor"
+)
+
+var _ device.Device[float32] = cpu.New[float32]()
+
+func TestReshape(t *testing.T) {
+	d := cpu.New[int]()
+	t1 := tensor.New(tensor.Shape{3, 2}, []int{1, 2, 3, 4, 5, 6})
+
+	expected := tensor.New(tensor.Shape{2, 3}, []int{1, 2, 3, 4, 5, 6})
+	if actual := d.Reshape(t1, tensor.Shape{2, 3}); !tensor.Equal(actual, expected) {
+		t.Errorf("%s: Reshape failed expected=%v got=%v", t.Name(), expected, actual)
+	}
+}
+
+func TestReshapeInvalid(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("%s: should have failed due to different number of elements", t.Name())
+		}
+	}()
+	cpu.New[int]().Reshape(tensor.Zeros[int](tensor.Shape{3, 2}), tensor.Shape{4})
+}
+
+func TestReshapeGrad(t *testing.T) {
+	d := cpu.New[float32](cpu.WithGrad(true))
+	t1 := tensor.New(tensor.Shape{3, 2}, []float32{1, 2, 3, 4, 5, 6})
+	t2 := d.Mul(d.Reshape(t1, tensor.Shape{6}), 2)
+	t2.Backward()
+
+	for i, g := range t1.Grad() {
+		if g != 2 {
+			t.Errorf("%s: gradient failed at %d expected=2 got=%f", t.Name(), i, g)
+		}
+	}
+}
+
+func TestSqueeze(t *testing.T) {
+	d := cpu.New[int]()
+	t1 := tensor.Zeros[int](tensor.Shape{1, 3, 1, 2})
+
+	if shape := d.Squeeze(t1).Shape(); !tensor.SameShape(shape, tensor.Shape{3, 2}) {
+		t.Errorf("%s: Squeeze failed got=%v", t.Name(), shape)
+	}
+	if shape := d.Squeeze(t1, 2).Shape(); !tensor.SameShape(shape, tensor.Shape{1, 3, 2}) {
+		t.Errorf("%s: Squeeze failed got=%v", t.Name(), shape)
+	}
+}
+
+func TestSqueezeInvalid(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("%s: should have failed due to dimension of size 3", t.Name())
+		}
+	}()
+	cpu.New[int]().Squeeze(tensor.Zeros[int](tensor.Shape{1, 3}), 1)
+}
+
+func TestUnsqueeze(t *testing.T) {
+	d := cpu.New[int]()
+	t1 := tensor.Zeros[int](tensor.Shape{3, 2})
+
+	if shape := d.Unsqueeze(t1, 0).Shape(); !tensor.SameShape(shape, tensor.Shape{1, 3, 2}) {
+		t.Errorf("%s: Unsqueeze failed got=%v", t.Name(), shape)
+	}
+	if shape := d.Unsqueeze(t1, 2).Shape(); !tensor.SameShape(shape, tensor.Shape{3, 2, 1}) {
+		t.Errorf("%s: Unsqueeze failed got=%v", t.Name(), shape)
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	d := cpu.New[int]()
+	t1 := tensor.Zeros[int](tensor.Shape{4, 4, 3, 2})
+
+	if shape := d.Flatten(t1, 0, 2).Shape(); !tensor.SameShape(shape, tensor.Shape{48, 2}) {
+		t.Errorf("%s: Flatten failed got=%v", t.Name(), shape)
+	}
+	if shape := d.Flatten(t1, 1, 1).Shape(); !tensor.SameShape(shape, tensor.Shape{4, 4, 3, 2}) {
+		t.Errorf("%s: Flatten failed got=%v", t.Name(), shape)
+	}
+}
+
+func TestPermute(t *testing.T) {
+	d := cpu.New[int]()
+	t1 := tensor.New(tensor.Shape{3, 2, 2}, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12})
+	t2 := d.Permute(t1, 2, 0, 1)
+
+	expected := tensor.New(tensor.Shape{2, 3, 2}, []int{1, 7, 2, 8, 3, 9, 4, 10, 5, 11, 6, 12})
+	if !tensor.Equal(t2, expected) {
+		t.Errorf("%s: Permute failed expected=%v got=%v", t.Name(), expected, t2)
+	}
+
+	// a permutation of two dimensions is a transpose
+	t3 := tensor.New(tensor.Shape{3, 2}, []int{1, 2, 3, 4, 5, 6})
+	if !tensor.Equal(d.Permute(t3, 1, 0), d.Transpose(t3)) {
+		t.Errorf("%s: Permute failed got=%v", t.Name(), d.Permute(t3, 1, 0))
+	}
+}
+
+func TestPermuteGrad(t *testing.T) {
+	d := cpu.New[float64](cpu.WithGrad(true))
+	t1 := tensor.New(tensor.Shape{3, 2}, []float64{1, 2, 3, 4, 5, 6})
+	t2 := d.PowInt(d.Permute(t1, 1, 0), 2)
+	t2.Backward()
+
+	expected := []float64{2, 4, 6, 8, 10, 12}
+	for i, g := range t1.Grad() {
+		if g != expected[i] {
+			t.Errorf("%s: gradient failed expected=%v got=%v", t.Name(), expected, t1.Grad())
+			break
+		}
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	d := cpu.New[int](cpu.WithGrad(true))
+	t1 := tensor.New(tensor.Shape{2}, []int{1, 2})
+
+	t2 := d.Broadcast(t1, tensor.Shape{2, 3})
+	expected := tensor.New(tensor.Shape{2, 3}, []int{1, 2, 1, 2, 1, 2})
+	if !tensor.Equal(t2, expected) {
+		t.Errorf("%s: Broadcast failed expected=%v got=%v", t.Name(), expected, t2)
+	}
+
+	t2.Backward()
+	for _, g := range t1.Grad() {
+		if g != 3 {
+			t.Errorf("%s: gradient failed expected=3 got=%v", t.Name(), t1.Grad())
+			break
+		}
+	}
+
+	t3 := d.Broadcast(tensor.New(tensor.Shape{1, 2}, []int{1, 2}), tensor.Shape{3, 2})
+	expected = tensor.New(tensor.Shape{3, 2}, []int{1, 1, 1, 2, 2, 2})
+	if !tensor.Equal(t3, expected) {
+		t.Errorf("%s: Broadcast failed expected=%v got=%v", t.Name(), expected, t3)
+	}
+}
+
+func TestBroadcastInvalid(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("%s: should have failed due to incompatible shapes", t.Name())
+		}
+	}()
+	cpu.New[int]().Broadcast(tensor.Zeros[int](tensor.Shape{2}), tensor.Shape{3})
+}
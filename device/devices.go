@@ -5,7 +5,7 @@ import (
 	"github.com/blast-go/blast/tensor"
 )
 
-type Device[T constraints.Number] interface {
+type Device[T constraints.Element] interface {
 	Add(*tensor.Tensor[T], *tensor.Tensor[T]) *tensor.Tensor[T]
 	Sub(*tensor.Tensor[T], *tensor.Tensor[T]) *tensor.Tensor[T]
 	MatMul(*tensor.Tensor[T], *tensor.Tensor[T]) *tensor.Tensor[T]
@@ -0,0 +1,189 @@
+// Command mnist trains a small fully connected network to classify the
+// handwritten digits of the MNIST dataset. Run download.sh first to fetch the
+// dataset into the current directory.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/blast-go/blast/data"
+	"github.com/blast-go/blast/data/idx"
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/optim"
+	"github.com/blast-go/blast/tensor"
+)
+
+const (
+	pixels  = 28 * 28
+	classes = 10
+)
+
+type config struct {
+	dir       string
+	epochs    int
+	batchSize int
+	hidden    uint
+	lr        float64
+	seed      int64
+}
+
+// mlp is a network with a single hidden layer and sigmoid activations.
+type mlp struct {
+	w1 *tensor.Tensor[float32]
+	w2 *tensor.Tensor[float32]
+}
+
+func newMLP(hidden uint) *mlp {
+	m := &mlp{
+		w1: tensor.Rand[float32](tensor.Shape{hidden, pixels}),
+		w2: tensor.Rand[float32](tensor.Shape{classes, hidden}),
+	}
+	// scale the initial weights to keep the activations in the linear region
+	// of the sigmoid
+	for _, w := range []*tensor.Tensor[float32]{m.w1, m.w2} {
+		scale := float32(1 / float32(w.Shape()[1]))
+		for i := range w.Elements() {
+			w.Elements()[i] *= scale
+		}
+	}
+	return m
+}
+
+func (m *mlp) Parameters() map[string]*tensor.Tensor[float32] {
+	return map[string]*tensor.Tensor[float32]{"w1": m.w1, "w2": m.w2}
+}
+
+// forward returns the output of the network for a batch of images with shape
+// {pixels, batch}, the output has shape {classes, batch}.
+func (m *mlp) forward(d cpu.CPU[float32], x *tensor.Tensor[float32]) *tensor.Tensor[float32] {
+	h := d.Sigmoid(d.MatMul(x, m.w1))
+	return d.Sigmoid(d.MatMul(h, m.w2))
+}
+
+// load reads the images and labels of a split, images are flattened and
+// scaled to [0, 1] and labels are one-hot encoded.
+func load(dir, split string) (*data.TensorDataset[float32], error) {
+	images, err := idx.Load[float32](path(dir, split+"-images-idx3-ubyte"))
+	if err != nil {
+		return nil, err
+	}
+	labels, err := idx.Load[uint8](path(dir, split+"-labels-idx1-ubyte"))
+	if err != nil {
+		return nil, err
+	}
+
+	n := labels.Shape()[0]
+	if len(images.Shape()) != 3 || images.Shape()[2] != n {
+		return nil, fmt.Errorf("invalid images shape %v for %d labels", images.Shape(), n)
+	}
+
+	pixelElements := images.Elements()
+	for i := range pixelElements {
+		pixelElements[i] /= 255
+	}
+	x := tensor.New(tensor.Shape{pixels, n}, pixelElements)
+
+	y := tensor.Zeros[float32](tensor.Shape{classes, n})
+	for i, l := range labels.Elements() {
+		y.Elements()[i*classes+int(l)] = 1
+	}
+
+	return data.NewTensorDataset(x, y), nil
+}
+
+// path returns the location of a dataset file, compressed files are used if
+// the uncompressed ones are missing.
+func path(dir, name string) string {
+	p := filepath.Join(dir, name)
+	if _, err := os.Stat(p); err != nil {
+		return p + ".gz"
+	}
+	return p
+}
+
+// accuracy returns the fraction of samples in which the class with the highest
+// output matches the expected class.
+func accuracy(out, y *tensor.Tensor[float32]) (correct, total int) {
+	outElements := out.Elements()
+	yElements := y.Elements()
+	n := int(out.Shape()[1])
+	for i := 0; i < n; i++ {
+		best := 0
+		for c := 1; c < classes; c++ {
+			if outElements[i*classes+c] > outElements[i*classes+best] {
+				best = c
+			}
+		}
+		if yElements[i*classes+best] == 1 {
+			correct++
+		}
+	}
+	return correct, n
+}
+
+// train fits a model on the training split and returns its accuracy on the
+// test split.
+func train(cfg config) (float64, error) {
+	trainSet, err := load(cfg.dir, "train")
+	if err != nil {
+		return 0, err
+	}
+	testSet, err := load(cfg.dir, "t10k")
+	if err != nil {
+		return 0, err
+	}
+
+	d := cpu.New[float32](cpu.WithGrad(true))
+	model := newMLP(cfg.hidden)
+	optimizer := optim.NewAdam(model.Parameters(), cfg.lr)
+	loader := data.NewLoader[float32](trainSet, data.WithBatchSize(cfg.batchSize), data.WithShuffle(cfg.seed))
+
+	for epoch := 1; epoch <= cfg.epochs; epoch++ {
+		var total float64
+		it := loader.Iter()
+		for batch, ok := it.Next(); ok; batch, ok = it.Next() {
+			x, y := batch[0], batch[1]
+
+			// squared error summed over the batch
+			loss := d.PowInt(d.Sub(model.forward(d, x), y), 2)
+			for _, e := range loss.Elements() {
+				total += float64(e)
+			}
+
+			optimizer.ZeroGrad()
+			loss.Backward()
+			optimizer.Step()
+		}
+		log.Printf("epoch=%d loss=%f", epoch, total/float64(trainSet.Len()))
+	}
+
+	var correct, total int
+	it := data.NewLoader[float32](testSet, data.WithBatchSize(1000)).Iter()
+	for batch, ok := it.Next(); ok; batch, ok = it.Next() {
+		c, n := accuracy(model.forward(d, batch[0]), batch[1])
+		correct += c
+		total += n
+	}
+	return float64(correct) / float64(total), nil
+}
+
+func main() {
+	var cfg config
+	flag.StringVar(&cfg.dir, "dir", ".", "directory with the MNIST files")
+	flag.IntVar(&cfg.epochs, "epochs", 5, "number of training epochs")
+	flag.IntVar(&cfg.batchSize, "batch", 64, "number of samples per batch")
+	flag.UintVar(&cfg.hidden, "hidden", 64, "number of hidden units")
+	flag.Float64Var(&cfg.lr, "lr", 0.001, "learning rate")
+	flag.Int64Var(&cfg.seed, "seed", 1, "seed used to shuffle the samples")
+	flag.Parse()
+
+	acc, err := train(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("test accuracy=%.4f", acc)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"compress/gzip"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blast-go/blast/data/idx"
+	"github.com/blast-go/blast/tensor"
+)
+
+// writeFixture generates a synthetic dataset with the layout of MNIST in
+// which every digit is a horizontal bar at a row that depends on it, drawn
+// over a mostly dark background with some noisy pixels.
+func writeFixture(t *testing.T, dir, split string, n int, r *rand.Rand) {
+	images := make([]uint8, n*pixels)
+	labels := make([]uint8, n)
+	for i := 0; i < n; i++ {
+		label := r.Intn(classes)
+		labels[i] = uint8(label)
+		image := images[i*pixels : (i+1)*pixels]
+		for p := range image {
+			if r.Intn(10) == 0 {
+				image[p] = uint8(r.Intn(256))
+			}
+		}
+		row := 2*label + 4
+		for col := 4; col < 24; col++ {
+			image[row*28+col] = 255
+		}
+	}
+
+	write := func(name string, x *tensor.Tensor[uint8]) {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		gw := gzip.NewWriter(f)
+		if err := idx.Write(gw, x, idx.UByte); err != nil {
+			t.Fatal(err)
+		}
+		if err := gw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(split+"-images-idx3-ubyte.gz", tensor.New(tensor.Shape{28, 28, uint(n)}, images))
+	write(split+"-labels-idx1-ubyte.gz", tensor.New(tensor.Shape{uint(n)}, labels))
+}
+
+func TestTrain(t *testing.T) {
+	dir := t.TempDir()
+	r := rand.New(rand.NewSource(1))
+	writeFixture(t, dir, "train", 500, r)
+	writeFixture(t, dir, "t10k", 100, r)
+
+	acc, err := train(config{dir: dir, epochs: 5, batchSize: 10, hidden: 32, lr: 0.01, seed: 1})
+	if err != nil {
+		t.Fatalf("%s: training failed: %v", t.Name(), err)
+	}
+	if acc < 0.9 {
+		t.Errorf("%s: accuracy too low got=%f", t.Name(), acc)
+	}
+}
@@ -0,0 +1,178 @@
+// Package half implements the half precision floating point types float16
+// and bfloat16 in software. They are storage types: tensors of half precision
+// numbers use half the memory of float32 tensors, and computations are done on
+// float32 tensors converted from them.
+//
+// Both types are structs holding their 16 bits, so they aren't numbers for
+// the arithmetic operators nor for constraints.Number: tensors of half
+// precision numbers are only stored, loaded and converted. Values are
+// converted with New and Float32, and bits with FromBits and Bits.
+//
+// Tensors of half precision numbers have a single path to computation: the
+// cpu device converts them to float32 tensors with Upcast and back with
+// Downcast, while safetensors stores and loads them with WriteHalf and GetHalf
+// keeping their bits.
+package half
+
+import (
+	"math"
+	"strconv"
+)
+
+// Float16 is an IEEE 754 binary16 number, with 5 exponent bits and 10
+// mantissa bits.
+type Float16 struct {
+	bits uint16
+}
+
+// BFloat16 is a brain floating point number, with the 8 exponent bits of a
+// float32 and 7 mantissa bits.
+type BFloat16 struct {
+	bits uint16
+}
+
+// Float is the constraint of the half precision types.
+type Float interface {
+	Float16 | BFloat16
+	Bits() uint16
+	Float32() float32
+	Float64() float64
+}
+
+// New returns f rounded to the nearest half precision number of type H, ties
+// to even. Numbers too large for H become infinities.
+func New[H Float](f float64) H {
+	var h H
+	switch any(h).(type) {
+	case Float16:
+		return FromBits[H](encode(f, 5, 10))
+	default:
+		return FromBits[H](encode(f, 8, 7))
+	}
+}
+
+// FromBits returns the half precision number of type H with the given bits.
+func FromBits[H Float](bits uint16) H {
+	var h H
+	switch any(h).(type) {
+	case Float16:
+		return any(Float16{bits}).(H)
+	default:
+		return any(BFloat16{bits}).(H)
+	}
+}
+
+// NewFloat16 returns f rounded to the nearest Float16.
+func NewFloat16(f float64) Float16 {
+	return New[Float16](f)
+}
+
+// NewBFloat16 returns f rounded to the nearest BFloat16.
+func NewBFloat16(f float64) BFloat16 {
+	return New[BFloat16](f)
+}
+
+// Bits returns the bits of h.
+func (h Float16) Bits() uint16 {
+	return h.bits
+}
+
+// Float32 returns h as a float32, which represents it exactly.
+func (h Float16) Float32() float32 {
+	return float32(decode(h.bits, 5, 10))
+}
+
+// Float64 returns h as a float64.
+func (h Float16) Float64() float64 {
+	return decode(h.bits, 5, 10)
+}
+
+func (h Float16) String() string {
+	return strconv.FormatFloat(h.Float64(), 'g', -1, 32)
+}
+
+// Bits returns the bits of h.
+func (h BFloat16) Bits() uint16 {
+	return h.bits
+}
+
+// Float32 returns h as a float32, which represents it exactly.
+func (h BFloat16) Float32() float32 {
+	return float32(decode(h.bits, 8, 7))
+}
+
+// Float64 returns h as a float64.
+func (h BFloat16) Float64() float64 {
+	return decode(h.bits, 8, 7)
+}
+
+func (h BFloat16) String() string {
+	return strconv.FormatFloat(h.Float64(), 'g', -1, 32)
+}
+
+// encode returns the bits of f rounded to nearest even in a binary format of
+// 16 bits with the given exponent and mantissa bits.
+func encode(f float64, exponent, mantissa uint) uint16 {
+	bias := 1<<(exponent-1) - 1
+	infinity := uint16(1<<exponent-1) << mantissa
+
+	var sign uint16
+	if math.Signbit(f) {
+		sign = 1 << (exponent + mantissa)
+		f = -f
+	}
+	switch {
+	case math.IsNaN(f):
+		// quiet NaN
+		return sign | infinity | 1<<(mantissa-1)
+	case math.IsInf(f, 0):
+		return sign | infinity
+	case f == 0:
+		return sign
+	}
+
+	// f = frac 2^exp with frac in [0.5, 1)
+	frac, exp := math.Frexp(f)
+	e := exp - 1 + bias
+	if e <= 0 {
+		// subnormal numbers are multiples of 2^(1-bias-mantissa), rounding
+		// up to 2^mantissa gives the smallest normal number
+		return sign | uint16(math.RoundToEven(math.Ldexp(f, bias-1+int(mantissa))))
+	}
+
+	m := uint16(math.RoundToEven(math.Ldexp(2*frac-1, int(mantissa))))
+	if m == 1<<mantissa {
+		m = 0
+		e++
+	}
+	if e >= 1<<exponent-1 {
+		return sign | infinity
+	}
+	return sign | uint16(e)<<mantissa | m
+}
+
+// decode returns the number with the given bits in a binary format of 16 bits
+// with the given exponent and mantissa bits.
+func decode(b uint16, exponent, mantissa uint) float64 {
+	bias := 1<<(exponent-1) - 1
+	e := int(b>>mantissa) & (1<<exponent - 1)
+	m := float64(b & (1<<mantissa - 1))
+
+	var f float64
+	switch e {
+	case 0:
+		f = math.Ldexp(m, 1-bias-int(mantissa))
+	case 1<<exponent - 1:
+		if m == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(float64(uint(1)<<mantissa)+m, e-bias-int(mantissa))
+	}
+	if b>>(exponent+mantissa) != 0 {
+		f = -f
+	}
+	return f
+}
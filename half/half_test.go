@@ -0,0 +1,80 @@
+package half_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/blast-go/blast/half"
+)
+
+func TestFloat16(t *testing.T) {
+	cases := []struct {
+		value    float64
+		expected uint16
+	}{
+		{1, 0x3c00},
+		{-2, 0xc000},
+		{math.Copysign(0, -1), 0x8000},
+		{65504, 0x7bff},
+		// rounds up to 2^16
+		{65520, 0x7c00},
+		{math.Inf(-1), 0xfc00},
+		// subnormal numbers, ties to even
+		{math.Ldexp(1, -24), 0x0001},
+		{math.Ldexp(1, -25), 0x0000},
+		{math.Ldexp(3, -25), 0x0002},
+		{math.Ldexp(1023, -24), 0x03ff},
+		{1 + math.Ldexp(1, -11), 0x3c00},
+		{1 + math.Ldexp(3, -11), 0x3c02},
+		{0.1, 0x2e66},
+	}
+	for _, c := range cases {
+		if actual := half.NewFloat16(c.value); actual.Bits() != c.expected {
+			t.Errorf("%s: %v expected=%#04x got=%#04x", t.Name(), c.value, c.expected, actual.Bits())
+		}
+	}
+	if h := half.NewFloat16(math.NaN()); !math.IsNaN(h.Float64()) {
+		t.Errorf("%s: NaN expected got=%v", t.Name(), h)
+	}
+}
+
+func TestBFloat16(t *testing.T) {
+	cases := []struct {
+		value    float64
+		expected uint16
+	}{
+		{1, 0x3f80},
+		{-2, 0xc000},
+		{math.Pi, 0x4049},
+		{math.MaxFloat32, 0x7f80},
+		{math.Ldexp(1, -133), 0x0001},
+		{1 + math.Ldexp(1, -8), 0x3f80},
+		{1 + math.Ldexp(3, -8), 0x3f82},
+	}
+	for _, c := range cases {
+		if actual := half.NewBFloat16(c.value); actual.Bits() != c.expected {
+			t.Errorf("%s: %v expected=%#04x got=%#04x", t.Name(), c.value, c.expected, actual.Bits())
+		}
+	}
+
+	// bfloat16 numbers are the upper half of float32 numbers
+	for _, bits := range []uint32{0x3fc00000, 0xd0f20000, 0x000b0000} {
+		f := math.Float32frombits(bits)
+		if actual := half.NewBFloat16(float64(f)).Float32(); actual != f {
+			t.Errorf("%s: expected=%v got=%v", t.Name(), f, actual)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for b := 0; b <= math.MaxUint16; b++ {
+		f16 := half.FromBits[half.Float16](uint16(b))
+		if v := f16.Float64(); !math.IsNaN(v) && half.NewFloat16(v) != f16 {
+			t.Errorf("%s: float16 %#04x failed got=%#04x", t.Name(), b, half.NewFloat16(v).Bits())
+		}
+		bf16 := half.FromBits[half.BFloat16](uint16(b))
+		if v := bf16.Float64(); !math.IsNaN(v) && half.NewBFloat16(v) != bf16 {
+			t.Errorf("%s: bfloat16 %#04x failed got=%#04x", t.Name(), b, half.NewBFloat16(v).Bits())
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package linalg
+
+import (
+	"math"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/tensor"
+)
+
+// Cholesky returns the lower triangular matrices L with A = L L^T of a batch
+// of symmetric positive definite matrices. Only the lower triangle of A is
+// read and the gradient is symmetric. Panics if the matrices are not square
+// or not positive definite.
+func Cholesky[T constraints.Float](d cpu.CPU[T], a *tensor.Tensor[T]) *tensor.Tensor[T] {
+	b := newBatch(a.Shape())
+	b.square()
+
+	parents := []*tensor.Tensor[T]{a}
+	return matrixOp(d, parents, b.matrixShape(b.rows, b.cols), b.n, func(i int) matrix {
+		return cholesky(load(a.Elements(), i, b.rows, b.cols))
+	}, func(i int, l, grad matrix) {
+		// S = L^-T Φ(L^T gL) L^-1 with Φ the lower triangle with half the
+		// diagonal, gA = (S + S^T) / 2
+		phi := l.t().mul(grad).tril(0)
+		for j := 0; j < phi.rows; j++ {
+			phi.set(j, j, phi.at(j, j)/2)
+		}
+		s := solveUpper(l.t(), rightSolveUpperT(phi, l.t()))
+		accumulate(a.Grad(), i, s.add(s.t()).scale(0.5))
+	})
+}
+
+func cholesky(a matrix) matrix {
+	n := a.rows
+	l := newMatrix(n, n)
+	for j := 0; j < n; j++ {
+		diagonal := a.at(j, j)
+		for k := 0; k < j; k++ {
+			diagonal -= l.at(j, k) * l.at(j, k)
+		}
+		if diagonal <= 0 || math.IsNaN(diagonal) {
+			panic("matrix is not positive definite")
+		}
+		l.set(j, j, math.Sqrt(diagonal))
+
+		for i := j + 1; i < n; i++ {
+			e := a.at(i, j)
+			for k := 0; k < j; k++ {
+				e -= l.at(i, k) * l.at(j, k)
+			}
+			l.set(i, j, e/l.at(j, j))
+		}
+	}
+	return l
+}
@@ -0,0 +1,177 @@
+package linalg
+
+import (
+	"math"
+	"sort"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/tensor"
+)
+
+// jacobiSweeps is the maximum number of sweeps of the Jacobi methods, they
+// usually converge in less than ten.
+const jacobiSweeps = 100
+
+// Eigh returns the eigenvalues in ascending order and the eigenvectors of a
+// batch of symmetric matrices, A = V diag(w) V^T. The eigenvalues have shape
+// {n, batch...} and the eigenvectors are the columns of V, with shape
+// {n, n, batch...}, with the sign that makes their largest component
+// positive. Only the lower triangle of A is read and the gradient is
+// symmetric, it requires distinct eigenvalues when flowing from the
+// eigenvectors. The decomposition is computed with the cyclic Jacobi method.
+// Panics if the matrices are not square.
+func Eigh[T constraints.Float](d cpu.CPU[T], a *tensor.Tensor[T]) (w, v *tensor.Tensor[T]) {
+	b := newBatch(a.Shape())
+	b.square()
+	factors := once(func() [][2]matrix {
+		factors := make([][2]matrix, b.n)
+		elements := a.Elements()
+		for i := range factors {
+			w, v := jacobiEigh(load(elements, i, b.rows, b.cols))
+			factors[i] = [2]matrix{w, v}
+		}
+		return factors
+	})
+
+	// gA = V (diag(gw) + F o skew(V^T gV)) V^T with F_ij = 1 / (w_j - w_i)
+	gradient := func(i int, gw, gv matrix) {
+		w, v := factors()[i][0], factors()[i][1]
+		n := w.cols
+		m := newMatrix(n, n)
+		if gv.e != nil {
+			k := v.t().mul(gv)
+			for p := 0; p < n; p++ {
+				for q := 0; q < n; q++ {
+					if p != q {
+						m.set(p, q, (k.at(p, q)-k.at(q, p))/2/(w.e[q]-w.e[p]))
+					}
+				}
+			}
+		}
+		if gw.e != nil {
+			for p := 0; p < n; p++ {
+				m.set(p, p, gw.e[p])
+			}
+		}
+		g := v.mul(m).mul(v.t())
+		accumulate(a.Grad(), i, g.add(g.t()).scale(0.5))
+	}
+
+	parents := []*tensor.Tensor[T]{a}
+	w = matrixOp(d, parents, b.vectorShape(b.cols), b.n, func(i int) matrix {
+		return factors()[i][0]
+	}, func(i int, _, grad matrix) {
+		gradient(i, grad, matrix{})
+	})
+	v = matrixOp(d, parents, b.matrixShape(b.rows, b.cols), b.n, func(i int) matrix {
+		return factors()[i][1]
+	}, func(i int, _, grad matrix) {
+		gradient(i, matrix{}, grad)
+	})
+	return w, v
+}
+
+// jacobiEigh returns the eigenvalues in ascending order, as a row, and the
+// eigenvectors of the symmetric matrix with the lower triangle of a.
+func jacobiEigh(a matrix) (matrix, matrix) {
+	n := a.rows
+	s := a.tril(0)
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			s.set(j, i, s.at(i, j))
+		}
+	}
+	v := identity(n)
+
+	for sweep := 0; sweep < jacobiSweeps; sweep++ {
+		off, norm := 0.0, 0.0
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				norm += s.at(i, j) * s.at(i, j)
+				if i != j {
+					off += s.at(i, j) * s.at(i, j)
+				}
+			}
+		}
+		if off <= 1e-30*norm || off == 0 {
+			break
+		}
+
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if s.at(p, q) == 0 {
+					continue
+				}
+				// rotation that zeroes the element pq of J^T S J
+				theta := (s.at(q, q) - s.at(p, p)) / (2 * s.at(p, q))
+				t := math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				c := 1 / math.Sqrt(t*t+1)
+				rotateColumns(s, p, q, c, t*c)
+				rotateRows(s, p, q, c, t*c)
+				rotateColumns(v, p, q, c, t*c)
+			}
+		}
+	}
+
+	// eigenvalues in ascending order
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return s.at(order[i], order[i]) < s.at(order[j], order[j]) })
+	w := newMatrix(1, n)
+	vectors := newMatrix(n, n)
+	for j, k := range order {
+		w.e[j] = s.at(k, k)
+		for i := 0; i < n; i++ {
+			vectors.set(i, j, v.at(i, k))
+		}
+	}
+	normalizeSigns(vectors, nil)
+	return w, vectors
+}
+
+// rotateColumns replaces the columns p and q of m by c m_p - s m_q and
+// s m_p + c m_q.
+func rotateColumns(m matrix, p, q int, c, s float64) {
+	for i := 0; i < m.rows; i++ {
+		mp, mq := m.at(i, p), m.at(i, q)
+		m.set(i, p, c*mp-s*mq)
+		m.set(i, q, s*mp+c*mq)
+	}
+}
+
+// rotateRows replaces the rows p and q of m by c m_p - s m_q and
+// s m_p + c m_q.
+func rotateRows(m matrix, p, q int, c, s float64) {
+	for j := 0; j < m.cols; j++ {
+		mp, mq := m.at(p, j), m.at(q, j)
+		m.set(p, j, c*mp-s*mq)
+		m.set(q, j, s*mp+c*mq)
+	}
+}
+
+// normalizeSigns flips the columns of m whose largest component is negative,
+// and the same columns of other when it is not nil.
+func normalizeSigns(m matrix, other *matrix) {
+	for j := 0; j < m.cols; j++ {
+		largest := 0.0
+		for i := 0; i < m.rows; i++ {
+			if math.Abs(m.at(i, j)) > math.Abs(largest) {
+				largest = m.at(i, j)
+			}
+		}
+		if largest >= 0 {
+			continue
+		}
+		for i := 0; i < m.rows; i++ {
+			m.set(i, j, -m.at(i, j))
+		}
+		if other != nil {
+			for i := 0; i < other.rows; i++ {
+				other.set(i, j, -other.at(i, j))
+			}
+		}
+	}
+}
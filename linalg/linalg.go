@@ -0,0 +1,205 @@
+// Package linalg contains linear algebra routines over tensors of floats.
+//
+// Matrices follow the layout of cpu.CPU MatMul, a tensor of shape
+// {cols, rows} is a matrix with rows rows stored one after the other, and the
+// dimensions after the first two are a batch of matrices on which the
+// routines are applied independently. Computations are done in float64 and
+// gradients are computed when the device has them enabled.
+package linalg
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/tensor"
+)
+
+// LU returns the factorization with partial pivoting A = P L U of a batch of
+// square matrices, where P is a permutation matrix, L a lower triangular
+// matrix with a unit diagonal and U an upper triangular matrix. Gradients flow
+// from L and U and require U to be invertible. Panics if the matrices are
+// not square.
+func LU[T constraints.Float](d cpu.CPU[T], a *tensor.Tensor[T]) (p, l, u *tensor.Tensor[T]) {
+	b := newBatch(a.Shape())
+	b.square()
+	factors := factorize(a, b)
+	parents := []*tensor.Tensor[T]{a}
+	shape := b.matrixShape(b.rows, b.cols)
+
+	p = matrixOp(d, parents, shape, b.n, func(i int) matrix {
+		return factors()[i].p()
+	}, nil)
+
+	// A = P L U, gA = P L^-T (tril(L^T gL, -1) + triu(gU U^T)) U^-T
+	gradient := func(i int, r matrix) {
+		f := factors()[i]
+		l, u := f.l(), f.u()
+		g := f.p().mul(solveUpper(l.t(), r))
+		accumulate(a.Grad(), i, rightSolveUpperT(g, u))
+	}
+	l = matrixOp(d, parents, shape, b.n, func(i int) matrix {
+		return factors()[i].l()
+	}, func(i int, _, grad matrix) {
+		l := factors()[i].l()
+		gradient(i, l.t().mul(grad).tril(-1))
+	})
+	u = matrixOp(d, parents, shape, b.n, func(i int) matrix {
+		return factors()[i].u()
+	}, func(i int, _, grad matrix) {
+		u := factors()[i].u()
+		gradient(i, grad.mul(u.t()).triu())
+	})
+	return p, l, u
+}
+
+// Solve returns X with A X = B for a batch of square matrices A of shape
+// {n, n, batch...} and matrices B of shape {k, n, batch...}. Gradients flow
+// to both A and B. Panics if the shapes are incompatible or a matrix of A is
+// singular.
+func Solve[T constraints.Float](d cpu.CPU[T], a, b *tensor.Tensor[T]) *tensor.Tensor[T] {
+	ab := newBatch(a.Shape())
+	ab.square()
+	bb := newBatch(b.Shape())
+	if bb.rows != ab.rows || !tensor.SameShape(a.Shape()[2:], b.Shape()[2:]) {
+		panic(fmt.Sprintf("incompatible shapes for solve %v and %v", a.Shape(), b.Shape()))
+	}
+	factors := factorize(a, ab)
+
+	parents := []*tensor.Tensor[T]{a, b}
+	return matrixOp(d, parents, b.Shape(), ab.n, func(i int) matrix {
+		return factors()[i].solve(load(b.Elements(), i, bb.rows, bb.cols))
+	}, func(i int, x, grad matrix) {
+		// gB = A^-T gX, gA = -gB X^T
+		gB := factors()[i].inverse().t().mul(grad)
+		accumulate(b.Grad(), i, gB)
+		accumulate(a.Grad(), i, gB.mul(x.t()).scale(-1))
+	})
+}
+
+// Inv returns the inverse of a batch of square matrices. Panics if the
+// matrices are not square or a matrix is singular.
+func Inv[T constraints.Float](d cpu.CPU[T], a *tensor.Tensor[T]) *tensor.Tensor[T] {
+	b := newBatch(a.Shape())
+	b.square()
+	factors := factorize(a, b)
+
+	parents := []*tensor.Tensor[T]{a}
+	return matrixOp(d, parents, b.matrixShape(b.rows, b.cols), b.n, func(i int) matrix {
+		return factors()[i].inverse()
+	}, func(i int, inv, grad matrix) {
+		// gA = -A^-T gX A^-T
+		accumulate(a.Grad(), i, inv.t().mul(grad).mul(inv.t()).scale(-1))
+	})
+}
+
+// Det returns the determinant of a batch of square matrices with the shape of
+// the batch, {1} for a single matrix. The gradient requires the matrices to
+// be invertible. Panics if the matrices are not square.
+func Det[T constraints.Float](d cpu.CPU[T], a *tensor.Tensor[T]) *tensor.Tensor[T] {
+	b := newBatch(a.Shape())
+	b.square()
+	factors := factorize(a, b)
+
+	parents := []*tensor.Tensor[T]{a}
+	return matrixOp(d, parents, b.batchShape(), b.n, func(i int) matrix {
+		return matrix{rows: 1, cols: 1, e: []float64{factors()[i].det()}}
+	}, func(i int, det, grad matrix) {
+		// gA = g det A^-T
+		accumulate(a.Grad(), i, factors()[i].inverse().t().scale(grad.e[0]*det.e[0]))
+	})
+}
+
+// LogDet returns the sign and the logarithm of the absolute value of the
+// determinant of a batch of square matrices, with the shape of the batch. The
+// sign is zero and the logarithm minus infinity for singular matrices.
+// Gradients only flow from the logarithm and require the matrices to be
+// invertible. Panics if the matrices are not square.
+func LogDet[T constraints.Float](d cpu.CPU[T], a *tensor.Tensor[T]) (sign, logAbsDet *tensor.Tensor[T]) {
+	b := newBatch(a.Shape())
+	b.square()
+	factors := factorize(a, b)
+
+	parents := []*tensor.Tensor[T]{a}
+	sign = matrixOp(d, parents, b.batchShape(), b.n, func(i int) matrix {
+		f := factors()[i]
+		s := f.sign
+		for j := 0; j < b.rows && !f.singular; j++ {
+			if f.a.at(j, j) < 0 {
+				s = -s
+			}
+		}
+		if f.singular {
+			s = 0
+		}
+		return matrix{rows: 1, cols: 1, e: []float64{s}}
+	}, nil)
+
+	logAbsDet = matrixOp(d, parents, b.batchShape(), b.n, func(i int) matrix {
+		f := factors()[i]
+		logDet := 0.0
+		for j := 0; j < b.rows; j++ {
+			logDet += math.Log(math.Abs(f.a.at(j, j)))
+		}
+		if f.singular {
+			logDet = math.Inf(-1)
+		}
+		return matrix{rows: 1, cols: 1, e: []float64{logDet}}
+	}, func(i int, _, grad matrix) {
+		// gA = g A^-T
+		accumulate(a.Grad(), i, factors()[i].inverse().t().scale(grad.e[0]))
+	})
+	return sign, logAbsDet
+}
+
+// factorize returns a function that computes the LU factorization of the
+// matrices of a the first time it is called.
+func factorize[T constraints.Float](a *tensor.Tensor[T], b batch) func() []lu {
+	return once(func() []lu {
+		factors := make([]lu, b.n)
+		elements := a.Elements()
+		for i := range factors {
+			factors[i] = factorLU(load(elements, i, b.rows, b.cols))
+		}
+		return factors
+	})
+}
+
+// matrixOp returns a tensor with the given shape made of n matrices computed
+// by forward. backward receives every matrix of the result and its gradient
+// and adds the gradients of the parents, it can be nil for results without
+// gradients.
+func matrixOp[T constraints.Float](d cpu.CPU[T], parents []*tensor.Tensor[T], shape tensor.Shape, n int, forward func(i int) matrix, backward func(i int, out, grad matrix)) *tensor.Tensor[T] {
+	var rows, cols int
+	fwd := func() []T {
+		var elements []T
+		for i := 0; i < n; i++ {
+			m := forward(i)
+			if elements == nil {
+				rows, cols = m.rows, m.cols
+				elements = make([]T, n*rows*cols)
+			}
+			store(elements, i, m)
+		}
+		return elements
+	}
+
+	var bwd tensor.BackwardFunc[T]
+	if d.GradEnabled() && backward != nil {
+		bwd = func(tOut *tensor.Tensor[T]) {
+			elements := tOut.Elements()
+			grad := tOut.Grad()
+			for i := 0; i < n; i++ {
+				backward(i, load(elements, i, rows, cols), load(grad, i, rows, cols))
+			}
+		}
+	}
+
+	return tensor.Op(shape, parents, fwd, bwd)
+}
+
+// rightSolveUpperT returns Y U^-T for an upper triangular U.
+func rightSolveUpperT(y, u matrix) matrix {
+	return solveUpper(u, y.t()).t()
+}
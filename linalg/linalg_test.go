@@ -0,0 +1,247 @@
+package linalg_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/linalg"
+	"github.com/blast-go/blast/tensor"
+)
+
+// batch of two 3x3 matrices
+var matrices = []float64{
+	2, 1, 1,
+	4, -6, 0,
+	-2, 8, 2,
+
+	0, 2, 1,
+	1, 1, 1,
+	3, 0, -1,
+}
+
+func equal(t *testing.T, name string, expected, actual []float64) {
+	t.Helper()
+	if len(expected) != len(actual) {
+		t.Errorf("%s: %s invalid number of elements expected=%d got=%d", t.Name(), name, len(expected), len(actual))
+		return
+	}
+	for i, e := range expected {
+		if math.Abs(actual[i]-e) > 1e-9 {
+			t.Errorf("%s: %s failed expected=%v got=%v", t.Name(), name, expected, actual)
+			return
+		}
+	}
+}
+
+func TestLU(t *testing.T) {
+	d := cpu.New[float64]()
+	a := tensor.New(tensor.Shape{3, 3, 2}, append([]float64(nil), matrices...))
+
+	p, l, u := linalg.LU(d, a)
+	equal(t, "reconstruction", a.Elements(), d.BatchMatMul(p, d.BatchMatMul(l, u)).Elements())
+	for i := uint(0); i < 3; i++ {
+		for j := i + 1; j < 3; j++ {
+			if l.Get(j, i, 0) != 0 || u.Get(i, j, 1) != 0 {
+				t.Errorf("%s: factors are not triangular", t.Name())
+			}
+		}
+		if l.Get(i, i, 1) != 1 {
+			t.Errorf("%s: L doesn't have a unit diagonal", t.Name())
+		}
+	}
+}
+
+func TestSolveAndInv(t *testing.T) {
+	d := cpu.New[float64]()
+	a := tensor.New(tensor.Shape{3, 3, 2}, append([]float64(nil), matrices...))
+	b := tensor.New(tensor.Shape{2, 3, 2}, []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12})
+
+	x := linalg.Solve(d, a, b)
+	equal(t, "Solve", b.Elements(), d.BatchMatMul(a, x).Elements())
+
+	identity := []float64{1, 0, 0, 0, 1, 0, 0, 0, 1}
+	equal(t, "Inv", append(identity, identity...), d.BatchMatMul(a, linalg.Inv(d, a)).Elements())
+}
+
+func TestSolveSingular(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("%s: should panic with a singular matrix", t.Name())
+		}
+	}()
+	d := cpu.New[float64]()
+	a := tensor.New(tensor.Shape{2, 2}, []float64{1, 2, 2, 4})
+	linalg.Inv(d, a).Elements()
+}
+
+func TestDet(t *testing.T) {
+	d := cpu.New[float64]()
+	a := tensor.New(tensor.Shape{3, 3, 2}, append([]float64(nil), matrices...))
+
+	det := linalg.Det(d, a)
+	equal(t, "Det", []float64{-12, 5}, det.Elements())
+	if !tensor.EqualShape(det, tensor.Zeros[float64](tensor.Shape{2})) {
+		t.Errorf("%s: invalid shape %v", t.Name(), det.Shape())
+	}
+
+	sign, logAbsDet := linalg.LogDet(d, a)
+	equal(t, "sign", []float64{-1, 1}, sign.Elements())
+	equal(t, "LogDet", []float64{math.Log(12), math.Log(5)}, logAbsDet.Elements())
+
+	singular := tensor.New(tensor.Shape{2, 2}, []float64{1, 2, 2, 4})
+	equal(t, "singular Det", []float64{0}, linalg.Det(d, singular).Elements())
+	sign, logAbsDet = linalg.LogDet(d, singular)
+	if sign.Elements()[0] != 0 || !math.IsInf(logAbsDet.Elements()[0], -1) {
+		t.Errorf("%s: invalid LogDet of a singular matrix %v %v", t.Name(), sign, logAbsDet)
+	}
+}
+
+func TestCholesky(t *testing.T) {
+	d := cpu.New[float64]()
+	a := tensor.New(tensor.Shape{3, 3}, []float64{4, 12, -16, 12, 37, -43, -16, -43, 98})
+
+	l := linalg.Cholesky(d, a)
+	equal(t, "Cholesky", []float64{2, 0, 0, 6, 1, 0, -8, 5, 3}, l.Elements())
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("%s: should panic with a matrix that is not positive definite", t.Name())
+		}
+	}()
+	linalg.Cholesky(d, tensor.New(tensor.Shape{2, 2}, []float64{1, 2, 2, 1})).Elements()
+}
+
+func TestQR(t *testing.T) {
+	d := cpu.New[float64]()
+	// 4x3 matrices
+	a := tensor.New(tensor.Shape{3, 4, 2}, append(append([]float64(nil), matrices...), 1, -1, 2, 5, 0, 3))
+
+	q, r := linalg.QR(d, a)
+	if !tensor.EqualShape(q, a) || !tensor.EqualShape(r, tensor.Zeros[float64](tensor.Shape{3, 3, 2})) {
+		t.Fatalf("%s: invalid shapes %v %v", t.Name(), q.Shape(), r.Shape())
+	}
+	equal(t, "reconstruction", a.Elements(), d.BatchMatMul(q, r).Elements())
+
+	identity := []float64{1, 0, 0, 0, 1, 0, 0, 0, 1}
+	equal(t, "orthonormal", append(identity, identity...), d.BatchMatMul(d.Permute(q, 1, 0, 2), q).Elements())
+	for b := uint(0); b < 2; b++ {
+		for i := uint(0); i < 3; i++ {
+			if r.Get(i, i, b) < 0 {
+				t.Errorf("%s: negative diagonal in R", t.Name())
+			}
+		}
+	}
+}
+
+func TestLstsq(t *testing.T) {
+	d := cpu.New[float64]()
+	// fit of y = 1 + 2x with noise
+	a := tensor.New(tensor.Shape{2, 4}, []float64{1, 0, 1, 1, 1, 2, 1, 3})
+	b := tensor.New(tensor.Shape{1, 4}, []float64{1.1, 2.9, 5.2, 6.8})
+
+	x := linalg.Lstsq(d, a, b)
+	// normal equations A^T A x = A^T b
+	aT := d.Transpose(a)
+	expected := linalg.Solve(d, d.MatMul(aT, a), d.MatMul(aT, b))
+	equal(t, "Lstsq", expected.Elements(), x.Elements())
+}
+
+func TestGrad(t *testing.T) {
+	d := cpu.New[float64](cpu.WithGrad(true))
+	a := tensor.New(tensor.Shape{3, 3, 2}, append([]float64(nil), matrices...))
+	tall := tensor.New(tensor.Shape{3, 4, 2}, append(append([]float64(nil), matrices...), 1, -1, 2, 5, 0, 3))
+	b := tensor.New(tensor.Shape{2, 3, 2}, []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12})
+	tallB := tensor.New(tensor.Shape{2, 4, 2}, []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+
+	cases := map[string]struct {
+		inputs  []*tensor.Tensor[float64]
+		forward func() []*tensor.Tensor[float64]
+	}{
+		"LU": {[]*tensor.Tensor[float64]{a}, func() []*tensor.Tensor[float64] {
+			_, l, u := linalg.LU(d, a)
+			return []*tensor.Tensor[float64]{l, u}
+		}},
+		"Solve": {[]*tensor.Tensor[float64]{a, b}, func() []*tensor.Tensor[float64] {
+			return []*tensor.Tensor[float64]{linalg.Solve(d, a, b)}
+		}},
+		"Inv": {[]*tensor.Tensor[float64]{a}, func() []*tensor.Tensor[float64] {
+			return []*tensor.Tensor[float64]{linalg.Inv(d, a)}
+		}},
+		"Det": {[]*tensor.Tensor[float64]{a}, func() []*tensor.Tensor[float64] {
+			return []*tensor.Tensor[float64]{linalg.Det(d, a)}
+		}},
+		"LogDet": {[]*tensor.Tensor[float64]{a}, func() []*tensor.Tensor[float64] {
+			_, logAbsDet := linalg.LogDet(d, a)
+			return []*tensor.Tensor[float64]{logAbsDet}
+		}},
+		"Cholesky": {[]*tensor.Tensor[float64]{a}, func() []*tensor.Tensor[float64] {
+			// A^T A + I is positive definite
+			spd := d.Add(d.BatchMatMul(d.Permute(a, 1, 0, 2), a), tensor.New(tensor.Shape{3, 3, 2}, []float64{
+				1, 0, 0, 0, 1, 0, 0, 0, 1,
+				1, 0, 0, 0, 1, 0, 0, 0, 1,
+			}))
+			return []*tensor.Tensor[float64]{linalg.Cholesky(d, spd)}
+		}},
+		"QR": {[]*tensor.Tensor[float64]{tall}, func() []*tensor.Tensor[float64] {
+			q, r := linalg.QR(d, tall)
+			return []*tensor.Tensor[float64]{q, r}
+		}},
+		"Lstsq": {[]*tensor.Tensor[float64]{tall, tallB}, func() []*tensor.Tensor[float64] {
+			return []*tensor.Tensor[float64]{linalg.Lstsq(d, tall, tallB)}
+		}},
+	}
+
+	for name, c := range cases {
+		checkGrad(t, d, name, c.inputs, c.forward)
+	}
+}
+
+// checkGrad compares the gradients of a weighted sum of the outputs of
+// forward with respect to the inputs with numeric gradients.
+func checkGrad(t *testing.T, d cpu.CPU[float64], name string, inputs []*tensor.Tensor[float64], forward func() []*tensor.Tensor[float64]) {
+	t.Helper()
+	loss := func() (*tensor.Tensor[float64], float64) {
+		var total *tensor.Tensor[float64]
+		value := 0.0
+		for k, out := range forward() {
+			weights := tensor.Zeros[float64](out.Shape())
+			for i := range weights.Elements() {
+				weights.Elements()[i] = math.Sin(float64(i + 3*k + 1))
+			}
+			n := uint(len(weights.Elements()))
+			weighted := d.Reshape(d.Hadamard(out, weights), tensor.Shape{n, 1})
+			sum := d.MatMul(weighted, tensor.Ones[float64](tensor.Shape{1, n}))
+			value += sum.Elements()[0]
+			if total == nil {
+				total = sum
+			} else {
+				total = d.Add(total, sum)
+			}
+		}
+		return total, value
+	}
+
+	for _, input := range inputs {
+		input.ZeroGrad()
+	}
+	total, _ := loss()
+	total.Backward()
+
+	h := 1e-6
+	for k, input := range inputs {
+		elements := input.Elements()
+		for i, e := range elements {
+			elements[i] = e + h
+			_, plus := loss()
+			elements[i] = e - h
+			_, minus := loss()
+			elements[i] = e
+
+			numeric := (plus - minus) / (2 * h)
+			if math.Abs(input.Grad()[i]-numeric) > 1e-5*math.Max(1, math.Abs(numeric)) {
+				t.Errorf("%s: %s gradient of input %d failed at %d expected=%f got=%f", t.Name(), name, k, i, numeric, input.Grad()[i])
+			}
+		}
+	}
+}
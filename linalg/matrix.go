@@ -0,0 +1,338 @@
+package linalg
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/tensor"
+)
+
+// matrix is a dense matrix of float64 stored by rows, the computations of
+// the package are done on matrices and converted back to the element type of
+// the tensors.
+type matrix struct {
+	rows, cols int
+	e          []float64
+}
+
+func newMatrix(rows, cols int) matrix {
+	return matrix{rows: rows, cols: cols, e: make([]float64, rows*cols)}
+}
+
+func identity(n int) matrix {
+	m := newMatrix(n, n)
+	for i := 0; i < n; i++ {
+		m.set(i, i, 1)
+	}
+	return m
+}
+
+func (m matrix) at(i, j int) float64 {
+	return m.e[i*m.cols+j]
+}
+
+func (m matrix) set(i, j int, v float64) {
+	m.e[i*m.cols+j] = v
+}
+
+func (m matrix) copy() matrix {
+	return matrix{rows: m.rows, cols: m.cols, e: append([]float64(nil), m.e...)}
+}
+
+// mul returns the product m b.
+func (m matrix) mul(b matrix) matrix {
+	out := newMatrix(m.rows, b.cols)
+	for i := 0; i < m.rows; i++ {
+		for k := 0; k < m.cols; k++ {
+			a := m.at(i, k)
+			if a == 0 {
+				continue
+			}
+			for j := 0; j < b.cols; j++ {
+				out.e[i*out.cols+j] += a * b.at(k, j)
+			}
+		}
+	}
+	return out
+}
+
+func (m matrix) t() matrix {
+	out := newMatrix(m.cols, m.rows)
+	for i := 0; i < m.rows; i++ {
+		for j := 0; j < m.cols; j++ {
+			out.set(j, i, m.at(i, j))
+		}
+	}
+	return out
+}
+
+func (m matrix) add(b matrix) matrix {
+	out := m.copy()
+	for i, e := range b.e {
+		out.e[i] += e
+	}
+	return out
+}
+
+func (m matrix) sub(b matrix) matrix {
+	out := m.copy()
+	for i, e := range b.e {
+		out.e[i] -= e
+	}
+	return out
+}
+
+func (m matrix) scale(s float64) matrix {
+	out := m.copy()
+	for i := range out.e {
+		out.e[i] *= s
+	}
+	return out
+}
+
+// tril returns the lower triangle of m below diagonal k, k = -1 excludes the
+// diagonal.
+func (m matrix) tril(k int) matrix {
+	out := newMatrix(m.rows, m.cols)
+	for i := 0; i < m.rows; i++ {
+		for j := 0; j <= i+k && j < m.cols; j++ {
+			out.set(i, j, m.at(i, j))
+		}
+	}
+	return out
+}
+
+// triu returns the upper triangle of m including the diagonal.
+func (m matrix) triu() matrix {
+	out := newMatrix(m.rows, m.cols)
+	for i := 0; i < m.rows; i++ {
+		for j := i; j < m.cols; j++ {
+			out.set(i, j, m.at(i, j))
+		}
+	}
+	return out
+}
+
+// lu is the factorization with partial pivoting P^T A = L U of a square
+// matrix. L has a unit diagonal and is stored below the diagonal of a, U is
+// stored in the rest. Row i of L U is row perm[i] of A.
+type lu struct {
+	a    matrix
+	perm []int
+	// sign is the determinant of the permutation.
+	sign     float64
+	singular bool
+}
+
+func factorLU(m matrix) lu {
+	n := m.rows
+	f := lu{a: m.copy(), perm: make([]int, n), sign: 1}
+	for i := range f.perm {
+		f.perm[i] = i
+	}
+
+	a := f.a
+	for k := 0; k < n; k++ {
+		// the largest element of the column is the pivot
+		pivot := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(a.at(i, k)) > math.Abs(a.at(pivot, k)) {
+				pivot = i
+			}
+		}
+		if a.at(pivot, k) == 0 {
+			f.singular = true
+			continue
+		}
+		if pivot != k {
+			for j := 0; j < n; j++ {
+				a.e[k*n+j], a.e[pivot*n+j] = a.e[pivot*n+j], a.e[k*n+j]
+			}
+			f.perm[k], f.perm[pivot] = f.perm[pivot], f.perm[k]
+			f.sign = -f.sign
+		}
+
+		for i := k + 1; i < n; i++ {
+			l := a.at(i, k) / a.at(k, k)
+			a.set(i, k, l)
+			for j := k + 1; j < n; j++ {
+				a.e[i*n+j] -= l * a.at(k, j)
+			}
+		}
+	}
+	return f
+}
+
+// l returns the unit lower triangular factor.
+func (f lu) l() matrix {
+	l := f.a.tril(-1)
+	for i := 0; i < l.rows; i++ {
+		l.set(i, i, 1)
+	}
+	return l
+}
+
+// u returns the upper triangular factor.
+func (f lu) u() matrix {
+	return f.a.triu()
+}
+
+// p returns the permutation matrix P with A = P L U.
+func (f lu) p() matrix {
+	p := newMatrix(f.a.rows, f.a.rows)
+	for i, j := range f.perm {
+		p.set(j, i, 1)
+	}
+	return p
+}
+
+// solve returns X with A X = b. Panics if A is singular.
+func (f lu) solve(b matrix) matrix {
+	if f.singular {
+		panic("matrix is singular")
+	}
+	n := f.a.rows
+	x := newMatrix(n, b.cols)
+	for i, j := range f.perm {
+		copy(x.e[i*b.cols:(i+1)*b.cols], b.e[j*b.cols:(j+1)*b.cols])
+	}
+	return solveUpper(f.a, solveLower(f.a, x, true))
+}
+
+func (f lu) inverse() matrix {
+	return f.solve(identity(f.a.rows))
+}
+
+func (f lu) det() float64 {
+	if f.singular {
+		return 0
+	}
+	det := f.sign
+	for i := 0; i < f.a.rows; i++ {
+		det *= f.a.at(i, i)
+	}
+	return det
+}
+
+// solveLower returns X with L X = b using the lower triangle of l, its
+// diagonal is taken as one when unit is true.
+func solveLower(l, b matrix, unit bool) matrix {
+	x := b.copy()
+	for i := 0; i < l.rows; i++ {
+		for k := 0; k < i; k++ {
+			if a := l.at(i, k); a != 0 {
+				for j := 0; j < x.cols; j++ {
+					x.e[i*x.cols+j] -= a * x.at(k, j)
+				}
+			}
+		}
+		if !unit {
+			for j := 0; j < x.cols; j++ {
+				x.e[i*x.cols+j] /= l.at(i, i)
+			}
+		}
+	}
+	return x
+}
+
+// solveUpper returns X with U X = b using the upper triangle of u.
+func solveUpper(u, b matrix) matrix {
+	x := b.copy()
+	for i := u.rows - 1; i >= 0; i-- {
+		for k := i + 1; k < u.rows; k++ {
+			if a := u.at(i, k); a != 0 {
+				for j := 0; j < x.cols; j++ {
+					x.e[i*x.cols+j] -= a * x.at(k, j)
+				}
+			}
+		}
+		for j := 0; j < x.cols; j++ {
+			x.e[i*x.cols+j] /= u.at(i, i)
+		}
+	}
+	return x
+}
+
+// batch describes a tensor of shape {cols, rows, batch...} as a batch of
+// matrices.
+type batch struct {
+	rows, cols, n int
+	shape         tensor.Shape
+}
+
+func newBatch(shape tensor.Shape) batch {
+	if len(shape) < 2 {
+		panic(fmt.Sprintf("tensor of shape %v is not a matrix", shape))
+	}
+	n := 1
+	for _, d := range shape[2:] {
+		n *= int(d)
+	}
+	return batch{rows: int(shape[1]), cols: int(shape[0]), n: n, shape: shape}
+}
+
+// square panics if the matrices of the batch are not square.
+func (b batch) square() {
+	if b.rows != b.cols {
+		panic(fmt.Sprintf("matrices of shape %v are not square", b.shape))
+	}
+}
+
+// batchShape returns the shape of the batch, {1} for a single matrix.
+func (b batch) batchShape() tensor.Shape {
+	if len(b.shape) == 2 {
+		return tensor.Shape{1}
+	}
+	return append(tensor.Shape(nil), b.shape[2:]...)
+}
+
+// matrixShape returns the shape of a batch of matrices with the given rows
+// and columns.
+func (b batch) matrixShape(rows, cols int) tensor.Shape {
+	return append(tensor.Shape{uint(cols), uint(rows)}, b.shape[2:]...)
+}
+
+// vectorShape returns the shape of a batch of vectors with n elements.
+func (b batch) vectorShape(n int) tensor.Shape {
+	return append(tensor.Shape{uint(n)}, b.shape[2:]...)
+}
+
+// load returns matrix i of the elements of a batch of matrices with the
+// given rows and columns.
+func load[T constraints.Float](elements []T, i, rows, cols int) matrix {
+	m := newMatrix(rows, cols)
+	for j, e := range elements[i*rows*cols : (i+1)*rows*cols] {
+		m.e[j] = float64(e)
+	}
+	return m
+}
+
+// store sets matrix i of a batch of elements to m.
+func store[T constraints.Float](elements []T, i int, m matrix) {
+	size := m.rows * m.cols
+	for j, e := range m.e {
+		elements[i*size+j] = T(e)
+	}
+}
+
+// accumulate adds m to matrix i of a batch of gradients.
+func accumulate[T constraints.Float](grad []T, i int, m matrix) {
+	size := m.rows * m.cols
+	for j, e := range m.e {
+		grad[i*size+j] += T(e)
+	}
+}
+
+// once returns a function that calls f the first time and returns its result
+// every time, so the outputs of a factorization can share it.
+func once[R any](f func() R) func() R {
+	var r R
+	done := false
+	return func() R {
+		if !done {
+			r, done = f(), true
+		}
+		return r
+	}
+}
@@ -0,0 +1,142 @@
+package linalg
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/tensor"
+)
+
+// QR returns the reduced QR factorization A = Q R of a batch of matrices with
+// m rows and n columns, m >= n. Q has shape {n, m, batch...} with orthonormal
+// columns and R shape {n, n, batch...} is upper triangular with a non
+// negative diagonal. The factorization is computed with Householder
+// reflections. Gradients flow from Q and R and require A to have full rank.
+// Panics if the matrices have more columns than rows.
+func QR[T constraints.Float](d cpu.CPU[T], a *tensor.Tensor[T]) (q, r *tensor.Tensor[T]) {
+	b := newBatch(a.Shape())
+	if b.rows < b.cols {
+		panic(fmt.Sprintf("QR of matrices of shape %v with more columns than rows", a.Shape()))
+	}
+	factors := once(func() [][2]matrix {
+		factors := make([][2]matrix, b.n)
+		elements := a.Elements()
+		for i := range factors {
+			q, r := householderQR(load(elements, i, b.rows, b.cols))
+			factors[i] = [2]matrix{q, r}
+		}
+		return factors
+	})
+
+	// M = R gR^T - gQ^T Q, gA = (gQ + Q copyltu(M)) R^-T where copyltu
+	// builds a symmetric matrix from the lower triangle of M
+	gradient := func(i int, gQ, m matrix) {
+		q, r := factors()[i][0], factors()[i][1]
+		sym := m.tril(0).add(m.tril(-1).t())
+		accumulate(a.Grad(), i, rightSolveUpperT(gQ.add(q.mul(sym)), r))
+	}
+
+	parents := []*tensor.Tensor[T]{a}
+	q = matrixOp(d, parents, b.matrixShape(b.rows, b.cols), b.n, func(i int) matrix {
+		return factors()[i][0]
+	}, func(i int, q, grad matrix) {
+		gradient(i, grad, grad.t().mul(q).scale(-1))
+	})
+	r = matrixOp(d, parents, b.matrixShape(b.cols, b.cols), b.n, func(i int) matrix {
+		return factors()[i][1]
+	}, func(i int, r, grad matrix) {
+		gradient(i, newMatrix(b.rows, b.cols), r.mul(grad.t()))
+	})
+	return q, r
+}
+
+// Lstsq returns the solution X of the least squares problem min ||A X - B||
+// for a batch of matrices A with m rows and n columns, m >= n, and matrices
+// B of shape {k, m, batch...}. X has shape {k, n, batch...} and is computed
+// as R^-1 Q^T B from the QR factorization of A, so gradients flow to both A
+// and B when A has full rank. Panics if the shapes are incompatible.
+func Lstsq[T constraints.Float](d cpu.CPU[T], a, b *tensor.Tensor[T]) *tensor.Tensor[T] {
+	if len(b.Shape()) < 2 || len(a.Shape()) != len(b.Shape()) || a.Shape()[1] != b.Shape()[1] {
+		panic(fmt.Sprintf("incompatible shapes for least squares %v and %v", a.Shape(), b.Shape()))
+	}
+	q, r := QR(d, a)
+	return Solve(d, r, d.BatchMatMul(d.Permute(q, transposeAxes(len(a.Shape()))...), b))
+}
+
+// householderQR returns the reduced QR factorization of a matrix with at least
+// as many rows as columns with a non negative diagonal in R.
+func householderQR(a matrix) (matrix, matrix) {
+	m, n := a.rows, a.cols
+	r := a.copy()
+	reflections := make([][]float64, n)
+	for k := 0; k < n; k++ {
+		norm := 0.0
+		for i := k; i < m; i++ {
+			norm += r.at(i, k) * r.at(i, k)
+		}
+		norm = math.Sqrt(norm)
+		if norm == 0 {
+			continue
+		}
+
+		// v = x - alpha e1 normalized, with the sign of alpha opposite to
+		// the one of x0 to avoid cancellations
+		alpha := -math.Copysign(norm, r.at(k, k))
+		v := make([]float64, m-k)
+		for i := range v {
+			v[i] = r.at(k+i, k)
+		}
+		v[0] -= alpha
+		vNorm := 0.0
+		for _, e := range v {
+			vNorm += e * e
+		}
+		vNorm = math.Sqrt(vNorm)
+		for i := range v {
+			v[i] /= vNorm
+		}
+		reflect(r, v, k)
+		reflections[k] = v
+	}
+
+	// Q is the product of the reflections applied to the first n columns of
+	// the identity
+	q := newMatrix(m, n)
+	for i := 0; i < n; i++ {
+		q.set(i, i, 1)
+	}
+	for k := n - 1; k >= 0; k-- {
+		if reflections[k] != nil {
+			reflect(q, reflections[k], k)
+		}
+	}
+
+	r = matrix{rows: n, cols: n, e: r.e[:n*n]}.triu()
+	for i := 0; i < n; i++ {
+		if r.at(i, i) < 0 {
+			for j := 0; j < n; j++ {
+				r.set(i, j, -r.at(i, j))
+			}
+			for j := 0; j < m; j++ {
+				q.set(j, i, -q.at(j, i))
+			}
+		}
+	}
+	return q, r
+}
+
+// reflect applies the Householder reflection I - 2 v v^T to the rows from k
+// of m.
+func reflect(m matrix, v []float64, k int) {
+	for j := 0; j < m.cols; j++ {
+		dot := 0.0
+		for i, e := range v {
+			dot += e * m.at(k+i, j)
+		}
+		for i, e := range v {
+			m.e[(k+i)*m.cols+j] -= 2 * e * dot
+		}
+	}
+}
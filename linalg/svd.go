@@ -0,0 +1,274 @@
+package linalg
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/tensor"
+)
+
+// oversamples is the number of directions sampled by TruncatedSVD besides
+// the requested ones.
+const oversamples = 10
+
+// SVD returns the reduced singular value decomposition A = U diag(s) V^T of a
+// batch of matrices with m rows and n columns. With k = min(m, n), U has shape
+// {k, m, batch...}, s shape {k, batch...} with the singular values in
+// descending order and V shape {k, n, batch...}. The singular vectors are
+// the columns of U and V, with the sign that makes the largest component of
+// the columns of U positive. The decomposition is computed with the one-sided
+// Jacobi method. The columns of U of zero singular values complete the
+// others to an orthonormal basis. Gradients require distinct non zero
+// singular values when flowing from U or V.
+func SVD[T constraints.Float](d cpu.CPU[T], a *tensor.Tensor[T]) (u, s, v *tensor.Tensor[T]) {
+	b := newBatch(a.Shape())
+	k := b.rows
+	if b.cols < k {
+		k = b.cols
+	}
+	factors := once(func() [][3]matrix {
+		factors := make([][3]matrix, b.n)
+		elements := a.Elements()
+		for i := range factors {
+			u, s, v := jacobiSVD(load(elements, i, b.rows, b.cols))
+			factors[i] = [3]matrix{u, s, v}
+		}
+		return factors
+	})
+
+	// with F_ij = 1 / (s_j^2 - s_i^2) and S = diag(s)
+	// gA = U [(F o (U^T gU - gU^T U)) S + diag(gs) + S (F o (V^T gV - gV^T V))] V^T
+	//    + (I - U U^T) gU S^-1 V^T + U S^-1 gV^T (I - V V^T)
+	gradient := func(i int, gu, gs, gv matrix) {
+		u, s, v := factors()[i][0], factors()[i][1], factors()[i][2]
+		inner := newMatrix(k, k)
+		var g matrix
+		switch {
+		case gs.e != nil:
+			for p := 0; p < k; p++ {
+				inner.set(p, p, gs.e[p])
+			}
+			g = u.mul(inner).mul(v.t())
+		case gu.e != nil:
+			j := u.t().mul(gu)
+			for p := 0; p < k; p++ {
+				for q := 0; q < k; q++ {
+					if p != q {
+						inner.set(p, q, (j.at(p, q)-j.at(q, p))/(s.e[q]*s.e[q]-s.e[p]*s.e[p])*s.e[q])
+					}
+				}
+			}
+			// the component of gU orthogonal to U
+			orthogonal := gu.sub(u.mul(u.t().mul(gu)))
+			g = u.mul(inner).mul(v.t()).add(divideColumns(orthogonal, s).mul(v.t()))
+		default:
+			j := v.t().mul(gv)
+			for p := 0; p < k; p++ {
+				for q := 0; q < k; q++ {
+					if p != q {
+						inner.set(p, q, s.e[p]*(j.at(p, q)-j.at(q, p))/(s.e[q]*s.e[q]-s.e[p]*s.e[p]))
+					}
+				}
+			}
+			orthogonal := gv.sub(v.mul(v.t().mul(gv)))
+			g = u.mul(inner).mul(v.t()).add(u.mul(divideColumns(orthogonal, s).t()))
+		}
+		accumulate(a.Grad(), i, g)
+	}
+
+	parents := []*tensor.Tensor[T]{a}
+	u = matrixOp(d, parents, b.matrixShape(b.rows, k), b.n, func(i int) matrix {
+		return factors()[i][0]
+	}, func(i int, _, grad matrix) {
+		gradient(i, grad, matrix{}, matrix{})
+	})
+	s = matrixOp(d, parents, b.vectorShape(k), b.n, func(i int) matrix {
+		return factors()[i][1]
+	}, func(i int, _, grad matrix) {
+		gradient(i, matrix{}, grad, matrix{})
+	})
+	v = matrixOp(d, parents, b.matrixShape(b.cols, k), b.n, func(i int) matrix {
+		return factors()[i][2]
+	}, func(i int, _, grad matrix) {
+		gradient(i, matrix{}, matrix{}, grad)
+	})
+	return u, s, v
+}
+
+// TruncatedSVD returns an approximation of the k largest singular values and
+// their singular vectors of a batch of matrices, with the shapes of SVD for
+// the given k. It uses the randomized algorithm of Halko, Martinsson and
+// Tropp: the range of A is sampled with random directions drawn from rng,
+// refined with the given number of power iterations, and the SVD of the
+// projection of A on that range is computed. It is composed of QR, SVD and
+// BatchMatMul so gradients flow to A. Panics if k is zero or greater than the
+// dimensions of the matrices.
+func TruncatedSVD[T constraints.Float](d cpu.CPU[T], a *tensor.Tensor[T], k, iterations int, rng *rand.Rand) (u, s, v *tensor.Tensor[T]) {
+	b := newBatch(a.Shape())
+	if k <= 0 || k > b.rows || k > b.cols {
+		panic(fmt.Sprintf("invalid rank %d for matrices of shape %v", k, a.Shape()))
+	}
+	samples := k + oversamples
+	if samples > b.cols {
+		samples = b.cols
+	}
+	if samples > b.rows {
+		samples = b.rows
+	}
+
+	omega := tensor.Zeros[T](b.matrixShape(b.cols, samples))
+	for i := range omega.Elements() {
+		omega.Elements()[i] = T(rng.NormFloat64())
+	}
+	aT := d.Permute(a, transposeAxes(len(a.Shape()))...)
+
+	// orthonormal basis Q of the range of (A A^T)^iterations A omega
+	q, _ := QR(d, d.BatchMatMul(a, omega))
+	for i := 0; i < iterations; i++ {
+		z, _ := QR(d, d.BatchMatMul(aT, q))
+		q, _ = QR(d, d.BatchMatMul(a, z))
+	}
+
+	// A ~ Q Q^T A = (Q U') S V^T
+	qT := d.Permute(q, transposeAxes(len(a.Shape()))...)
+	uSmall, s, v := SVD(d, d.BatchMatMul(qT, a))
+	u = d.BatchMatMul(q, uSmall)
+
+	ranges := []tensor.Range{{Start: 0, Stop: k, Step: 1}}
+	return d.Slice(u, ranges...), d.Slice(s, ranges...), d.Slice(v, ranges...)
+}
+
+// jacobiSVD returns the reduced singular value decomposition of a with the
+// singular values in descending order as a row.
+func jacobiSVD(a matrix) (matrix, matrix, matrix) {
+	if a.rows < a.cols {
+		v, s, u := jacobiSVD(a.t())
+		normalizeSigns(u, &v)
+		return u, s, v
+	}
+
+	// the columns of U are rotated until they are orthogonal
+	u, v := a.copy(), identity(a.cols)
+	n := a.cols
+	for sweep := 0; sweep < jacobiSweeps; sweep++ {
+		rotated := false
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				alpha, beta, gamma := 0.0, 0.0, 0.0
+				for i := 0; i < u.rows; i++ {
+					alpha += u.at(i, p) * u.at(i, p)
+					beta += u.at(i, q) * u.at(i, q)
+					gamma += u.at(i, p) * u.at(i, q)
+				}
+				if gamma == 0 || math.Abs(gamma) <= 1e-15*math.Sqrt(alpha*beta) {
+					continue
+				}
+				rotated = true
+				zeta := (beta - alpha) / (2 * gamma)
+				t := math.Copysign(1, zeta) / (math.Abs(zeta) + math.Sqrt(1+zeta*zeta))
+				c := 1 / math.Sqrt(1+t*t)
+				rotateColumns(u, p, q, c, c*t)
+				rotateColumns(v, p, q, c, c*t)
+			}
+		}
+		if !rotated {
+			break
+		}
+	}
+
+	// the singular values are the norms of the columns
+	norms := make([]float64, n)
+	for j := range norms {
+		for i := 0; i < u.rows; i++ {
+			norms[j] += u.at(i, j) * u.at(i, j)
+		}
+		norms[j] = math.Sqrt(norms[j])
+	}
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return norms[order[i]] > norms[order[j]] })
+
+	uSorted, s, vSorted := newMatrix(u.rows, n), newMatrix(1, n), newMatrix(n, n)
+	for j, k := range order {
+		s.e[j] = norms[k]
+		for i := 0; i < u.rows; i++ {
+			if norms[k] != 0 {
+				uSorted.set(i, j, u.at(i, k)/norms[k])
+			}
+		}
+		for i := 0; i < n; i++ {
+			vSorted.set(i, j, v.at(i, k))
+		}
+	}
+	// the columns of zero singular values are sorted last
+	rank := 0
+	for rank < n && s.e[rank] != 0 {
+		rank++
+	}
+	completeColumns(uSorted, rank)
+	normalizeSigns(uSorted, &vSorted)
+	return uSorted, s, vSorted
+}
+
+// completeColumns replaces the columns of m after the first n orthonormal
+// ones by orthonormal vectors, made with Gram-Schmidt from the vectors of the
+// standard basis that aren't in the span of the previous columns.
+func completeColumns(m matrix, n int) {
+	for i := 0; n < m.cols && i < m.rows; i++ {
+		column := make([]float64, m.rows)
+		column[i] = 1
+		// projected twice to keep the orthogonality lost to rounding
+		for pass := 0; pass < 2; pass++ {
+			for j := 0; j < n; j++ {
+				dot := 0.0
+				for r := range column {
+					dot += m.at(r, j) * column[r]
+				}
+				for r := range column {
+					column[r] -= dot * m.at(r, j)
+				}
+			}
+		}
+		norm := 0.0
+		for _, e := range column {
+			norm += e * e
+		}
+		// the vectors of the basis in the span have a norm near zero, one of
+		// them out of it has a norm of at least 1/sqrt(rows)
+		if norm = math.Sqrt(norm); norm < 0.5/math.Sqrt(float64(m.rows)) {
+			continue
+		}
+		for r, e := range column {
+			m.set(r, n, e/norm)
+		}
+		n++
+	}
+}
+
+// divideColumns returns m with every column j divided by s_j.
+func divideColumns(m, s matrix) matrix {
+	out := m.copy()
+	for i := 0; i < m.rows; i++ {
+		for j := 0; j < m.cols; j++ {
+			out.set(i, j, m.at(i, j)/s.e[j])
+		}
+	}
+	return out
+}
+
+// transposeAxes returns the permutation of n dimensions that transposes the
+// matrices of a batch.
+func transposeAxes(n int) []int {
+	axes := make([]int, n)
+	for i := range axes {
+		axes[i] = i
+	}
+	axes[0], axes[1] = 1, 0
+	return axes
+}
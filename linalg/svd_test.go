@@ -0,0 +1,124 @@
+package linalg_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/linalg"
+	"github.com/blast-go/blast/tensor"
+)
+
+// diagonal returns a batch of diagonal matrices with the given vectors.
+func diagonal(d cpu.CPU[float64], s *tensor.Tensor[float64]) *tensor.Tensor[float64] {
+	k := s.Shape()[0]
+	shape := append(tensor.Shape{k, k}, s.Shape()[1:]...)
+	out := tensor.Zeros[float64](shape)
+	for i, e := range s.Elements() {
+		j := uint(i) % k
+		out.Elements()[uint(i)/k*k*k+j*k+j] = e
+	}
+	return out
+}
+
+func TestSVD(t *testing.T) {
+	d := cpu.New[float64]()
+	tall := tensor.New(tensor.Shape{3, 4, 2}, append(append([]float64(nil), matrices...), 1, -1, 2, 5, 0, 3))
+	wide := d.Permute(tall, 1, 0, 2)
+
+	for name, a := range map[string]*tensor.Tensor[float64]{"tall": tall, "wide": wide} {
+		u, s, v := linalg.SVD(d, a)
+		if !tensor.EqualShape(s, tensor.Zeros[float64](tensor.Shape{3, 2})) {
+			t.Fatalf("%s: %s invalid shape %v", t.Name(), name, s.Shape())
+		}
+		reconstruction := d.BatchMatMul(d.BatchMatMul(u, diagonal(d, s)), d.Permute(v, 1, 0, 2))
+		equal(t, name+" reconstruction", a.Elements(), reconstruction.Elements())
+
+		identity := []float64{1, 0, 0, 0, 1, 0, 0, 0, 1}
+		equal(t, name+" U", append(identity, identity...), d.BatchMatMul(d.Permute(u, 1, 0, 2), u).Elements())
+		equal(t, name+" V", append(identity, identity...), d.BatchMatMul(d.Permute(v, 1, 0, 2), v).Elements())
+		for b := uint(0); b < 2; b++ {
+			if s.Get(0, b) < s.Get(1, b) || s.Get(1, b) < s.Get(2, b) || s.Get(2, b) < 0 {
+				t.Errorf("%s: %s singular values are not sorted %v", t.Name(), name, s)
+			}
+		}
+	}
+
+	// singular values of a diagonal matrix
+	_, s, _ := linalg.SVD(d, tensor.New(tensor.Shape{2, 2}, []float64{-3, 0, 0, 4}))
+	equal(t, "diagonal", []float64{4, 3}, s.Elements())
+
+	// the columns of U of zero singular values are still orthonormal
+	rankDeficient := map[string]*tensor.Tensor[float64]{
+		"rank 1": tensor.New(tensor.Shape{3, 4}, []float64{1, 2, 0, 1, 2, 0, 3, 6, 0, -1, -2, 0}),
+		"zeros":  tensor.Zeros[float64](tensor.Shape{3, 4}),
+	}
+	for name, a := range rankDeficient {
+		u, s, v := linalg.SVD(d, a)
+		reconstruction := d.MatMul(d.MatMul(u, diagonal(d, s)), d.Transpose(v))
+		equal(t, name+" reconstruction", a.Elements(), reconstruction.Elements())
+		equal(t, name+" U", []float64{1, 0, 0, 0, 1, 0, 0, 0, 1}, d.MatMul(d.Transpose(u), u).Elements())
+	}
+}
+
+func TestTruncatedSVD(t *testing.T) {
+	d := cpu.New[float64]()
+	// a matrix of rank 2 with 12 rows and 20 columns
+	rng := rand.New(rand.NewSource(1))
+	x := tensor.Zeros[float64](tensor.Shape{2, 12})
+	y := tensor.Zeros[float64](tensor.Shape{20, 2})
+	for _, m := range []*tensor.Tensor[float64]{x, y} {
+		for i := range m.Elements() {
+			m.Elements()[i] = rng.NormFloat64()
+		}
+	}
+	a := d.MatMul(x, y)
+
+	u, s, v := linalg.TruncatedSVD(d, a, 2, 1, rng)
+	_, expected, _ := linalg.SVD(d, a)
+	equal(t, "singular values", expected.Elements()[:2], s.Elements())
+	equal(t, "reconstruction", a.Elements(), d.MatMul(d.MatMul(u, diagonal(d, s)), d.Transpose(v)).Elements())
+}
+
+func TestEigh(t *testing.T) {
+	d := cpu.New[float64]()
+	// only the lower triangle is read
+	a := tensor.New(tensor.Shape{3, 3}, []float64{2, 100, 100, -1, 2, 100, 0, -1, 2})
+
+	w, v := linalg.Eigh(d, a)
+	equal(t, "eigenvalues", []float64{2 - math.Sqrt2, 2, 2 + math.Sqrt2}, w.Elements())
+
+	symmetric := tensor.New(tensor.Shape{3, 3}, []float64{2, -1, 0, -1, 2, -1, 0, -1, 2})
+	reconstruction := d.MatMul(d.MatMul(v, diagonal(d, w)), d.Transpose(v))
+	equal(t, "reconstruction", symmetric.Elements(), reconstruction.Elements())
+}
+
+func TestDecompositionsGrad(t *testing.T) {
+	d := cpu.New[float64](cpu.WithGrad(true))
+	a := tensor.New(tensor.Shape{3, 3, 2}, append([]float64(nil), matrices...))
+	tall := tensor.New(tensor.Shape{3, 4, 2}, append(append([]float64(nil), matrices...), 1, -1, 2, 5, 0, 3))
+	wide := tensor.New(tensor.Shape{4, 2}, []float64{1, -2, 0.5, 3, 2, 1, -1, 0.3})
+
+	cases := map[string]struct {
+		inputs  []*tensor.Tensor[float64]
+		forward func() []*tensor.Tensor[float64]
+	}{
+		"SVD": {[]*tensor.Tensor[float64]{tall}, func() []*tensor.Tensor[float64] {
+			u, s, v := linalg.SVD(d, tall)
+			return []*tensor.Tensor[float64]{u, s, v}
+		}},
+		"SVDWide": {[]*tensor.Tensor[float64]{wide}, func() []*tensor.Tensor[float64] {
+			u, s, v := linalg.SVD(d, wide)
+			return []*tensor.Tensor[float64]{u, s, v}
+		}},
+		"Eigh": {[]*tensor.Tensor[float64]{a}, func() []*tensor.Tensor[float64] {
+			// A + A^T is symmetric
+			w, v := linalg.Eigh(d, d.Add(a, d.Permute(a, 1, 0, 2)))
+			return []*tensor.Tensor[float64]{w, v}
+		}},
+	}
+	for name, c := range cases {
+		checkGrad(t, d, name, c.inputs, c.forward)
+	}
+}
@@ -0,0 +1,170 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/tensor"
+)
+
+// Sequences processed by attention layers have shape {dim, n, length}, as the
+// sequences of recurrent layers, and padding masks have shape {n, length}
+// with ones for the valid positions.
+
+// ScaledDotProductAttention returns softmax(q k^T / sqrt(dk)) v for every
+// element of the batch. q has shape {dk, lq, b}, k {dk, lk, b} and v
+// {dv, lk, b}, the result has shape {dv, lq, b}. mask, when not nil, has shape
+// {lk, lq, b} with ones where a query can attend to a key and zeros
+// elsewhere, queries that can't attend to any key get a zero output. The
+// attention matrix is not stored, see cpu.CPU.Attention. Panics if the shapes
+// are incompatible.
+func ScaledDotProductAttention[T constraints.Number](d cpu.CPU[T], q, k, v, mask *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return d.Attention(q, k, v, mask, false)
+}
+
+// MultiHeadAttention projects queries, keys and values into several heads,
+// applies scaled dot-product attention to every head independently and
+// projects the concatenation of the results.
+type MultiHeadAttention[T constraints.Number] struct {
+	Query  *Linear[T]
+	Key    *Linear[T]
+	Value  *Linear[T]
+	Output *Linear[T]
+
+	device cpu.CPU[T]
+	heads  uint
+}
+
+// NewMultiHeadAttention returns a new attention layer for inputs of size dim
+// split into the given number of heads, its projections are initialized like
+// Linear layers. Panics if dim is not divisible by heads.
+func NewMultiHeadAttention[T constraints.Number](d cpu.CPU[T], dim, heads uint, opts ...option) *MultiHeadAttention[T] {
+	if heads == 0 || dim%heads != 0 {
+		panic(fmt.Sprintf("dimension %d is not divisible by %d heads", dim, heads))
+	}
+	return &MultiHeadAttention[T]{
+		Query:  NewLinear(d, dim, dim, opts...),
+		Key:    NewLinear(d, dim, dim, opts...),
+		Value:  NewLinear(d, dim, dim, opts...),
+		Output: NewLinear(d, dim, dim, opts...),
+		device: d,
+		heads:  heads,
+	}
+}
+
+// Forward returns the attention of query, with shape {dim, n, lq}, over key
+// and value, with shape {dim, n, lk}. keyMask, with shape {n, lk}, excludes
+// the padding of the keys and can be nil. When causal is true every query only
+// attends to the keys at the same or previous positions.
+func (m *MultiHeadAttention[T]) Forward(query, key, value, keyMask *tensor.Tensor[T], causal bool) *tensor.Tensor[T] {
+	d := m.device
+	shape := query.Shape()
+	if len(shape) != 3 || len(key.Shape()) != 3 {
+		panic(fmt.Sprintf("sequences must have shape {dim, n, length} got %v and %v", shape, key.Shape()))
+	}
+	dim, n, lq, lk := shape[0], shape[1], shape[2], key.Shape()[2]
+
+	q := m.split(m.Query.Forward(query))
+	k := m.split(m.Key.Forward(key))
+	v := m.split(m.Value.Forward(value))
+	mask := attentionMask[T](keyMask, lk, m.heads, n)
+
+	// {head, lq, heads*n} to {dim, n, lq}
+	o := d.Reshape(d.Attention(q, k, v, mask, causal), tensor.Shape{dim / m.heads, lq, m.heads, n})
+	o = d.Reshape(d.Permute(o, 0, 2, 3, 1), tensor.Shape{dim, n, lq})
+	return m.Output.Forward(o)
+}
+
+// Parameters returns the parameters of the projections.
+func (m *MultiHeadAttention[T]) Parameters() map[string]*tensor.Tensor[T] {
+	return Collect(map[string]Module[T]{
+		"query":  m.Query,
+		"key":    m.Key,
+		"value":  m.Value,
+		"output": m.Output,
+	})
+}
+
+// split reshapes a sequence of shape {dim, n, length} into the heads of
+// every element of the batch, with shape {dim/heads, length, heads*n}.
+func (m *MultiHeadAttention[T]) split(x *tensor.Tensor[T]) *tensor.Tensor[T] {
+	d := m.device
+	shape := x.Shape()
+	head := shape[0] / m.heads
+	x = d.Reshape(x, tensor.Shape{head, m.heads, shape[1], shape[2]})
+	return d.Reshape(d.Permute(x, 0, 3, 1, 2), tensor.Shape{head, shape[2], m.heads * shape[1]})
+}
+
+// attentionMask returns the mask with shape {lk, 1, heads*n} shared by all
+// the queries of the heads of a batch, nil if there is no padding.
+func attentionMask[T constraints.Number](keyMask *tensor.Tensor[T], lk, heads, n uint) *tensor.Tensor[T] {
+	if keyMask == nil {
+		return nil
+	}
+	if !tensor.SameShape(keyMask.Shape(), tensor.Shape{n, lk}) {
+		panic(fmt.Sprintf("mask of shape %v must have shape %v", keyMask.Shape(), tensor.Shape{n, lk}))
+	}
+
+	mask := tensor.Zeros[T](tensor.Shape{lk, 1, heads * n})
+	elements := mask.Elements()
+	keyElements := keyMask.Elements()
+	for b := uint(0); b < heads*n; b++ {
+		for j := uint(0); j < lk; j++ {
+			elements[b*lk+j] = keyElements[j*n+b/heads]
+		}
+	}
+	return mask
+}
+
+// SinusoidalEncoding returns the sinusoidal position encodings of
+// "Attention Is All You Need" with shape {dim, length}. The even features of
+// position pos are sin(pos / 10000^(i/dim)) and the odd ones the cosine.
+func SinusoidalEncoding[T constraints.Number](dim, length uint) *tensor.Tensor[T] {
+	t := tensor.Zeros[T](tensor.Shape{dim, length})
+	elements := t.Elements()
+	for pos := uint(0); pos < length; pos++ {
+		for i := uint(0); i < dim; i++ {
+			angle := float64(pos) / math.Pow(10000, float64(i-i%2)/float64(dim))
+			if i%2 == 0 {
+				elements[pos*dim+i] = T(math.Sin(angle))
+			} else {
+				elements[pos*dim+i] = T(math.Cos(angle))
+			}
+		}
+	}
+	return t
+}
+
+// PositionalEncoding adds the sinusoidal encoding of their position to the
+// elements of sequences.
+type PositionalEncoding[T constraints.Number] struct {
+	device   cpu.CPU[T]
+	encoding *tensor.Tensor[T]
+}
+
+// NewPositionalEncoding returns a new positional encoding for sequences of
+// elements of size dim up to maxLength elements.
+func NewPositionalEncoding[T constraints.Number](d cpu.CPU[T], dim, maxLength uint) *PositionalEncoding[T] {
+	encoding := SinusoidalEncoding[T](dim, maxLength)
+	return &PositionalEncoding[T]{device: d, encoding: d.Reshape(encoding, tensor.Shape{dim, 1, maxLength})}
+}
+
+// Forward returns the sequences, with shape {dim, n, length}, with the
+// encoding of the positions added. Panics if the sequences are longer than
+// the maximum length.
+func (m *PositionalEncoding[T]) Forward(x *tensor.Tensor[T]) *tensor.Tensor[T] {
+	d := m.device
+	shape := x.Shape()
+	if len(shape) != 3 || shape[0] != m.encoding.Shape()[0] || shape[2] > m.encoding.Shape()[2] {
+		panic(fmt.Sprintf("invalid sequences of shape %v for encoding of shape %v", shape, m.encoding.Shape()))
+	}
+	encoding := d.Slice(m.encoding, tensor.All(), tensor.All(), tensor.Range{Start: 0, Stop: int(shape[2]), Step: 1})
+	return d.Add(x, d.Broadcast(encoding, shape))
+}
+
+// Parameters returns an empty map, the encoding is not learned.
+func (m *PositionalEncoding[T]) Parameters() map[string]*tensor.Tensor[T] {
+	return map[string]*tensor.Tensor[T]{}
+}
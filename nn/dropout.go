@@ -0,0 +1,105 @@
+package nn
+
+import (
+	"math/rand"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/random"
+	"github.com/blast-go/blast/tensor"
+)
+
+// Dropout randomly zeroes elements of the input during training and is the
+// identity during evaluation.
+type Dropout[T constraints.Number] struct {
+	dropout[T]
+}
+
+// NewDropout returns a new dropout layer in training mode that drops elements
+// with probability p using a generator initialized with seed.
+func NewDropout[T constraints.Number](d cpu.CPU[T], p float64, seed int64) *Dropout[T] {
+	return &Dropout[T]{newDropout(d, p, seed)}
+}
+
+// Forward returns the input with the dropped elements zeroed and the rest
+// scaled by 1/(1-p) in training mode, or the input in evaluation mode.
+func (m *Dropout[T]) Forward(x *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return m.device.Dropout(x, m.p, m.training, m.rng)
+}
+
+// Dropout2D randomly zeroes whole channels of the input during training and
+// is the identity during evaluation. The channels are the second to last
+// dimension of the input.
+type Dropout2D[T constraints.Number] struct {
+	dropout[T]
+}
+
+// NewDropout2D returns a new channel dropout layer in training mode that
+// drops channels with probability p using a generator initialized with seed.
+func NewDropout2D[T constraints.Number](d cpu.CPU[T], p float64, seed int64) *Dropout2D[T] {
+	return &Dropout2D[T]{newDropout(d, p, seed)}
+}
+
+// Forward returns the input with the dropped channels zeroed and the rest
+// scaled by 1/(1-p) in training mode, or the input in evaluation mode.
+func (m *Dropout2D[T]) Forward(x *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return m.device.Dropout2D(x, m.p, m.training, m.rng)
+}
+
+// AlphaDropout randomly drops elements of the input during training keeping
+// its mean and variance, it is meant to be used with SELU activations. It is
+// the identity during evaluation.
+type AlphaDropout[T constraints.Number] struct {
+	dropout[T]
+}
+
+// NewAlphaDropout returns a new alpha dropout layer in training mode that
+// drops elements with probability p using a generator initialized with seed.
+func NewAlphaDropout[T constraints.Number](d cpu.CPU[T], p float64, seed int64) *AlphaDropout[T] {
+	return &AlphaDropout[T]{newDropout(d, p, seed)}
+}
+
+// Forward returns the input with dropped elements in training mode, or the
+// input in evaluation mode.
+func (m *AlphaDropout[T]) Forward(x *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return m.device.AlphaDropout(x, m.p, m.training, m.rng)
+}
+
+// dropout holds what is common to all the dropout layers.
+type dropout[T constraints.Number] struct {
+	device   cpu.CPU[T]
+	p        float64
+	rng      *rand.Rand
+	source   *random.Source
+	training bool
+}
+
+func newDropout[T constraints.Number](d cpu.CPU[T], p float64, seed int64) dropout[T] {
+	rng, source := random.New(seed)
+	return newSharedDropout(d, p, rng, source)
+}
+
+// newSharedDropout returns a dropout that samples its masks from a generator
+// shared with other layers.
+func newSharedDropout[T constraints.Number](d cpu.CPU[T], p float64, rng *rand.Rand, source *random.Source) dropout[T] {
+	if p < 0 || p >= 1 {
+		panic("dropout probability must be in [0, 1)")
+	}
+	return dropout[T]{device: d, p: p, rng: rng, source: source, training: true}
+}
+
+// Train enables training mode when v is true and evaluation mode otherwise.
+func (m *dropout[T]) Train(v bool) {
+	m.training = v
+}
+
+// Parameters returns an empty map, dropout has no learnable parameters.
+func (m *dropout[T]) Parameters() map[string]*tensor.Tensor[T] {
+	return map[string]*tensor.Tensor[T]{}
+}
+
+// RNG returns the source of the random masks so its state can be saved and
+// restored.
+func (m *dropout[T]) RNG() *random.Source {
+	return m.source
+}
@@ -0,0 +1,36 @@
+package nn_test
+
+import (
+	"testing"
+
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/nn"
+	"github.com/blast-go/blast/tensor"
+)
+
+func TestDropout(t *testing.T) {
+	d := cpu.New[float32]()
+	x := tensor.Ones[float32](tensor.Shape{10, 10})
+
+	m1 := nn.NewDropout(d, 0.5, 7)
+	m2 := nn.NewDropout(d, 0.5, 7)
+	if !tensor.Equal(m1.Forward(x), m2.Forward(x)) {
+		t.Errorf("%s: dropout with the same seed should be equal", t.Name())
+	}
+
+	state := m1.RNG().State()
+	y1 := m1.Forward(x)
+	m1.RNG().SetState(state)
+	if !tensor.Equal(y1, m1.Forward(x)) {
+		t.Errorf("%s: dropout should be reproducible after restoring the generator", t.Name())
+	}
+
+	var _ nn.Trainable = m1
+	m1.Train(false)
+	if m1.Forward(x) != x {
+		t.Errorf("%s: dropout should be the identity in evaluation", t.Name())
+	}
+	if len(m1.Parameters()) != 0 {
+		t.Errorf("%s: dropout should have no parameters", t.Name())
+	}
+}
@@ -0,0 +1,55 @@
+package nn
+
+import (
+	"math/rand"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/tensor"
+)
+
+// Embedding is a lookup table that maps indices, usually tokens of a
+// vocabulary, to learnable vectors. Its gradient is sparse, only the rows of
+// the looked up indices are computed, and the optimizers only update those
+// rows.
+type Embedding[T constraints.Number] struct {
+	// Weight has shape {dim, vocab}, the vector of every index is contiguous.
+	Weight *tensor.Tensor[T]
+
+	device cpu.CPU[T]
+}
+
+// NewEmbedding returns a new embedding of vocab vectors of size dim
+// initialized from a standard normal distribution using the generator set
+// with WithRand.
+func NewEmbedding[T constraints.Number](d cpu.CPU[T], vocab, dim uint, opts ...option) *Embedding[T] {
+	o := newOptions(opts)
+	return &Embedding[T]{Weight: normal[T](o.rng, tensor.Shape{dim, vocab}), device: d}
+}
+
+// Forward returns the vectors of the indices, the result has shape {dim}
+// followed by the shape of indices. Panics if an index is out of range.
+func (m *Embedding[T]) Forward(indices *tensor.Tensor[int]) *tensor.Tensor[T] {
+	return m.device.Embedding(m.Weight, indices)
+}
+
+// Parameters returns the weight of the embedding.
+func (m *Embedding[T]) Parameters() map[string]*tensor.Tensor[T] {
+	return map[string]*tensor.Tensor[T]{"weight": m.Weight}
+}
+
+// normal returns a new tensor with elements drawn from a standard normal
+// distribution using r, or the global generator when r is nil.
+func normal[T constraints.Number](r *rand.Rand, shape tensor.Shape) *tensor.Tensor[T] {
+	norm := rand.NormFloat64
+	if r != nil {
+		norm = r.NormFloat64
+	}
+
+	t := tensor.Zeros[T](shape)
+	elements := t.Elements()
+	for i := range elements {
+		elements[i] = T(norm())
+	}
+	return t
+}
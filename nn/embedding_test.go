@@ -0,0 +1,50 @@
+package nn_test
+
+import (
+	"testing"
+
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/nn"
+	"github.com/blast-go/blast/optim"
+	"github.com/blast-go/blast/random"
+	"github.com/blast-go/blast/tensor"
+)
+
+func TestEmbedding(t *testing.T) {
+	d := cpu.New[float64](cpu.WithGrad(true))
+	m := nn.NewEmbedding(d, 5, 3)
+	before := append([]float64(nil), m.Weight.Elements()...)
+
+	o := optim.NewSGD(m.Parameters(), 0.1)
+	y := m.Forward(tensor.New(tensor.Shape{2, 1}, []int{1, 3}))
+	if len(y.Shape()) != 3 || y.Shape()[0] != 3 || y.Shape()[1] != 2 || y.Shape()[2] != 1 {
+		t.Fatalf("%s: invalid shape %v", t.Name(), y.Shape())
+	}
+	y.Backward()
+	o.Step()
+
+	// only the vectors of indices 1 and 3 change
+	for i, e := range m.Weight.Elements() {
+		row := i / 3
+		changed := e != before[i]
+		if changed != (row == 1 || row == 3) {
+			t.Errorf("%s: element %d of row %d changed=%t", t.Name(), i, row, changed)
+		}
+	}
+}
+
+func TestEmbeddingSeed(t *testing.T) {
+	d := cpu.New[float64]()
+	newEmbedding := func(seed int64) *tensor.Tensor[float64] {
+		r, _ := random.New(seed)
+		return nn.NewEmbedding(d, 5, 3, nn.WithRand(r)).Weight
+	}
+
+	w1, w2 := newEmbedding(3), newEmbedding(3)
+	if !tensor.Equal(w1, w2) {
+		t.Errorf("%s: weights should be equal with the same seed", t.Name())
+	}
+	if tensor.Equal(w1, newEmbedding(4)) {
+		t.Errorf("%s: weights should differ with another seed", t.Name())
+	}
+}
@@ -0,0 +1,67 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/tensor"
+)
+
+// Linear applies an affine transformation to the first dimension of the
+// input, y = x W + b.
+type Linear[T constraints.Number] struct {
+	// Weight has shape {out, in}.
+	Weight *tensor.Tensor[T]
+	// Bias has shape {out}, nil when the layer has no bias.
+	Bias *tensor.Tensor[T]
+
+	device cpu.CPU[T]
+}
+
+// NewLinear returns a new linear layer with its parameters initialized from
+// U(-1/sqrt(in), 1/sqrt(in)) using the generator set with WithRand. The bias
+// can be disabled with WithBias.
+func NewLinear[T constraints.Number](d cpu.CPU[T], in, out uint, opts ...option) *Linear[T] {
+	o := newOptions(opts)
+	bound := 1 / math.Sqrt(float64(in))
+	m := &Linear[T]{Weight: uniform[T](o.rng, tensor.Shape{out, in}, bound), device: d}
+	if o.bias {
+		m.Bias = uniform[T](o.rng, tensor.Shape{out}, bound)
+	}
+	return m
+}
+
+// Forward returns the transformed input. The input has shape {in, ...} and
+// the result {out, ...}, the rest of the dimensions are kept. Panics if the
+// first dimension of the input is not in.
+func (m *Linear[T]) Forward(x *tensor.Tensor[T]) *tensor.Tensor[T] {
+	d := m.device
+	shape := x.Shape()
+	in, out := m.Weight.Shape()[1], m.Weight.Shape()[0]
+	if shape[0] != in {
+		panic(fmt.Sprintf("input of shape %v must have %d features", shape, in))
+	}
+
+	flat := x
+	if len(shape) != 2 {
+		flat = d.Reshape(x, tensor.Shape{in, uint(tensor.Size(shape)) / in})
+	}
+	y := d.MatMul(flat, m.Weight)
+	if m.Bias != nil {
+		y = d.Add(y, d.Broadcast(m.Bias, y.Shape()))
+	}
+
+	if len(shape) == 2 {
+		return y
+	}
+	outShape := tensor.CopyShape(shape)
+	outShape[0] = out
+	return d.Reshape(y, outShape)
+}
+
+// Parameters returns the weight and the bias of the layer.
+func (m *Linear[T]) Parameters() map[string]*tensor.Tensor[T] {
+	return affineParameters(m.Weight, m.Bias)
+}
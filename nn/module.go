@@ -0,0 +1,143 @@
+// Package nn contains the building blocks used to define models.
+package nn
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/safetensors"
+	"github.com/blast-go/blast/tensor"
+)
+
+// Module is a part of a model that holds learnable parameters.
+type Module[T constraints.Number] interface {
+	// Parameters returns the learnable tensors of the module indexed by a
+	// unique name.
+	Parameters() map[string]*tensor.Tensor[T]
+}
+
+// Stateful is a module that also holds tensors that are part of its state
+// but are not learned, like the running statistics of a batch normalization.
+type Stateful[T constraints.Number] interface {
+	Module[T]
+	// Buffers returns the tensors of the state of the module that are not
+	// parameters indexed by a unique name.
+	Buffers() map[string]*tensor.Tensor[T]
+}
+
+// Trainable is implemented by modules that behave differently during training
+// and evaluation. Modules start in training mode.
+type Trainable interface {
+	// Train enables training mode when v is true and evaluation mode
+	// otherwise.
+	Train(v bool)
+}
+
+// Collect merges the parameters of several modules into a single map, the
+// name of each parameter is prefixed by the name of its module and a dot.
+func Collect[T constraints.Number](modules map[string]Module[T]) map[string]*tensor.Tensor[T] {
+	params := make(map[string]*tensor.Tensor[T])
+	for prefix, m := range modules {
+		for name, p := range m.Parameters() {
+			params[prefix+"."+name] = p
+		}
+	}
+	return params
+}
+
+// CollectBuffers merges the buffers of the Stateful modules among several
+// modules into a single map, names are prefixed as in Collect.
+func CollectBuffers[T constraints.Number](modules map[string]Module[T]) map[string]*tensor.Tensor[T] {
+	buffers := make(map[string]*tensor.Tensor[T])
+	for prefix, m := range modules {
+		if s, ok := m.(Stateful[T]); ok {
+			for name, b := range s.Buffers() {
+				buffers[prefix+"."+name] = b
+			}
+		}
+	}
+	return buffers
+}
+
+// StateDict returns the tensors that define the state of the module indexed
+// by name, the parameters and the buffers if the module is Stateful. The
+// tensors are shared with the module, not copies.
+func StateDict[T constraints.Number](m Module[T]) map[string]*tensor.Tensor[T] {
+	state := make(map[string]*tensor.Tensor[T])
+	for name, p := range m.Parameters() {
+		state[name] = p
+	}
+	if s, ok := m.(Stateful[T]); ok {
+		for name, b := range s.Buffers() {
+			state[name] = b
+		}
+	}
+	return state
+}
+
+// LoadStateDict copies the elements of the tensors in state into the
+// parameters and buffers of the module with the same name. An error is returned if a
+// tensor doesn't match the shape of its parameter. When strict is true it is
+// also an error for state to have missing or unexpected names.
+func LoadStateDict[T constraints.Number](m Module[T], state map[string]*tensor.Tensor[T], strict bool) error {
+	params := StateDict(m)
+
+	var missing, unexpected, mismatched []string
+	for name, p := range params {
+		s, ok := state[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		if !tensor.EqualShape(p, s) {
+			mismatched = append(mismatched, fmt.Sprintf("%s expected=%v got=%v", name, p.Shape(), s.Shape()))
+		}
+	}
+	for name := range state {
+		if _, ok := params[name]; !ok {
+			unexpected = append(unexpected, name)
+		}
+	}
+
+	var errs []string
+	if len(mismatched) > 0 {
+		sort.Strings(mismatched)
+		errs = append(errs, "incompatible shapes: "+strings.Join(mismatched, ", "))
+	}
+	if strict && len(missing) > 0 {
+		sort.Strings(missing)
+		errs = append(errs, "missing: "+strings.Join(missing, ", "))
+	}
+	if strict && len(unexpected) > 0 {
+		sort.Strings(unexpected)
+		errs = append(errs, "unexpected: "+strings.Join(unexpected, ", "))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("nn: can't load state: %s", strings.Join(errs, "; "))
+	}
+
+	for name, p := range params {
+		if s, ok := state[name]; ok {
+			copy(p.Elements(), s.Elements())
+		}
+	}
+	return nil
+}
+
+// Save writes the state of the module into the file located at path using
+// the safetensors format.
+func Save[T constraints.Number](path string, m Module[T]) error {
+	return safetensors.Save(path, StateDict(m), nil)
+}
+
+// Load reads the state of the module from a safetensors file located at path.
+// The file must contain exactly the parameters of the module.
+func Load[T constraints.Number](path string, m Module[T]) error {
+	state, _, err := safetensors.Load[T](path)
+	if err != nil {
+		return err
+	}
+	return LoadStateDict(m, state, true)
+}
@@ -0,0 +1,85 @@
+package nn_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/blast-go/blast/nn"
+	"github.com/blast-go/blast/tensor"
+)
+
+type model struct {
+	weight *tensor.Tensor[float32]
+	bias   *tensor.Tensor[float32]
+}
+
+func (m *model) Parameters() map[string]*tensor.Tensor[float32] {
+	return map[string]*tensor.Tensor[float32]{"weight": m.weight, "bias": m.bias}
+}
+
+func newModel() *model {
+	return &model{
+		weight: tensor.Rand[float32](tensor.Shape{3, 2}),
+		bias:   tensor.Rand[float32](tensor.Shape{2}),
+	}
+}
+
+func TestCollect(t *testing.T) {
+	m1 := newModel()
+	m2 := newModel()
+	params := nn.Collect(map[string]nn.Module[float32]{"l1": m1, "l2": m2})
+
+	if len(params) != 4 || params["l1.weight"] != m1.weight || params["l2.bias"] != m2.bias {
+		t.Errorf("%s: invalid parameters got=%v", t.Name(), params)
+	}
+}
+
+func TestLoadStateDict(t *testing.T) {
+	m1 := newModel()
+	m2 := newModel()
+
+	if err := nn.LoadStateDict[float32](m2, nn.StateDict[float32](m1), true); err != nil {
+		t.Fatalf("%s: load failed: %v", t.Name(), err)
+	}
+	if !tensor.Equal(m1.weight, m2.weight) || !tensor.Equal(m1.bias, m2.bias) {
+		t.Errorf("%s: state not loaded", t.Name())
+	}
+}
+
+func TestLoadStateDictInvalid(t *testing.T) {
+	m := newModel()
+
+	state := map[string]*tensor.Tensor[float32]{
+		"weight": tensor.Zeros[float32](tensor.Shape{2, 3}),
+		"bias":   tensor.Zeros[float32](tensor.Shape{2}),
+	}
+	if err := nn.LoadStateDict[float32](m, state, false); err == nil {
+		t.Errorf("%s: should fail due to incompatible shape", t.Name())
+	}
+
+	state = map[string]*tensor.Tensor[float32]{
+		"bias": tensor.Zeros[float32](tensor.Shape{2}),
+	}
+	if err := nn.LoadStateDict[float32](m, state, true); err == nil {
+		t.Errorf("%s: should fail due to missing parameter", t.Name())
+	}
+	if err := nn.LoadStateDict[float32](m, state, false); err != nil {
+		t.Errorf("%s: non strict load failed: %v", t.Name(), err)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.safetensors")
+	m1 := newModel()
+	m2 := newModel()
+
+	if err := nn.Save[float32](path, m1); err != nil {
+		t.Fatalf("%s: save failed: %v", t.Name(), err)
+	}
+	if err := nn.Load[float32](path, m2); err != nil {
+		t.Fatalf("%s: load failed: %v", t.Name(), err)
+	}
+	if !tensor.Equal(m1.weight, m2.weight) || !tensor.Equal(m1.bias, m2.bias) {
+		t.Errorf("%s: state not loaded", t.Name())
+	}
+}
@@ -0,0 +1,186 @@
+package nn
+
+import (
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/tensor"
+)
+
+// BatchNorm normalizes every channel with the statistics of the batch during
+// training and with running statistics during evaluation. The channels are
+// the second to last dimension of the input, as in cpu.CPU.BatchNorm.
+type BatchNorm[T constraints.Number] struct {
+	// Weight and Bias are nil when the layer is not affine.
+	Weight *tensor.Tensor[T]
+	Bias   *tensor.Tensor[T]
+	// RunningMean and RunningVar are nil when running statistics are not
+	// tracked.
+	RunningMean *tensor.Tensor[T]
+	RunningVar  *tensor.Tensor[T]
+
+	device   cpu.CPU[T]
+	momentum float64
+	eps      float64
+	training bool
+}
+
+// NewBatchNorm returns a new batch normalization layer for the given number
+// of channels in training mode. The weight starts at one, the bias and the
+// running mean at zero and the running variance at one.
+func NewBatchNorm[T constraints.Number](d cpu.CPU[T], channels uint, opts ...option) *BatchNorm[T] {
+	o := newOptions(opts)
+	m := &BatchNorm[T]{device: d, momentum: o.momentum, eps: o.eps, training: true}
+	if o.affine {
+		m.Weight = tensor.Ones[T](tensor.Shape{channels})
+		m.Bias = tensor.Zeros[T](tensor.Shape{channels})
+	}
+	if o.runningStats {
+		m.RunningMean = tensor.Zeros[T](tensor.Shape{channels})
+		m.RunningVar = tensor.Ones[T](tensor.Shape{channels})
+	}
+	return m
+}
+
+// Forward returns the normalized input. In training mode the running
+// statistics are updated when the result is computed.
+func (m *BatchNorm[T]) Forward(x *tensor.Tensor[T]) *tensor.Tensor[T] {
+	// without running statistics the batch statistics are used in both modes
+	training := m.training || m.RunningMean == nil
+	return m.device.BatchNorm(x, m.Weight, m.Bias, m.RunningMean, m.RunningVar, training, m.momentum, m.eps)
+}
+
+// Train enables training mode when v is true and evaluation mode otherwise.
+func (m *BatchNorm[T]) Train(v bool) {
+	m.training = v
+}
+
+// Parameters returns the weight and bias of the layer if it is affine.
+func (m *BatchNorm[T]) Parameters() map[string]*tensor.Tensor[T] {
+	return affineParameters(m.Weight, m.Bias)
+}
+
+// Buffers returns the running statistics of the layer if they are tracked.
+func (m *BatchNorm[T]) Buffers() map[string]*tensor.Tensor[T] {
+	buffers := make(map[string]*tensor.Tensor[T])
+	if m.RunningMean != nil {
+		buffers["running_mean"] = m.RunningMean
+		buffers["running_var"] = m.RunningVar
+	}
+	return buffers
+}
+
+// LayerNorm normalizes the first dimensions of the input, usually the
+// features of every sample.
+type LayerNorm[T constraints.Number] struct {
+	// Weight and Bias are nil when the layer is not affine.
+	Weight *tensor.Tensor[T]
+	Bias   *tensor.Tensor[T]
+
+	device cpu.CPU[T]
+	dims   int
+	eps    float64
+}
+
+// NewLayerNorm returns a new layer normalization over the first dimensions of
+// the input with the given shape. The weight starts at one and the bias at
+// zero.
+func NewLayerNorm[T constraints.Number](d cpu.CPU[T], shape tensor.Shape, opts ...option) *LayerNorm[T] {
+	o := newOptions(opts)
+	m := &LayerNorm[T]{device: d, dims: len(shape), eps: o.eps}
+	if o.affine {
+		m.Weight = tensor.Ones[T](tensor.CopyShape(shape))
+		m.Bias = tensor.Zeros[T](tensor.CopyShape(shape))
+	}
+	return m
+}
+
+// Forward returns the normalized input.
+func (m *LayerNorm[T]) Forward(x *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return m.device.LayerNorm(x, m.Weight, m.Bias, m.dims, m.eps)
+}
+
+// Parameters returns the weight and bias of the layer if it is affine.
+func (m *LayerNorm[T]) Parameters() map[string]*tensor.Tensor[T] {
+	return affineParameters(m.Weight, m.Bias)
+}
+
+// GroupNorm normalizes groups of channels of every sample. The channels are
+// the second to last dimension of the input.
+type GroupNorm[T constraints.Number] struct {
+	// Weight and Bias are nil when the layer is not affine.
+	Weight *tensor.Tensor[T]
+	Bias   *tensor.Tensor[T]
+
+	device cpu.CPU[T]
+	groups int
+	eps    float64
+}
+
+// NewGroupNorm returns a new group normalization that divides the channels
+// into groups. The weight starts at one and the bias at zero. Panics if the
+// number of channels is not divisible by groups.
+func NewGroupNorm[T constraints.Number](d cpu.CPU[T], groups int, channels uint, opts ...option) *GroupNorm[T] {
+	if groups <= 0 || int(channels)%groups != 0 {
+		panic("number of channels must be divisible by the number of groups")
+	}
+	o := newOptions(opts)
+	m := &GroupNorm[T]{device: d, groups: groups, eps: o.eps}
+	if o.affine {
+		m.Weight = tensor.Ones[T](tensor.Shape{channels})
+		m.Bias = tensor.Zeros[T](tensor.Shape{channels})
+	}
+	return m
+}
+
+// Forward returns the normalized input.
+func (m *GroupNorm[T]) Forward(x *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return m.device.GroupNorm(x, m.Weight, m.Bias, m.groups, m.eps)
+}
+
+// Parameters returns the weight and bias of the layer if it is affine.
+func (m *GroupNorm[T]) Parameters() map[string]*tensor.Tensor[T] {
+	return affineParameters(m.Weight, m.Bias)
+}
+
+// RMSNorm divides the first dimensions of the input by their root mean
+// square.
+type RMSNorm[T constraints.Number] struct {
+	// Weight is nil when the layer is not affine.
+	Weight *tensor.Tensor[T]
+
+	device cpu.CPU[T]
+	dims   int
+	eps    float64
+}
+
+// NewRMSNorm returns a new root mean square normalization over the first
+// dimensions of the input with the given shape. The weight starts at one.
+func NewRMSNorm[T constraints.Number](d cpu.CPU[T], shape tensor.Shape, opts ...option) *RMSNorm[T] {
+	o := newOptions(opts)
+	m := &RMSNorm[T]{device: d, dims: len(shape), eps: o.eps}
+	if o.affine {
+		m.Weight = tensor.Ones[T](tensor.CopyShape(shape))
+	}
+	return m
+}
+
+// Forward returns the normalized input.
+func (m *RMSNorm[T]) Forward(x *tensor.Tensor[T]) *tensor.Tensor[T] {
+	return m.device.RMSNorm(x, m.Weight, m.dims, m.eps)
+}
+
+// Parameters returns the weight of the layer if it is affine.
+func (m *RMSNorm[T]) Parameters() map[string]*tensor.Tensor[T] {
+	return affineParameters(m.Weight, nil)
+}
+
+func affineParameters[T constraints.Number](weight, bias *tensor.Tensor[T]) map[string]*tensor.Tensor[T] {
+	params := make(map[string]*tensor.Tensor[T])
+	if weight != nil {
+		params["weight"] = weight
+	}
+	if bias != nil {
+		params["bias"] = bias
+	}
+	return params
+}
@@ -0,0 +1,87 @@
+package nn_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/nn"
+	"github.com/blast-go/blast/tensor"
+)
+
+func TestBatchNorm(t *testing.T) {
+	d := cpu.New[float64](cpu.WithGrad(true))
+	m := nn.NewBatchNorm(d, 2, nn.WithEps(0), nn.WithMomentum(1))
+	x := tensor.New(tensor.Shape{2, 2}, []float64{1, 2, 3, 6})
+
+	y := m.Forward(x)
+	expected := []float64{-1, -1, 1, 1}
+	for i, e := range y.Elements() {
+		if math.Abs(e-expected[i]) > 1e-9 {
+			t.Errorf("%s: training forward failed expected=%v got=%v", t.Name(), expected, y)
+			break
+		}
+	}
+
+	m.Train(false)
+	y = m.Forward(x)
+	// running statistics replaced by the unbiased batch statistics
+	expected = []float64{-1 / math.Sqrt(2), -2 / math.Sqrt(8), 1 / math.Sqrt(2), 2 / math.Sqrt(8)}
+	for i, e := range y.Elements() {
+		if math.Abs(e-expected[i]) > 1e-9 {
+			t.Errorf("%s: evaluation forward failed expected=%v got=%v", t.Name(), expected, y)
+			break
+		}
+	}
+}
+
+func TestBatchNormStateDict(t *testing.T) {
+	d := cpu.New[float32]()
+	m1 := nn.NewBatchNorm(d, 3)
+	m1.Forward(tensor.Rand[float32](tensor.Shape{3, 4})).Elements()
+
+	state := nn.StateDict[float32](m1)
+	for _, name := range []string{"weight", "bias", "running_mean", "running_var"} {
+		if _, ok := state[name]; !ok {
+			t.Errorf("%s: missing %s in state", t.Name(), name)
+		}
+	}
+	if len(m1.Parameters()) != 2 {
+		t.Errorf("%s: running statistics should not be parameters got=%v", t.Name(), m1.Parameters())
+	}
+
+	m2 := nn.NewBatchNorm(d, 3)
+	if err := nn.LoadStateDict[float32](m2, state, true); err != nil {
+		t.Fatalf("%s: load failed: %v", t.Name(), err)
+	}
+	if !tensor.Equal(m1.RunningMean, m2.RunningMean) || !tensor.Equal(m1.RunningVar, m2.RunningVar) {
+		t.Errorf("%s: running statistics not loaded", t.Name())
+	}
+}
+
+func TestNormWithoutAffine(t *testing.T) {
+	d := cpu.New[float32]()
+	modules := map[string]nn.Module[float32]{
+		"batch": nn.NewBatchNorm(d, 4, nn.WithAffine(false), nn.WithRunningStats(false)),
+		"layer": nn.NewLayerNorm(d, tensor.Shape{4}, nn.WithAffine(false)),
+		"group": nn.NewGroupNorm(d, 2, 4, nn.WithAffine(false)),
+		"rms":   nn.NewRMSNorm(d, tensor.Shape{4}, nn.WithAffine(false)),
+	}
+	if params := nn.Collect(modules); len(params) != 0 {
+		t.Errorf("%s: expected no parameters got=%v", t.Name(), params)
+	}
+	if buffers := nn.CollectBuffers(modules); len(buffers) != 0 {
+		t.Errorf("%s: expected no buffers got=%v", t.Name(), buffers)
+	}
+}
+
+func TestLayerNorm(t *testing.T) {
+	d := cpu.New[float64]()
+	m := nn.NewLayerNorm(d, tensor.Shape{2}, nn.WithEps(0))
+	y := m.Forward(tensor.New(tensor.Shape{2, 2}, []float64{1, 3, 2, 6}))
+
+	expected := tensor.New(tensor.Shape{2, 2}, []float64{-1, 1, -1, 1})
+	if !tensor.Equal(y, expected) {
+		t.Errorf("%s: forward failed expected=%v got=%v", t.Name(), expected, y)
+	}
+}
@@ -0,0 +1,134 @@
+package nn
+
+import "math/rand"
+
+type option func(*options)
+
+type options struct {
+	eps           float64
+	momentum      float64
+	affine        bool
+	runningStats  bool
+	layers        int
+	bidirectional bool
+	relu          bool
+	bias          bool
+	dropout       float64
+	seed          int64
+	normFirst     bool
+	gelu          bool
+	rng           *rand.Rand
+}
+
+// WithEps sets the value added to the variance to avoid divisions by zero in
+// normalization layers, 1e-5 by default.
+func WithEps(eps float64) option {
+	return func(o *options) {
+		o.eps = eps
+	}
+}
+
+// WithMomentum sets the weight of the batch statistics when updating the
+// running statistics of a batch normalization, 0.1 by default.
+func WithMomentum(momentum float64) option {
+	return func(o *options) {
+		o.momentum = momentum
+	}
+}
+
+// WithAffine enables the learnable scale and shift of normalization layers,
+// enabled by default.
+func WithAffine(v bool) option {
+	return func(o *options) {
+		o.affine = v
+	}
+}
+
+// WithRunningStats enables tracking the running statistics of a batch
+// normalization, which are used in evaluation mode. When disabled the
+// statistics of the batch are always used. Enabled by default.
+func WithRunningStats(v bool) option {
+	return func(o *options) {
+		o.runningStats = v
+	}
+}
+
+// WithLayers sets the number of stacked layers of recurrent modules, the
+// output of every layer is the input of the next one. 1 by default.
+func WithLayers(n int) option {
+	return func(o *options) {
+		o.layers = n
+	}
+}
+
+// WithBidirectional makes recurrent modules process sequences in both
+// directions and concatenate the outputs of both. Disabled by default.
+func WithBidirectional(v bool) option {
+	return func(o *options) {
+		o.bidirectional = v
+	}
+}
+
+// WithReLU makes RNN cells use ReLU instead of tanh as nonlinearity.
+func WithReLU(v bool) option {
+	return func(o *options) {
+		o.relu = v
+	}
+}
+
+// WithBias enables the learnable bias of linear layers, enabled by default.
+func WithBias(v bool) option {
+	return func(o *options) {
+		o.bias = v
+	}
+}
+
+// WithDropout sets the probability of the dropout layers inside transformer
+// layers and the seed of their generator. Disabled by default.
+func WithDropout(p float64, seed int64) option {
+	return func(o *options) {
+		o.dropout = p
+		o.seed = seed
+	}
+}
+
+// WithNormFirst makes transformer layers normalize the input of every block
+// instead of its output after the residual connection. Disabled by default.
+func WithNormFirst(v bool) option {
+	return func(o *options) {
+		o.normFirst = v
+	}
+}
+
+// WithGELU makes the feed forward network of transformer layers use GELU
+// instead of ReLU as activation.
+func WithGELU(v bool) option {
+	return func(o *options) {
+		o.gelu = v
+	}
+}
+
+// WithRand sets the generator that initializes the parameters of layers, the
+// global generator of math/rand by default. Layers made of other layers share
+// it with them, so a generator of the random package makes the initialization
+// reproducible.
+func WithRand(r *rand.Rand) option {
+	return func(o *options) {
+		o.rng = r
+	}
+}
+
+func newOptions(opts []option) options {
+	o := options{
+		eps:          1e-5,
+		momentum:     0.1,
+		affine:       true,
+		runningStats: true,
+		layers:       1,
+		bias:         true,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
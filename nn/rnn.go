@@ -0,0 +1,420 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/tensor"
+)
+
+// Inputs of the recurrent cells have shape {input, n} and states have shape
+// {hidden, n}, where n is the size of the batch. Sequences have shape
+// {input, n, length} and masks, when given, have shape {n, length} with ones
+// for the valid steps of every sequence and zeros for the padding at the end
+// of the shorter ones.
+
+// RNNCell is an Elman recurrent cell, h' = tanh(x Wih + bih + h Whh + bhh).
+type RNNCell[T constraints.Number] struct {
+	// WeightIH has shape {hidden, input} and WeightHH {hidden, hidden}.
+	WeightIH *tensor.Tensor[T]
+	WeightHH *tensor.Tensor[T]
+	BiasIH   *tensor.Tensor[T]
+	BiasHH   *tensor.Tensor[T]
+
+	device cpu.CPU[T]
+	relu   bool
+}
+
+// NewRNNCell returns a new RNN cell with its parameters initialized from
+// U(-1/sqrt(hidden), 1/sqrt(hidden)) using the generator set with WithRand.
+// WithReLU replaces tanh with ReLU.
+func NewRNNCell[T constraints.Number](d cpu.CPU[T], inputSize, hiddenSize uint, opts ...option) *RNNCell[T] {
+	o := newOptions(opts)
+	w := newCellWeights[T](o.rng, inputSize, hiddenSize, 1)
+	return &RNNCell[T]{WeightIH: w[0], WeightHH: w[1], BiasIH: w[2], BiasHH: w[3], device: d, relu: o.relu}
+}
+
+// Forward returns the next hidden state.
+func (m *RNNCell[T]) Forward(x, h *tensor.Tensor[T]) *tensor.Tensor[T] {
+	d := m.device
+	a := d.Add(linear(d, x, m.WeightIH, m.BiasIH), linear(d, h, m.WeightHH, m.BiasHH))
+	if m.relu {
+		return d.ReLU(a)
+	}
+	return d.Tanh(a)
+}
+
+// Parameters returns the weights and biases of the cell.
+func (m *RNNCell[T]) Parameters() map[string]*tensor.Tensor[T] {
+	return cellParameters(m.WeightIH, m.WeightHH, m.BiasIH, m.BiasHH)
+}
+
+func (m *RNNCell[T]) step(x *tensor.Tensor[T], state []*tensor.Tensor[T]) []*tensor.Tensor[T] {
+	return []*tensor.Tensor[T]{m.Forward(x, state[0])}
+}
+
+func (m *RNNCell[T]) stateSize() int {
+	return 1
+}
+
+func (m *RNNCell[T]) hiddenSize() uint {
+	return m.WeightHH.Shape()[1]
+}
+
+// LSTMCell is a long short-term memory cell. The input, forget, cell and
+// output gates are stacked in this order in the weights.
+type LSTMCell[T constraints.Number] struct {
+	// WeightIH has shape {4*hidden, input} and WeightHH {4*hidden, hidden}.
+	WeightIH *tensor.Tensor[T]
+	WeightHH *tensor.Tensor[T]
+	BiasIH   *tensor.Tensor[T]
+	BiasHH   *tensor.Tensor[T]
+
+	device cpu.CPU[T]
+}
+
+// NewLSTMCell returns a new LSTM cell with its parameters initialized from
+// U(-1/sqrt(hidden), 1/sqrt(hidden)) using the generator set with WithRand.
+func NewLSTMCell[T constraints.Number](d cpu.CPU[T], inputSize, hiddenSize uint, opts ...option) *LSTMCell[T] {
+	o := newOptions(opts)
+	w := newCellWeights[T](o.rng, inputSize, hiddenSize, 4)
+	return &LSTMCell[T]{WeightIH: w[0], WeightHH: w[1], BiasIH: w[2], BiasHH: w[3], device: d}
+}
+
+// Forward returns the next hidden and cell states.
+func (m *LSTMCell[T]) Forward(x, h, c *tensor.Tensor[T]) (*tensor.Tensor[T], *tensor.Tensor[T]) {
+	d := m.device
+	gates := d.Chunk(d.Add(linear(d, x, m.WeightIH, m.BiasIH), linear(d, h, m.WeightHH, m.BiasHH)), 0, 4)
+	i := d.Sigmoid(gates[0])
+	f := d.Sigmoid(gates[1])
+	g := d.Tanh(gates[2])
+	o := d.Sigmoid(gates[3])
+
+	c = d.Add(d.Hadamard(f, c), d.Hadamard(i, g))
+	return d.Hadamard(o, d.Tanh(c)), c
+}
+
+// Parameters returns the weights and biases of the cell.
+func (m *LSTMCell[T]) Parameters() map[string]*tensor.Tensor[T] {
+	return cellParameters(m.WeightIH, m.WeightHH, m.BiasIH, m.BiasHH)
+}
+
+func (m *LSTMCell[T]) step(x *tensor.Tensor[T], state []*tensor.Tensor[T]) []*tensor.Tensor[T] {
+	h, c := m.Forward(x, state[0], state[1])
+	return []*tensor.Tensor[T]{h, c}
+}
+
+func (m *LSTMCell[T]) stateSize() int {
+	return 2
+}
+
+func (m *LSTMCell[T]) hiddenSize() uint {
+	return m.WeightHH.Shape()[1]
+}
+
+// GRUCell is a gated recurrent unit cell. The reset, update and new gates
+// are stacked in this order in the weights.
+type GRUCell[T constraints.Number] struct {
+	// WeightIH has shape {3*hidden, input} and WeightHH {3*hidden, hidden}.
+	WeightIH *tensor.Tensor[T]
+	WeightHH *tensor.Tensor[T]
+	BiasIH   *tensor.Tensor[T]
+	BiasHH   *tensor.Tensor[T]
+
+	device cpu.CPU[T]
+}
+
+// NewGRUCell returns a new GRU cell with its parameters initialized from
+// U(-1/sqrt(hidden), 1/sqrt(hidden)) using the generator set with WithRand.
+func NewGRUCell[T constraints.Number](d cpu.CPU[T], inputSize, hiddenSize uint, opts ...option) *GRUCell[T] {
+	o := newOptions(opts)
+	w := newCellWeights[T](o.rng, inputSize, hiddenSize, 3)
+	return &GRUCell[T]{WeightIH: w[0], WeightHH: w[1], BiasIH: w[2], BiasHH: w[3], device: d}
+}
+
+// Forward returns the next hidden state.
+func (m *GRUCell[T]) Forward(x, h *tensor.Tensor[T]) *tensor.Tensor[T] {
+	d := m.device
+	gi := d.Chunk(linear(d, x, m.WeightIH, m.BiasIH), 0, 3)
+	gh := d.Chunk(linear(d, h, m.WeightHH, m.BiasHH), 0, 3)
+	r := d.Sigmoid(d.Add(gi[0], gh[0]))
+	z := d.Sigmoid(d.Add(gi[1], gh[1]))
+	n := d.Tanh(d.Add(gi[2], d.Hadamard(r, gh[2])))
+
+	// (1-z)*n + z*h
+	return d.Add(n, d.Hadamard(z, d.Sub(h, n)))
+}
+
+// Parameters returns the weights and biases of the cell.
+func (m *GRUCell[T]) Parameters() map[string]*tensor.Tensor[T] {
+	return cellParameters(m.WeightIH, m.WeightHH, m.BiasIH, m.BiasHH)
+}
+
+func (m *GRUCell[T]) step(x *tensor.Tensor[T], state []*tensor.Tensor[T]) []*tensor.Tensor[T] {
+	return []*tensor.Tensor[T]{m.Forward(x, state[0])}
+}
+
+func (m *GRUCell[T]) stateSize() int {
+	return 1
+}
+
+func (m *GRUCell[T]) hiddenSize() uint {
+	return m.WeightHH.Shape()[1]
+}
+
+// RNN applies a stack of RNN cells to sequences.
+type RNN[T constraints.Number] struct {
+	recurrent[T]
+}
+
+// NewRNN returns a new RNN. The number of layers, the direction and the
+// nonlinearity are set with WithLayers, WithBidirectional and WithReLU.
+func NewRNN[T constraints.Number](d cpu.CPU[T], inputSize, hiddenSize uint, opts ...option) *RNN[T] {
+	return &RNN[T]{newRecurrent(d, inputSize, hiddenSize, opts, func(in uint) cell[T] {
+		return NewRNNCell(d, in, hiddenSize, opts...)
+	})}
+}
+
+// Forward returns the output of the last layer for every step, with shape
+// {hidden*directions, n, length}, and the last hidden state of every layer
+// and direction, with shape {hidden, n, layers*directions}. mask can be nil
+// when all sequences have the same length.
+func (m *RNN[T]) Forward(x, mask *tensor.Tensor[T]) (*tensor.Tensor[T], *tensor.Tensor[T]) {
+	output, states := m.forward(x, mask)
+	return output, states[0]
+}
+
+// LSTM applies a stack of LSTM cells to sequences.
+type LSTM[T constraints.Number] struct {
+	recurrent[T]
+}
+
+// NewLSTM returns a new LSTM. The number of layers and the direction are set
+// with WithLayers and WithBidirectional.
+func NewLSTM[T constraints.Number](d cpu.CPU[T], inputSize, hiddenSize uint, opts ...option) *LSTM[T] {
+	return &LSTM[T]{newRecurrent(d, inputSize, hiddenSize, opts, func(in uint) cell[T] {
+		return NewLSTMCell(d, in, hiddenSize, opts...)
+	})}
+}
+
+// Forward returns the output of the last layer for every step, with shape
+// {hidden*directions, n, length}, and the last hidden and cell states of every
+// layer and direction, with shape {hidden, n, layers*directions}. mask can be
+// nil when all sequences have the same length.
+func (m *LSTM[T]) Forward(x, mask *tensor.Tensor[T]) (*tensor.Tensor[T], *tensor.Tensor[T], *tensor.Tensor[T]) {
+	output, states := m.forward(x, mask)
+	return output, states[0], states[1]
+}
+
+// GRU applies a stack of GRU cells to sequences.
+type GRU[T constraints.Number] struct {
+	recurrent[T]
+}
+
+// NewGRU returns a new GRU. The number of layers and the direction are set
+// with WithLayers and WithBidirectional.
+func NewGRU[T constraints.Number](d cpu.CPU[T], inputSize, hiddenSize uint, opts ...option) *GRU[T] {
+	return &GRU[T]{newRecurrent(d, inputSize, hiddenSize, opts, func(in uint) cell[T] {
+		return NewGRUCell(d, in, hiddenSize, opts...)
+	})}
+}
+
+// Forward returns the output of the last layer for every step, with shape
+// {hidden*directions, n, length}, and the last hidden state of every layer
+// and direction, with shape {hidden, n, layers*directions}. mask can be nil
+// when all sequences have the same length.
+func (m *GRU[T]) Forward(x, mask *tensor.Tensor[T]) (*tensor.Tensor[T], *tensor.Tensor[T]) {
+	output, states := m.forward(x, mask)
+	return output, states[0]
+}
+
+// cell computes the next state of a recurrent module from an input and the
+// current state, the first tensor of the state is the hidden state.
+type cell[T constraints.Number] interface {
+	Module[T]
+	step(x *tensor.Tensor[T], state []*tensor.Tensor[T]) []*tensor.Tensor[T]
+	stateSize() int
+	hiddenSize() uint
+}
+
+// recurrent holds the cells of the layers and directions of a recurrent
+// module and applies them to sequences.
+type recurrent[T constraints.Number] struct {
+	device cpu.CPU[T]
+	// cells are indexed by layer and direction.
+	cells [][]cell[T]
+}
+
+func newRecurrent[T constraints.Number](d cpu.CPU[T], inputSize, hiddenSize uint, opts []option, newCell func(uint) cell[T]) recurrent[T] {
+	o := newOptions(opts)
+	if o.layers <= 0 {
+		panic(fmt.Sprintf("invalid number of layers %d", o.layers))
+	}
+	directions := 1
+	if o.bidirectional {
+		directions = 2
+	}
+
+	r := recurrent[T]{device: d, cells: make([][]cell[T], o.layers)}
+	in := inputSize
+	for l := range r.cells {
+		for dir := 0; dir < directions; dir++ {
+			r.cells[l] = append(r.cells[l], newCell(in))
+		}
+		in = hiddenSize * uint(directions)
+	}
+	return r
+}
+
+// Parameters returns the parameters of all the cells, the name of every
+// parameter is suffixed by the layer and by "_reverse" for the cells that
+// process sequences backwards, for example weight_ih_l1_reverse.
+func (m *recurrent[T]) Parameters() map[string]*tensor.Tensor[T] {
+	params := make(map[string]*tensor.Tensor[T])
+	for l, cells := range m.cells {
+		for dir, c := range cells {
+			suffix := fmt.Sprintf("_l%d", l)
+			if dir == 1 {
+				suffix += "_reverse"
+			}
+			for name, p := range c.Parameters() {
+				params[name+suffix] = p
+			}
+		}
+	}
+	return params
+}
+
+// forward returns the output of the last layer and the last states, every
+// tensor of the state stacked for all layers and directions.
+func (m *recurrent[T]) forward(x, mask *tensor.Tensor[T]) (*tensor.Tensor[T], []*tensor.Tensor[T]) {
+	d := m.device
+	shape := x.Shape()
+	if len(shape) != 3 {
+		panic(fmt.Sprintf("sequences must have shape {input, n, length} got %v", shape))
+	}
+	n, length := shape[1], int(shape[2])
+	hidden := m.cells[0][0].hiddenSize()
+	stateShape := tensor.Shape{hidden, n}
+
+	// a condition with the shape of the state for every step
+	var valid []*tensor.Tensor[T]
+	if mask != nil {
+		if !tensor.SameShape(mask.Shape(), tensor.Shape{n, uint(length)}) {
+			panic(fmt.Sprintf("mask of shape %v must have shape %v", mask.Shape(), tensor.Shape{n, uint(length)}))
+		}
+		for t := 0; t < length; t++ {
+			elements := make([]T, hidden*n)
+			for b := 0; b < int(n); b++ {
+				if mask.Elements()[t*int(n)+b] != 0 {
+					for h := 0; h < int(hidden); h++ {
+						elements[b*int(hidden)+h] = 1
+					}
+				}
+			}
+			valid = append(valid, tensor.New(tensor.CopyShape(stateShape), elements))
+		}
+	}
+	zeros := tensor.Zeros[T](tensor.CopyShape(stateShape))
+
+	inputs := make([]*tensor.Tensor[T], length)
+	for t, s := range d.Chunk(x, 2, length) {
+		inputs[t] = d.Reshape(s, tensor.Shape{shape[0], n})
+	}
+
+	stateSize := m.cells[0][0].stateSize()
+	last := make([][]*tensor.Tensor[T], stateSize)
+	for _, cells := range m.cells {
+		outputs := make([][]*tensor.Tensor[T], len(cells))
+		for dir, c := range cells {
+			state := make([]*tensor.Tensor[T], stateSize)
+			for k := range state {
+				state[k] = zeros
+			}
+
+			outputs[dir] = make([]*tensor.Tensor[T], length)
+			for i := 0; i < length; i++ {
+				t := i
+				if dir == 1 {
+					t = length - 1 - i
+				}
+
+				next := c.step(inputs[t], state)
+				if valid == nil {
+					outputs[dir][t] = next[0]
+				} else {
+					// padded steps keep the state and output zeros, so the
+					// reverse direction starts at the end of every sequence
+					for k := range next {
+						next[k] = d.Where(valid[t], next[k], state[k])
+					}
+					outputs[dir][t] = d.Where(valid[t], next[0], zeros)
+				}
+				state = next
+			}
+			for k := range state {
+				last[k] = append(last[k], state[k])
+			}
+		}
+
+		for t := range inputs {
+			if len(cells) == 1 {
+				inputs[t] = outputs[0][t]
+			} else {
+				inputs[t] = d.Concat(0, outputs[0][t], outputs[1][t])
+			}
+		}
+	}
+
+	states := make([]*tensor.Tensor[T], stateSize)
+	for k := range states {
+		states[k] = d.Stack(2, last[k]...)
+	}
+	return d.Stack(2, inputs...), states
+}
+
+// linear returns x W + b broadcast over the batch.
+func linear[T constraints.Number](d cpu.CPU[T], x, w, b *tensor.Tensor[T]) *tensor.Tensor[T] {
+	y := d.MatMul(x, w)
+	return d.Add(y, d.Broadcast(b, y.Shape()))
+}
+
+// newCellWeights returns the input and hidden weights and biases of a cell
+// with the given number of gates.
+func newCellWeights[T constraints.Number](r *rand.Rand, inputSize, hiddenSize, gates uint) []*tensor.Tensor[T] {
+	bound := 1 / math.Sqrt(float64(hiddenSize))
+	return []*tensor.Tensor[T]{
+		uniform[T](r, tensor.Shape{gates * hiddenSize, inputSize}, bound),
+		uniform[T](r, tensor.Shape{gates * hiddenSize, hiddenSize}, bound),
+		uniform[T](r, tensor.Shape{gates * hiddenSize}, bound),
+		uniform[T](r, tensor.Shape{gates * hiddenSize}, bound),
+	}
+}
+
+func cellParameters[T constraints.Number](weightIH, weightHH, biasIH, biasHH *tensor.Tensor[T]) map[string]*tensor.Tensor[T] {
+	return map[string]*tensor.Tensor[T]{
+		"weight_ih": weightIH,
+		"weight_hh": weightHH,
+		"bias_ih":   biasIH,
+		"bias_hh":   biasHH,
+	}
+}
+
+// uniform returns a new tensor with elements drawn from U(-bound, bound)
+// using r, or the global generator when r is nil.
+func uniform[T constraints.Number](r *rand.Rand, shape tensor.Shape, bound float64) *tensor.Tensor[T] {
+	float := rand.Float64
+	if r != nil {
+		float = r.Float64
+	}
+
+	t := tensor.Zeros[T](shape)
+	elements := t.Elements()
+	for i := range elements {
+		elements[i] = T((2*float() - 1) * bound)
+	}
+	return t
+}
@@ -0,0 +1,153 @@
+package nn_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/nn"
+	"github.com/blast-go/blast/random"
+	"github.com/blast-go/blast/tensor"
+)
+
+func sum(t *tensor.Tensor[float64]) float64 {
+	s := 0.0
+	for _, e := range t.Elements() {
+		s += e
+	}
+	return s
+}
+
+func TestRecurrentShapes(t *testing.T) {
+	d := cpu.New[float64]()
+	x := tensor.Rand[float64](tensor.Shape{3, 2, 5})
+
+	output, h := nn.NewRNN(d, 3, 4, nn.WithLayers(2), nn.WithBidirectional(true)).Forward(x, nil)
+	if !tensor.EqualShape(output, tensor.Zeros[float64](tensor.Shape{8, 2, 5})) {
+		t.Errorf("%s: invalid output shape %v", t.Name(), output.Shape())
+	}
+	if !tensor.EqualShape(h, tensor.Zeros[float64](tensor.Shape{4, 2, 4})) {
+		t.Errorf("%s: invalid hidden shape %v", t.Name(), h.Shape())
+	}
+
+	_, h, c := nn.NewLSTM(d, 3, 4).Forward(x, nil)
+	if !tensor.EqualShape(h, c) || !tensor.EqualShape(c, tensor.Zeros[float64](tensor.Shape{4, 2, 1})) {
+		t.Errorf("%s: invalid state shapes %v %v", t.Name(), h.Shape(), c.Shape())
+	}
+
+	params := nn.NewGRU(d, 3, 4, nn.WithLayers(2), nn.WithBidirectional(true)).Parameters()
+	if len(params) != 16 || !tensor.EqualShape(params["weight_ih_l1_reverse"], tensor.Zeros[float64](tensor.Shape{12, 8})) {
+		t.Errorf("%s: invalid parameters %d", t.Name(), len(params))
+	}
+}
+
+func TestRecurrentGrad(t *testing.T) {
+	d := cpu.New[float64](cpu.WithGrad(true))
+	x := tensor.Rand[float64](tensor.Shape{2, 2, 3})
+	// the second sequence has only 2 steps
+	mask := tensor.New(tensor.Shape{2, 3}, []float64{1, 1, 1, 1, 1, 0})
+
+	type module struct {
+		params  map[string]*tensor.Tensor[float64]
+		forward func() *tensor.Tensor[float64]
+	}
+	modules := map[string]module{}
+	rnn := nn.NewRNN(d, 2, 3, nn.WithLayers(2), nn.WithBidirectional(true))
+	modules["RNN"] = module{rnn.Parameters(), func() *tensor.Tensor[float64] {
+		output, _ := rnn.Forward(x, mask)
+		return output
+	}}
+	lstm := nn.NewLSTM(d, 2, 3, nn.WithLayers(2), nn.WithBidirectional(true))
+	modules["LSTM"] = module{lstm.Parameters(), func() *tensor.Tensor[float64] {
+		output, _, _ := lstm.Forward(x, mask)
+		return output
+	}}
+	gru := nn.NewGRU(d, 2, 3, nn.WithLayers(2), nn.WithBidirectional(true))
+	modules["GRU"] = module{gru.Parameters(), func() *tensor.Tensor[float64] {
+		output, _ := gru.Forward(x, mask)
+		return output
+	}}
+
+	for name, m := range modules {
+		x.ZeroGrad()
+		loss := func() *tensor.Tensor[float64] { return d.PowInt(m.forward(), 2) }
+		loss().Backward()
+
+		inputs := map[string]*tensor.Tensor[float64]{"x": x}
+		for n, p := range m.params {
+			inputs[n] = p
+		}
+		h := 1e-6
+		for input, p := range inputs {
+			elements := p.Elements()
+			for i, e := range elements {
+				elements[i] = e + h
+				plus := sum(loss())
+				elements[i] = e - h
+				minus := sum(loss())
+				elements[i] = e
+
+				numeric := (plus - minus) / (2 * h)
+				if math.Abs(p.Grad()[i]-numeric) > 1e-5*math.Max(1, math.Abs(numeric)) {
+					t.Errorf("%s: %s gradient of %s failed at %d expected=%f got=%f", t.Name(), name, input, i, numeric, p.Grad()[i])
+				}
+			}
+		}
+	}
+}
+
+func TestRecurrentMask(t *testing.T) {
+	d := cpu.New[float64]()
+	gru := nn.NewGRU(d, 2, 3, nn.WithBidirectional(true))
+
+	// batch with a sequence of 3 steps and one of 2 steps padded to 3
+	x := tensor.Rand[float64](tensor.Shape{2, 2, 3})
+	mask := tensor.New(tensor.Shape{2, 3}, []float64{1, 1, 1, 1, 1, 0})
+	output, h := gru.Forward(x, mask)
+
+	// the second sequence alone
+	short := tensor.New(tensor.Shape{2, 1, 2}, []float64{
+		x.Get(0, 1, 0), x.Get(1, 1, 0),
+		x.Get(0, 1, 1), x.Get(1, 1, 1),
+	})
+	shortOutput, shortH := gru.Forward(short, nil)
+
+	for k := uint(0); k < 6; k++ {
+		for step := uint(0); step < 2; step++ {
+			if math.Abs(output.Get(k, 1, step)-shortOutput.Get(k, 0, step)) > 1e-12 {
+				t.Errorf("%s: output of step %d differs expected=%f got=%f", t.Name(), step, shortOutput.Get(k, 0, step), output.Get(k, 1, step))
+			}
+		}
+		if output.Get(k, 1, 2) != 0 {
+			t.Errorf("%s: padded output should be zero got=%f", t.Name(), output.Get(k, 1, 2))
+		}
+	}
+	for k := uint(0); k < 3; k++ {
+		for dir := uint(0); dir < 2; dir++ {
+			if math.Abs(h.Get(k, 1, dir)-shortH.Get(k, 0, dir)) > 1e-12 {
+				t.Errorf("%s: hidden state of direction %d differs expected=%f got=%f", t.Name(), dir, shortH.Get(k, 0, dir), h.Get(k, 1, dir))
+			}
+		}
+	}
+}
+
+func TestRecurrentSeed(t *testing.T) {
+	d := cpu.New[float64]()
+	newLSTM := func(seed int64) map[string]*tensor.Tensor[float64] {
+		r, _ := random.New(seed)
+		return nn.NewLSTM(d, 3, 4, nn.WithLayers(2), nn.WithRand(r)).Parameters()
+	}
+
+	p1, p2, p3 := newLSTM(1), newLSTM(1), newLSTM(2)
+	for name, p := range p1 {
+		if !tensor.Equal(p, p2[name]) {
+			t.Errorf("%s: %s should be equal with the same seed", t.Name(), name)
+		}
+		if tensor.Equal(p, p3[name]) {
+			t.Errorf("%s: %s should differ with another seed", t.Name(), name)
+		}
+	}
+	if tensor.Equal(p1["weight_hh_l0"], p1["weight_hh_l1"]) {
+		t.Errorf("%s: layers should not share their weights", t.Name())
+	}
+}
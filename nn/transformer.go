@@ -0,0 +1,209 @@
+package nn
+
+import (
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/random"
+	"github.com/blast-go/blast/tensor"
+)
+
+// TransformerEncoderLayer is a layer of the encoder of a transformer, a self
+// attention block followed by a feed forward block. Each block has a residual
+// connection and a layer normalization, applied by default to the output of
+// the residual connection or, with WithNormFirst, to the input of the block.
+type TransformerEncoderLayer[T constraints.Number] struct {
+	SelfAttention *MultiHeadAttention[T]
+	Linear1       *Linear[T]
+	Linear2       *Linear[T]
+	Norm1         *LayerNorm[T]
+	Norm2         *LayerNorm[T]
+
+	device    cpu.CPU[T]
+	dropouts  []*Dropout[T]
+	source    *random.Source
+	normFirst bool
+	gelu      bool
+}
+
+// NewTransformerEncoderLayer returns a new encoder layer for elements of size
+// dim with the given number of attention heads and a feed forward network with
+// a hidden layer of size ff. Dropout is enabled with WithDropout and the
+// generator initializing the parameters is set with WithRand.
+func NewTransformerEncoderLayer[T constraints.Number](d cpu.CPU[T], dim, heads, ff uint, opts ...option) *TransformerEncoderLayer[T] {
+	o := newOptions(opts)
+	m := &TransformerEncoderLayer[T]{
+		SelfAttention: NewMultiHeadAttention(d, dim, heads, opts...),
+		Linear1:       NewLinear(d, dim, ff, opts...),
+		Linear2:       NewLinear(d, ff, dim, opts...),
+		Norm1:         NewLayerNorm(d, tensor.Shape{dim}, opts...),
+		Norm2:         NewLayerNorm(d, tensor.Shape{dim}, opts...),
+		device:        d,
+		normFirst:     o.normFirst,
+		gelu:          o.gelu,
+	}
+	m.dropouts, m.source = transformerDropouts(d, o, 3)
+	return m
+}
+
+// Forward returns the encoded sequences, x has shape {dim, n, length} and
+// mask, with shape {n, length}, excludes the padding from the attention and
+// can be nil.
+func (m *TransformerEncoderLayer[T]) Forward(x, mask *tensor.Tensor[T]) *tensor.Tensor[T] {
+	d := m.device
+	attention := func(x *tensor.Tensor[T]) *tensor.Tensor[T] {
+		return m.dropouts[0].Forward(m.SelfAttention.Forward(x, x, x, mask, false))
+	}
+	ff := func(x *tensor.Tensor[T]) *tensor.Tensor[T] {
+		return feedForward(d, m.Linear1, m.Linear2, m.dropouts[1], m.dropouts[2], m.gelu, x)
+	}
+
+	if m.normFirst {
+		x = d.Add(x, attention(m.Norm1.Forward(x)))
+		return d.Add(x, ff(m.Norm2.Forward(x)))
+	}
+	x = m.Norm1.Forward(d.Add(x, attention(x)))
+	return m.Norm2.Forward(d.Add(x, ff(x)))
+}
+
+// Train enables training mode when v is true and evaluation mode otherwise.
+func (m *TransformerEncoderLayer[T]) Train(v bool) {
+	for _, dropout := range m.dropouts {
+		dropout.Train(v)
+	}
+}
+
+// Parameters returns the parameters of the attention, the feed forward network
+// and the normalizations.
+func (m *TransformerEncoderLayer[T]) Parameters() map[string]*tensor.Tensor[T] {
+	return Collect(map[string]Module[T]{
+		"self_attn": m.SelfAttention,
+		"linear1":   m.Linear1,
+		"linear2":   m.Linear2,
+		"norm1":     m.Norm1,
+		"norm2":     m.Norm2,
+	})
+}
+
+// RNG returns the source of the dropout masks so its state can be saved and
+// restored.
+func (m *TransformerEncoderLayer[T]) RNG() *random.Source {
+	return m.source
+}
+
+// TransformerDecoderLayer is a layer of the decoder of a transformer, a
+// causal self attention block, an attention block over the output of the
+// encoder and a feed forward block. Residual connections and normalizations
+// are applied as in TransformerEncoderLayer.
+type TransformerDecoderLayer[T constraints.Number] struct {
+	SelfAttention  *MultiHeadAttention[T]
+	CrossAttention *MultiHeadAttention[T]
+	Linear1        *Linear[T]
+	Linear2        *Linear[T]
+	Norm1          *LayerNorm[T]
+	Norm2          *LayerNorm[T]
+	Norm3          *LayerNorm[T]
+
+	device    cpu.CPU[T]
+	dropouts  []*Dropout[T]
+	source    *random.Source
+	normFirst bool
+	gelu      bool
+}
+
+// NewTransformerDecoderLayer returns a new decoder layer for elements of size
+// dim with the given number of attention heads and a feed forward network with
+// a hidden layer of size ff. Dropout is enabled with WithDropout and the
+// generator initializing the parameters is set with WithRand.
+func NewTransformerDecoderLayer[T constraints.Number](d cpu.CPU[T], dim, heads, ff uint, opts ...option) *TransformerDecoderLayer[T] {
+	o := newOptions(opts)
+	m := &TransformerDecoderLayer[T]{
+		SelfAttention:  NewMultiHeadAttention(d, dim, heads, opts...),
+		CrossAttention: NewMultiHeadAttention(d, dim, heads, opts...),
+		Linear1:        NewLinear(d, dim, ff, opts...),
+		Linear2:        NewLinear(d, ff, dim, opts...),
+		Norm1:          NewLayerNorm(d, tensor.Shape{dim}, opts...),
+		Norm2:          NewLayerNorm(d, tensor.Shape{dim}, opts...),
+		Norm3:          NewLayerNorm(d, tensor.Shape{dim}, opts...),
+		device:         d,
+		normFirst:      o.normFirst,
+		gelu:           o.gelu,
+	}
+	m.dropouts, m.source = transformerDropouts(d, o, 4)
+	return m
+}
+
+// Forward returns the decoded sequences. x has shape {dim, n, length} and
+// every position only attends to itself and the previous ones, mask excludes
+// the padding of x. memory, the output of the encoder, has shape
+// {dim, n, memoryLength} and memoryMask excludes its padding. Both masks can
+// be nil.
+func (m *TransformerDecoderLayer[T]) Forward(x, memory, mask, memoryMask *tensor.Tensor[T]) *tensor.Tensor[T] {
+	d := m.device
+	self := func(x *tensor.Tensor[T]) *tensor.Tensor[T] {
+		return m.dropouts[0].Forward(m.SelfAttention.Forward(x, x, x, mask, true))
+	}
+	cross := func(x *tensor.Tensor[T]) *tensor.Tensor[T] {
+		return m.dropouts[1].Forward(m.CrossAttention.Forward(x, memory, memory, memoryMask, false))
+	}
+	ff := func(x *tensor.Tensor[T]) *tensor.Tensor[T] {
+		return feedForward(d, m.Linear1, m.Linear2, m.dropouts[2], m.dropouts[3], m.gelu, x)
+	}
+
+	if m.normFirst {
+		x = d.Add(x, self(m.Norm1.Forward(x)))
+		x = d.Add(x, cross(m.Norm2.Forward(x)))
+		return d.Add(x, ff(m.Norm3.Forward(x)))
+	}
+	x = m.Norm1.Forward(d.Add(x, self(x)))
+	x = m.Norm2.Forward(d.Add(x, cross(x)))
+	return m.Norm3.Forward(d.Add(x, ff(x)))
+}
+
+// Train enables training mode when v is true and evaluation mode otherwise.
+func (m *TransformerDecoderLayer[T]) Train(v bool) {
+	for _, dropout := range m.dropouts {
+		dropout.Train(v)
+	}
+}
+
+// Parameters returns the parameters of the attentions, the feed forward
+// network and the normalizations.
+func (m *TransformerDecoderLayer[T]) Parameters() map[string]*tensor.Tensor[T] {
+	return Collect(map[string]Module[T]{
+		"self_attn":  m.SelfAttention,
+		"cross_attn": m.CrossAttention,
+		"linear1":    m.Linear1,
+		"linear2":    m.Linear2,
+		"norm1":      m.Norm1,
+		"norm2":      m.Norm2,
+		"norm3":      m.Norm3,
+	})
+}
+
+// RNG returns the source of the dropout masks so its state can be saved and
+// restored.
+func (m *TransformerDecoderLayer[T]) RNG() *random.Source {
+	return m.source
+}
+
+// transformerDropouts returns n dropout layers sharing a single generator.
+func transformerDropouts[T constraints.Number](d cpu.CPU[T], o options, n int) ([]*Dropout[T], *random.Source) {
+	rng, source := random.New(o.seed)
+	dropouts := make([]*Dropout[T], n)
+	for i := range dropouts {
+		dropouts[i] = &Dropout[T]{newSharedDropout(d, o.dropout, rng, source)}
+	}
+	return dropouts, source
+}
+
+// feedForward returns linear2(dropout(activation(linear1(x)))) with a final
+// dropout.
+func feedForward[T constraints.Number](d cpu.CPU[T], linear1, linear2 *Linear[T], dropout1, dropout2 *Dropout[T], gelu bool, x *tensor.Tensor[T]) *tensor.Tensor[T] {
+	h := linear1.Forward(x)
+	if gelu {
+		h = d.GELU(h)
+	} else {
+		h = d.ReLU(h)
+	}
+	return dropout2.Forward(linear2.Forward(dropout1.Forward(h)))
+}
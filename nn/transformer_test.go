@@ -0,0 +1,196 @@
+package nn_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/blast-go/blast/device/cpu"
+	"github.com/blast-go/blast/nn"
+	"github.com/blast-go/blast/random"
+	"github.com/blast-go/blast/tensor"
+)
+
+func TestLinear(t *testing.T) {
+	d := cpu.New[float64]()
+	m := nn.NewLinear(d, 3, 2)
+	x := tensor.Rand[float64](tensor.Shape{3, 4, 5})
+
+	y := m.Forward(x)
+	if !tensor.EqualShape(y, tensor.Zeros[float64](tensor.Shape{2, 4, 5})) {
+		t.Fatalf("%s: invalid output shape %v", t.Name(), y.Shape())
+	}
+	expected := m.Bias.Get(1)
+	for i := uint(0); i < 3; i++ {
+		expected += x.Get(i, 2, 3) * m.Weight.Get(1, i)
+	}
+	if math.Abs(y.Get(1, 2, 3)-expected) > 1e-12 {
+		t.Errorf("%s: Forward failed expected=%f got=%f", t.Name(), expected, y.Get(1, 2, 3))
+	}
+
+	if len(nn.NewLinear(d, 3, 2, nn.WithBias(false)).Parameters()) != 1 {
+		t.Errorf("%s: layer without bias should only have a weight", t.Name())
+	}
+}
+
+func TestAttentionMask(t *testing.T) {
+	d := cpu.New[float64]()
+	m := nn.NewMultiHeadAttention(d, 4, 2)
+
+	// two sequences of 3 elements, the second one padded after 2 elements
+	x := tensor.Rand[float64](tensor.Shape{4, 2, 3})
+	mask := tensor.New(tensor.Shape{2, 3}, []float64{1, 1, 1, 1, 1, 0})
+	causal := m.Forward(x, x, x, nil, true)
+	padded := m.Forward(x, x, x, mask, false)
+
+	// changing the last element only changes the last causal output and
+	// doesn't change the outputs of the padded sequence
+	changed := tensor.New(tensor.Shape{4, 2, 3}, append([]float64(nil), x.Elements()...))
+	for k := uint(0); k < 4; k++ {
+		changed.Set(changed.Get(k, 0, 2)+1, k, 0, 2)
+		changed.Set(changed.Get(k, 1, 2)+1, k, 1, 2)
+	}
+	changedCausal := m.Forward(changed, changed, changed, nil, true)
+	changedPadded := m.Forward(x, changed, changed, mask, false)
+
+	for k := uint(0); k < 4; k++ {
+		for n := uint(0); n < 2; n++ {
+			for step := uint(0); step < 2; step++ {
+				if math.Abs(causal.Get(k, n, step)-changedCausal.Get(k, n, step)) > 1e-12 {
+					t.Errorf("%s: causal output of step %d depends on the future", t.Name(), step)
+				}
+			}
+			if math.Abs(causal.Get(k, n, 2)-changedCausal.Get(k, n, 2)) < 1e-9 {
+				t.Errorf("%s: causal output of the last step should change", t.Name())
+			}
+		}
+		for step := uint(0); step < 3; step++ {
+			if math.Abs(padded.Get(k, 1, step)-changedPadded.Get(k, 1, step)) > 1e-12 {
+				t.Errorf("%s: output of step %d depends on the padding", t.Name(), step)
+			}
+		}
+	}
+}
+
+func TestScaledDotProductAttention(t *testing.T) {
+	d := cpu.New[float64]()
+	q := tensor.New(tensor.Shape{1, 2, 1}, []float64{1, 2})
+	k := tensor.New(tensor.Shape{1, 2, 1}, []float64{0, 1})
+	v := tensor.New(tensor.Shape{2, 2, 1}, []float64{1, 2, 3, 4})
+	// the second query can't attend to any key
+	mask := tensor.New(tensor.Shape{2, 2, 1}, []float64{1, 1, 0, 0})
+
+	y := nn.ScaledDotProductAttention(d, q, k, v, mask)
+	w := 1 / (1 + math.E)
+	expected := []float64{w*1 + (1-w)*3, w*2 + (1-w)*4, 0, 0}
+	for i, e := range y.Elements() {
+		if math.Abs(e-expected[i]) > 1e-12 {
+			t.Errorf("%s: attention failed expected=%v got=%v", t.Name(), expected, y.Elements())
+			break
+		}
+	}
+}
+
+func TestPositionalEncoding(t *testing.T) {
+	d := cpu.New[float64]()
+	m := nn.NewPositionalEncoding(d, 4, 10)
+	x := tensor.Zeros[float64](tensor.Shape{4, 2, 3})
+
+	y := m.Forward(x)
+	for n := uint(0); n < 2; n++ {
+		expected := []float64{math.Sin(2), math.Cos(2), math.Sin(0.02), math.Cos(0.02)}
+		for k, e := range expected {
+			if math.Abs(y.Get(uint(k), n, 2)-e) > 1e-12 {
+				t.Errorf("%s: encoding failed expected=%f got=%f", t.Name(), e, y.Get(uint(k), n, 2))
+			}
+		}
+	}
+}
+
+func TestTransformerGrad(t *testing.T) {
+	d := cpu.New[float64](cpu.WithGrad(true))
+	x := tensor.Rand[float64](tensor.Shape{4, 2, 3})
+	memory := tensor.Rand[float64](tensor.Shape{4, 2, 2})
+	mask := tensor.New(tensor.Shape{2, 3}, []float64{1, 1, 1, 1, 1, 0})
+	memoryMask := tensor.New(tensor.Shape{2, 2}, []float64{1, 1, 1, 0})
+
+	type module struct {
+		params  map[string]*tensor.Tensor[float64]
+		forward func() *tensor.Tensor[float64]
+	}
+	modules := map[string]module{}
+	encoder := nn.NewTransformerEncoderLayer(d, 4, 2, 6)
+	modules["Encoder"] = module{encoder.Parameters(), func() *tensor.Tensor[float64] {
+		return encoder.Forward(x, mask)
+	}}
+	preNorm := nn.NewTransformerEncoderLayer(d, 4, 2, 6, nn.WithNormFirst(true), nn.WithGELU(true))
+	modules["PreNormEncoder"] = module{preNorm.Parameters(), func() *tensor.Tensor[float64] {
+		return preNorm.Forward(x, nil)
+	}}
+	decoder := nn.NewTransformerDecoderLayer(d, 4, 2, 6)
+	modules["Decoder"] = module{decoder.Parameters(), func() *tensor.Tensor[float64] {
+		return decoder.Forward(x, memory, mask, memoryMask)
+	}}
+
+	weights := tensor.Rand[float64](tensor.Shape{4, 2, 3})
+	for name, m := range modules {
+		x.ZeroGrad()
+		memory.ZeroGrad()
+		// a weighted sum, the plain sum of a normalized output is constant
+		loss := func() *tensor.Tensor[float64] { return d.Hadamard(m.forward(), weights) }
+		loss().Backward()
+
+		inputs := map[string]*tensor.Tensor[float64]{"x": x, "memory": memory}
+		for n, p := range m.params {
+			inputs[n] = p
+		}
+		h := 1e-6
+		for input, p := range inputs {
+			elements := p.Elements()
+			for i, e := range elements {
+				elements[i] = e + h
+				plus := sum(loss())
+				elements[i] = e - h
+				minus := sum(loss())
+				elements[i] = e
+
+				numeric := (plus - minus) / (2 * h)
+				if math.Abs(p.Grad()[i]-numeric) > 1e-5*math.Max(1, math.Abs(numeric)) {
+					t.Errorf("%s: %s gradient of %s failed at %d expected=%f got=%f", t.Name(), name, input, i, numeric, p.Grad()[i])
+				}
+			}
+		}
+	}
+
+	if len(decoder.Parameters()) != 2*8+4+6 {
+		t.Errorf("%s: invalid number of decoder parameters %d", t.Name(), len(decoder.Parameters()))
+	}
+}
+
+func TestTransformerDropout(t *testing.T) {
+	d := cpu.New[float64]()
+	m := nn.NewTransformerEncoderLayer(d, 4, 2, 6, nn.WithDropout(0.5, 1))
+	x := tensor.Rand[float64](tensor.Shape{4, 2, 3})
+
+	if tensor.Equal(m.Forward(x, nil), m.Forward(x, nil)) {
+		t.Errorf("%s: outputs in training mode should differ", t.Name())
+	}
+	m.Train(false)
+	if !tensor.Equal(m.Forward(x, nil), m.Forward(x, nil)) {
+		t.Errorf("%s: outputs in evaluation mode should be equal", t.Name())
+	}
+}
+
+func TestTransformerSeed(t *testing.T) {
+	d := cpu.New[float64]()
+	newLayer := func() map[string]*tensor.Tensor[float64] {
+		r, _ := random.New(3)
+		return nn.NewTransformerDecoderLayer(d, 4, 2, 8, nn.WithRand(r)).Parameters()
+	}
+
+	p1, p2 := newLayer(), newLayer()
+	for name, p := range p1 {
+		if !tensor.Equal(p, p2[name]) {
+			t.Errorf("%s: %s should be equal with the same seed", t.Name(), name)
+		}
+	}
+}
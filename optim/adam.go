@@ -0,0 +1,103 @@
+package optim
+
+import (
+	"math"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/tensor"
+)
+
+// Adam implements the Adam algorithm described in "Adam: A Method for
+// Stochastic Optimization".
+type Adam[T constraints.Number] struct {
+	params map[string]*tensor.Tensor[T]
+	names  []string
+	lr     float64
+	cfg    options
+	step   int
+	m      map[string]*tensor.Tensor[T]
+	v      map[string]*tensor.Tensor[T]
+}
+
+// NewAdam returns a new Adam optimizer for the given parameters.
+func NewAdam[T constraints.Number](params map[string]*tensor.Tensor[T], lr float64, opts ...option) *Adam[T] {
+	return &Adam[T]{
+		params: params,
+		names:  sortedNames(params),
+		lr:     lr,
+		cfg:    newOptions(opts),
+		m:      buffersFor(params),
+		v:      buffersFor(params),
+	}
+}
+
+// Step updates the parameters using their current gradients. Parameters
+// with a sparse gradient only have their rows and running averages updated,
+// as in the lazy variant of Adam.
+func (o *Adam[T]) Step() {
+	o.step++
+	b1, b2 := o.cfg.beta1, o.cfg.beta2
+	c1 := 1 - math.Pow(b1, float64(o.step))
+	c2 := 1 - math.Pow(b2, float64(o.step))
+
+	for _, name := range o.names {
+		p := o.params[name]
+		elements := p.Elements()
+		m := o.m[name].Elements()
+		v := o.v[name].Elements()
+
+		gradients(p, func(i int, g float64) {
+			e := elements[i]
+			g += o.cfg.weightDecay * float64(e)
+			mi := b1*float64(m[i]) + (1-b1)*g
+			vi := b2*float64(v[i]) + (1-b2)*g*g
+			m[i] = T(mi)
+			v[i] = T(vi)
+			elements[i] = T(float64(e) - o.lr*(mi/c1)/(math.Sqrt(vi/c2)+o.cfg.eps))
+		})
+	}
+}
+
+// ZeroGrad sets the gradients of all parameters to zero.
+func (o *Adam[T]) ZeroGrad() {
+	zeroGrad(o.params)
+}
+
+// LR returns the current learning rate.
+func (o *Adam[T]) LR() float64 {
+	return o.lr
+}
+
+// SetLR changes the learning rate.
+func (o *Adam[T]) SetLR(lr float64) {
+	o.lr = lr
+}
+
+// State returns the learning rate, the number of steps and the running
+// averages of the gradients.
+func (o *Adam[T]) State() State[T] {
+	s := State[T]{
+		Tensors: make(map[string]*tensor.Tensor[T]),
+		Values:  map[string]float64{"lr": o.lr, "step": float64(o.step)},
+	}
+	for name := range o.params {
+		s.Tensors["m."+name] = o.m[name]
+		s.Tensors["v."+name] = o.v[name]
+	}
+	return s
+}
+
+// LoadState restores a state previously returned by State.
+func (o *Adam[T]) LoadState(s State[T]) error {
+	if err := loadBuffers("m.", o.m, s); err != nil {
+		return err
+	}
+	if err := loadBuffers("v.", o.v, s); err != nil {
+		return err
+	}
+	if lr, ok := s.Values["lr"]; ok {
+		o.lr = lr
+	}
+	o.step = int(s.Values["step"])
+	return nil
+}
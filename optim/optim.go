@@ -0,0 +1,158 @@
+// Package optim implements the optimization algorithms used to update the
+// parameters of a model from their gradients.
+package optim
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/tensor"
+)
+
+// Optimizer updates a set of parameters using their gradients.
+type Optimizer[T constraints.Number] interface {
+	// Step updates the parameters using their current gradients.
+	Step()
+	// ZeroGrad sets the gradients of all parameters to zero.
+	ZeroGrad()
+	// LR returns the current learning rate.
+	LR() float64
+	// SetLR changes the learning rate, used by schedulers.
+	SetLR(lr float64)
+	// State returns the internal state of the optimizer.
+	State() State[T]
+	// LoadState restores a state previously returned by State.
+	LoadState(State[T]) error
+}
+
+// State holds the internal state of an optimizer: tensors like momentum
+// buffers indexed by name and scalar values like the number of steps.
+type State[T constraints.Number] struct {
+	Tensors map[string]*tensor.Tensor[T]
+	Values  map[string]float64
+}
+
+type option func(*options)
+
+type options struct {
+	momentum    float64
+	weightDecay float64
+	beta1       float64
+	beta2       float64
+	eps         float64
+}
+
+// WithMomentum sets the momentum factor used by SGD.
+func WithMomentum(v float64) option {
+	return func(o *options) {
+		o.momentum = v
+	}
+}
+
+// WithWeightDecay sets the L2 penalty added to the gradients.
+func WithWeightDecay(v float64) option {
+	return func(o *options) {
+		o.weightDecay = v
+	}
+}
+
+// WithBetas sets the coefficients used by Adam to compute the running
+// averages of the gradient and its square.
+func WithBetas(beta1, beta2 float64) option {
+	return func(o *options) {
+		o.beta1 = beta1
+		o.beta2 = beta2
+	}
+}
+
+// WithEps sets the term added to the denominator by Adam to improve
+// numerical stability.
+func WithEps(v float64) option {
+	return func(o *options) {
+		o.eps = v
+	}
+}
+
+func newOptions(opts []option) options {
+	cfg := options{beta1: 0.9, beta2: 0.999, eps: 1e-8}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	return cfg
+}
+
+// sortedNames returns the names of the parameters in a deterministic order.
+func sortedNames[T constraints.Number](params map[string]*tensor.Tensor[T]) []string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func zeroGrad[T constraints.Number](params map[string]*tensor.Tensor[T]) {
+	for _, p := range params {
+		p.ZeroGrad()
+	}
+}
+
+// gradients calls f with the position and the gradient of every element of
+// the parameter to update. When the parameter only has a sparse gradient just
+// the elements of its rows are visited, so the rest of the elements and
+// their optimizer state are left untouched. When it has both the sparse
+// gradient is added to the dense one.
+func gradients[T constraints.Number](p *tensor.Tensor[T], f func(i int, g float64)) {
+	sparse := p.SparseGrad()
+	if sparse == nil || p.HasGrad() {
+		grad := p.Grad()
+		if sparse != nil {
+			grad = make([]T, len(grad))
+			copy(grad, p.Grad())
+			for _, row := range sparse.Rows() {
+				offset := row * sparse.RowSize()
+				for j, g := range sparse.Row(row) {
+					grad[offset+j] += g
+				}
+			}
+		}
+		for i, g := range grad {
+			f(i, float64(g))
+		}
+		return
+	}
+
+	for _, row := range sparse.Rows() {
+		offset := row * sparse.RowSize()
+		for j, g := range sparse.Row(row) {
+			f(offset+j, float64(g))
+		}
+	}
+}
+
+// buffersFor returns a zero tensor for every parameter with the same shape.
+func buffersFor[T constraints.Number](params map[string]*tensor.Tensor[T]) map[string]*tensor.Tensor[T] {
+	buffers := make(map[string]*tensor.Tensor[T], len(params))
+	for name, p := range params {
+		buffers[name] = tensor.Zeros[T](p.Shape())
+	}
+	return buffers
+}
+
+// loadBuffers copies the tensors named prefix+name from state into buffers.
+func loadBuffers[T constraints.Number](prefix string, buffers map[string]*tensor.Tensor[T], state State[T]) error {
+	for name, b := range buffers {
+		s, ok := state.Tensors[prefix+name]
+		if !ok {
+			return fmt.Errorf("optim: missing state %s%s", prefix, name)
+		}
+		if !tensor.EqualShape(b, s) {
+			return fmt.Errorf("optim: incompatible shape for %s%s expected=%v got=%v", prefix, name, b.Shape(), s.Shape())
+		}
+	}
+	for name, b := range buffers {
+		copy(b.Elements(), state.Tensors[prefix+name].Elements())
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package optim_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/blast-go/blast/optim"
+	"github.com/blast-go/blast/tensor"
+)
+
+func TestSGD(t *testing.T) {
+	p := tensor.New(tensor.Shape{3}, []float64{1, 2, 3})
+	copy(p.Grad(), []float64{1, -1, 0.5})
+
+	o := optim.NewSGD(map[string]*tensor.Tensor[float64]{"p": p}, 0.1, optim.WithMomentum(0.9))
+	o.Step()
+	o.Step()
+
+	// v1 = g, v2 = 0.9*g + g
+	expected := []float64{1 - 0.1*2.9, 2 + 0.1*2.9, 3 - 0.05*2.9}
+	for i, e := range p.Elements() {
+		if math.Abs(e-expected[i]) > 1e-9 {
+			t.Errorf("%s: expected=%v got=%v", t.Name(), expected, p.Elements())
+			break
+		}
+	}
+
+	o.ZeroGrad()
+	for _, g := range p.Grad() {
+		if g != 0 {
+			t.Errorf("%s: gradients not zeroed", t.Name())
+		}
+	}
+}
+
+func TestAdam(t *testing.T) {
+	p := tensor.New(tensor.Shape{2}, []float64{1, -1})
+	copy(p.Grad(), []float64{0.5, -2})
+
+	o := optim.NewAdam(map[string]*tensor.Tensor[float64]{"p": p}, 0.01)
+	o.Step()
+
+	// the first step of adam moves every parameter by lr in the direction
+	// opposite to the sign of its gradient
+	expected := []float64{0.99, -0.99}
+	for i, e := range p.Elements() {
+		if math.Abs(e-expected[i]) > 1e-6 {
+			t.Errorf("%s: expected=%v got=%v", t.Name(), expected, p.Elements())
+			break
+		}
+	}
+}
+
+func TestAdamState(t *testing.T) {
+	p1 := tensor.New(tensor.Shape{2}, []float64{1, -1})
+	p2 := tensor.New(tensor.Shape{2}, []float64{1, -1})
+	copy(p1.Grad(), []float64{0.5, -2})
+	copy(p2.Grad(), []float64{0.5, -2})
+
+	o1 := optim.NewAdam(map[string]*tensor.Tensor[float64]{"p": p1}, 0.01)
+	o1.Step()
+
+	o2 := optim.NewAdam(map[string]*tensor.Tensor[float64]{"p": p2}, 0.01)
+	o2.Step()
+	if err := o2.LoadState(o1.State()); err != nil {
+		t.Fatalf("%s: load failed: %v", t.Name(), err)
+	}
+
+	o1.Step()
+	o2.Step()
+	if !tensor.Equal(p1, p2) {
+		t.Errorf("%s: restored optimizer differs expected=%v got=%v", t.Name(), p1, p2)
+	}
+}
+
+func TestStepLR(t *testing.T) {
+	p := tensor.New(tensor.Shape{1}, []float64{1})
+	o := optim.NewSGD(map[string]*tensor.Tensor[float64]{"p": p}, 1)
+	s := optim.NewStepLR[float64](o, 2, 0.5)
+
+	expected := []float64{1, 0.5, 0.5, 0.25}
+	for i, lr := range expected {
+		s.Step()
+		if o.LR() != lr {
+			t.Errorf("%s: epoch %d expected=%f got=%f", t.Name(), i+1, lr, o.LR())
+		}
+	}
+}
+
+func TestSparseUpdates(t *testing.T) {
+	newParams := func() (*tensor.Tensor[float64], *tensor.Tensor[float64]) {
+		// 3 rows of 2 elements, only row 1 has gradient
+		sparse := tensor.New(tensor.Shape{2, 3}, []float64{1, 2, 3, 4, 5, 6})
+		copy(sparse.RowGrad(1), []float64{0.5, -1})
+		dense := tensor.New(tensor.Shape{2, 3}, []float64{1, 2, 3, 4, 5, 6})
+		copy(dense.Grad(), []float64{0, 0, 0.5, -1, 0, 0})
+		return sparse, dense
+	}
+
+	optimizers := map[string]func(map[string]*tensor.Tensor[float64]) optim.Optimizer[float64]{
+		"SGD": func(p map[string]*tensor.Tensor[float64]) optim.Optimizer[float64] {
+			return optim.NewSGD(p, 0.1, optim.WithMomentum(0.9))
+		},
+		"Adam": func(p map[string]*tensor.Tensor[float64]) optim.Optimizer[float64] {
+			return optim.NewAdam(p, 0.1)
+		},
+	}
+	for name, newOptimizer := range optimizers {
+		sparse, dense := newParams()
+		o1 := newOptimizer(map[string]*tensor.Tensor[float64]{"p": sparse})
+		o2 := newOptimizer(map[string]*tensor.Tensor[float64]{"p": dense})
+		o1.Step()
+		o2.Step()
+
+		if sparse.HasGrad() {
+			t.Errorf("%s: %s dense gradient should not be allocated", t.Name(), name)
+		}
+		for i, e := range sparse.Elements() {
+			if math.Abs(e-dense.Elements()[i]) > 1e-9 {
+				t.Errorf("%s: %s expected=%v got=%v", t.Name(), name, dense.Elements(), sparse.Elements())
+				break
+			}
+		}
+
+		// with both gradients the sparse one is added to the dense one
+		mixed, doubled := newParams()
+		copy(mixed.Grad(), []float64{0, 0, 0.5, -1, 0, 0})
+		copy(doubled.Grad(), []float64{0, 0, 1, -2, 0, 0})
+		newOptimizer(map[string]*tensor.Tensor[float64]{"p": mixed}).Step()
+		newOptimizer(map[string]*tensor.Tensor[float64]{"p": doubled}).Step()
+		for i, e := range mixed.Elements() {
+			if math.Abs(e-doubled.Elements()[i]) > 1e-9 {
+				t.Errorf("%s: %s mixed gradients expected=%v got=%v", t.Name(), name, doubled.Elements(), mixed.Elements())
+				break
+			}
+		}
+
+		o1.ZeroGrad()
+		if sparse.SparseGrad() != nil || sparse.HasGrad() {
+			t.Errorf("%s: %s gradients not zeroed", t.Name(), name)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package optim
+
+import (
+	"math"
+
+	"github.com/blast-go/blast/constraints"
+)
+
+// Scheduler adjusts the learning rate of an optimizer as training
+// progresses.
+type Scheduler interface {
+	// Step advances the scheduler by one epoch.
+	Step()
+	// State returns the internal state of the scheduler.
+	State() map[string]float64
+	// LoadState restores a state previously returned by State.
+	LoadState(map[string]float64) error
+}
+
+// StepLR decays the learning rate by gamma every stepSize epochs.
+type StepLR[T constraints.Number] struct {
+	optimizer Optimizer[T]
+	baseLR    float64
+	stepSize  int
+	gamma     float64
+	epoch     int
+}
+
+// NewStepLR returns a new StepLR scheduler for the optimizer.
+func NewStepLR[T constraints.Number](o Optimizer[T], stepSize int, gamma float64) *StepLR[T] {
+	if stepSize <= 0 {
+		panic("step size must be positive")
+	}
+	return &StepLR[T]{optimizer: o, baseLR: o.LR(), stepSize: stepSize, gamma: gamma}
+}
+
+// Step advances the scheduler by one epoch and updates the learning rate.
+func (s *StepLR[T]) Step() {
+	s.epoch++
+	s.optimizer.SetLR(s.baseLR * math.Pow(s.gamma, float64(s.epoch/s.stepSize)))
+}
+
+// State returns the epoch and the initial learning rate.
+func (s *StepLR[T]) State() map[string]float64 {
+	return map[string]float64{"epoch": float64(s.epoch), "base_lr": s.baseLR}
+}
+
+// LoadState restores a state previously returned by State.
+func (s *StepLR[T]) LoadState(state map[string]float64) error {
+	s.epoch = int(state["epoch"])
+	if lr, ok := state["base_lr"]; ok {
+		s.baseLR = lr
+	}
+	s.optimizer.SetLR(s.baseLR * math.Pow(s.gamma, float64(s.epoch/s.stepSize)))
+	return nil
+}
@@ -0,0 +1,93 @@
+package optim
+
+import (
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/tensor"
+)
+
+// SGD implements stochastic gradient descent with optional momentum and
+// weight decay.
+type SGD[T constraints.Number] struct {
+	params   map[string]*tensor.Tensor[T]
+	names    []string
+	lr       float64
+	cfg      options
+	velocity map[string]*tensor.Tensor[T]
+}
+
+// NewSGD returns a new SGD optimizer for the given parameters.
+func NewSGD[T constraints.Number](params map[string]*tensor.Tensor[T], lr float64, opts ...option) *SGD[T] {
+	o := &SGD[T]{
+		params: params,
+		names:  sortedNames(params),
+		lr:     lr,
+		cfg:    newOptions(opts),
+	}
+	if o.cfg.momentum != 0 {
+		o.velocity = buffersFor(params)
+	}
+	return o
+}
+
+// Step updates the parameters using their current gradients. Parameters
+// with a sparse gradient only have their rows and momentum buffers updated.
+func (o *SGD[T]) Step() {
+	for _, name := range o.names {
+		p := o.params[name]
+		elements := p.Elements()
+
+		var velocity []T
+		if o.velocity != nil {
+			velocity = o.velocity[name].Elements()
+		}
+
+		gradients(p, func(i int, g float64) {
+			e := elements[i]
+			g += o.cfg.weightDecay * float64(e)
+			if velocity != nil {
+				v := o.cfg.momentum*float64(velocity[i]) + g
+				velocity[i] = T(v)
+				g = v
+			}
+			elements[i] = T(float64(e) - o.lr*g)
+		})
+	}
+}
+
+// ZeroGrad sets the gradients of all parameters to zero.
+func (o *SGD[T]) ZeroGrad() {
+	zeroGrad(o.params)
+}
+
+// LR returns the current learning rate.
+func (o *SGD[T]) LR() float64 {
+	return o.lr
+}
+
+// SetLR changes the learning rate.
+func (o *SGD[T]) SetLR(lr float64) {
+	o.lr = lr
+}
+
+// State returns the learning rate and the momentum buffers.
+func (o *SGD[T]) State() State[T] {
+	s := State[T]{
+		Tensors: make(map[string]*tensor.Tensor[T]),
+		Values:  map[string]float64{"lr": o.lr},
+	}
+	for name, v := range o.velocity {
+		s.Tensors["velocity."+name] = v
+	}
+	return s
+}
+
+// LoadState restores a state previously returned by State.
+func (o *SGD[T]) LoadState(s State[T]) error {
+	if err := loadBuffers("velocity.", o.velocity, s); err != nil {
+		return err
+	}
+	if lr, ok := s.Values["lr"]; ok {
+		o.lr = lr
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+// Package random provides a seedable source of pseudo random numbers whose
+// state can be saved and restored, allowing reproducible training runs.
+package random
+
+import "math/rand"
+
+// Source is a splitmix64 pseudo random number generator. It implements
+// rand.Source64 so it can be used with rand.New. A Source is not safe for
+// concurrent use.
+type Source struct {
+	state uint64
+}
+
+// NewSource returns a new Source initialized with the given seed.
+func NewSource(seed int64) *Source {
+	return &Source{state: uint64(seed)}
+}
+
+// New returns a new rand.Rand that uses a Source initialized with the given
+// seed, together with the Source so its state can be saved.
+func New(seed int64) (*rand.Rand, *Source) {
+	src := NewSource(seed)
+	return rand.New(src), src
+}
+
+// Seed resets the state of the source using the given seed.
+func (s *Source) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+// Uint64 returns a pseudo random 64 bit value.
+func (s *Source) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Int63 returns a non-negative pseudo random 63 bit integer.
+func (s *Source) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// State returns the internal state of the source.
+func (s *Source) State() uint64 {
+	return s.state
+}
+
+// SetState restores a state previously returned by State.
+func (s *Source) SetState(state uint64) {
+	s.state = state
+}
@@ -0,0 +1,37 @@
+package random_test
+
+import (
+	"testing"
+
+	"github.com/blast-go/blast/random"
+)
+
+func TestSeed(t *testing.T) {
+	s1 := random.NewSource(42)
+	s2 := random.NewSource(42)
+	for i := 0; i < 10; i++ {
+		if s1.Uint64() != s2.Uint64() {
+			t.Fatalf("%s: sources with the same seed differ", t.Name())
+		}
+	}
+
+	s3 := random.NewSource(43)
+	if s1.Uint64() == s3.Uint64() {
+		t.Errorf("%s: sources with different seed are equal", t.Name())
+	}
+}
+
+func TestState(t *testing.T) {
+	r, src := random.New(7)
+	r.Intn(100)
+
+	state := src.State()
+	expected := []int{r.Intn(100), r.Intn(100), r.Intn(100)}
+
+	src.SetState(state)
+	for i, e := range expected {
+		if actual := r.Intn(100); actual != e {
+			t.Errorf("%s: restored state differs at %d expected=%d got=%d", t.Name(), i, e, actual)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package safetensors
+
+import "os"
+
+// Memory mapping is not available, the whole file is read into memory.
+func mmapFile(path string) ([]byte, func() error, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
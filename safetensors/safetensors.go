@@ -0,0 +1,584 @@
+// Package safetensors reads and writes tensors using the safetensors format.
+// A safetensors file is made of an 8 byte little-endian header size, a JSON
+// header describing every tensor and a raw buffer with all the elements stored
+// in little-endian order.
+//
+// The safetensors format stores shapes with the outermost dimension first
+// while blast stores them with the innermost dimension first, shapes are
+// reversed when reading and writing so that the elements keep the same layout.
+//
+// Tensors of numbers are read and written with Get and Write, tensors of half
+// precision numbers with GetHalf and WriteHalf which keep their bits.
+package safetensors
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/blast-go/blast/constraints"
+	"github.com/blast-go/blast/half"
+	"github.com/blast-go/blast/tensor"
+)
+
+// DType identifies the type of the elements of a tensor stored in a file.
+type DType string
+
+const (
+	BOOL DType = "BOOL"
+	U8   DType = "U8"
+	I8   DType = "I8"
+	U16  DType = "U16"
+	I16  DType = "I16"
+	F16  DType = "F16"
+	BF16 DType = "BF16"
+	U32  DType = "U32"
+	I32  DType = "I32"
+	F32  DType = "F32"
+	U64  DType = "U64"
+	I64  DType = "I64"
+	F64  DType = "F64"
+)
+
+// Size returns the number of bytes used by a single element of the type.
+// Returns zero for unknown types.
+func (d DType) Size() int {
+	switch d {
+	case BOOL, U8, I8:
+		return 1
+	case U16, I16, F16, BF16:
+		return 2
+	case U32, I32, F32:
+		return 4
+	case U64, I64, F64:
+		return 8
+	default:
+		return 0
+	}
+}
+
+// Info describes a tensor stored in a file as it appears in the header.
+type Info struct {
+	DType   DType     `json:"dtype"`
+	Shape   []uint64  `json:"shape"`
+	Offsets [2]uint64 `json:"data_offsets"`
+}
+
+const metadataKey = "__metadata__"
+
+// Maximum size allowed for the JSON header, protects against allocating
+// huge amounts of memory when reading corrupted files.
+const maxHeaderSize = 100 << 20
+
+var ErrClosed = errors.New("safetensors: file already closed")
+
+// File gives access to the tensors stored in a safetensors file. The elements
+// of the tensors are only decoded when they are needed.
+type File struct {
+	infos    map[string]Info
+	metadata map[string]string
+	data     []byte
+	release  func() error
+}
+
+// Open memory maps the file located at path. Tensors returned by Get are
+// decoded lazily from the mapped memory the first time their elements are
+// accessed, so the file must not be closed before that happens.
+func Open(path string) (*File, error) {
+	data, release, err := mmapFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := parse(data)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	f.release = release
+	return f, nil
+}
+
+// Read reads a whole safetensors file into memory from r.
+func Read(r io.Reader) (*File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return parse(data)
+}
+
+func parse(data []byte) (*File, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("safetensors: file too small size=%d", len(data))
+	}
+
+	size := binary.LittleEndian.Uint64(data[:8])
+	if size > maxHeaderSize || size > uint64(len(data)-8) {
+		return nil, fmt.Errorf("safetensors: invalid header size=%d", size)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data[8:8+size], &raw); err != nil {
+		return nil, fmt.Errorf("safetensors: invalid header: %w", err)
+	}
+
+	f := &File{infos: make(map[string]Info, len(raw)), data: data[8+size:]}
+	for name, msg := range raw {
+		if name == metadataKey {
+			if err := json.Unmarshal(msg, &f.metadata); err != nil {
+				return nil, fmt.Errorf("safetensors: invalid metadata: %w", err)
+			}
+			continue
+		}
+
+		var info Info
+		if err := json.Unmarshal(msg, &info); err != nil {
+			return nil, fmt.Errorf("safetensors: invalid tensor %q: %w", name, err)
+		}
+		if err := validate(info, uint64(len(f.data))); err != nil {
+			return nil, fmt.Errorf("safetensors: invalid tensor %q: %w", name, err)
+		}
+		f.infos[name] = info
+	}
+
+	return f, nil
+}
+
+func validate(info Info, bufferSize uint64) error {
+	elementSize := info.DType.Size()
+	if elementSize == 0 {
+		return fmt.Errorf("unknown dtype %s", info.DType)
+	}
+
+	// the number of elements is bounded by the buffer at each step so the
+	// product can't overflow
+	limit := bufferSize / uint64(elementSize)
+	size := uint64(1)
+	for _, d := range info.Shape {
+		if d == 0 {
+			return fmt.Errorf("invalid dimension 0 in shape %v", info.Shape)
+		}
+		if d > limit/size {
+			return fmt.Errorf("shape %v larger than the buffer size=%d", info.Shape, bufferSize)
+		}
+		size *= d
+	}
+
+	begin, end := info.Offsets[0], info.Offsets[1]
+	if begin > end || end > bufferSize {
+		return fmt.Errorf("offsets out of bounds begin=%d end=%d size=%d", begin, end, bufferSize)
+	}
+	if end-begin != size*uint64(elementSize) {
+		return fmt.Errorf("invalid number of bytes expected=%d got=%d", size*uint64(elementSize), end-begin)
+	}
+	return nil
+}
+
+// Names returns the sorted names of all tensors in the file.
+func (f *File) Names() []string {
+	names := make([]string, 0, len(f.infos))
+	for name := range f.infos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Info returns the description of the tensor with the given name and true, or
+// false if the file does not contain such tensor.
+func (f *File) Info(name string) (Info, bool) {
+	info, ok := f.infos[name]
+	return info, ok
+}
+
+// Metadata returns the free form string map stored in the header.
+func (f *File) Metadata() map[string]string {
+	return f.metadata
+}
+
+// Close releases the resources used by the file. Tensors that have not been
+// decoded yet can't be used after calling Close.
+func (f *File) Close() error {
+	if f.data == nil {
+		return ErrClosed
+	}
+	f.data = nil
+	if f.release != nil {
+		return f.release()
+	}
+	return nil
+}
+
+// Get returns the tensor with the given name converted to the numeric type
+// T. Elements are decoded the first time they are accessed.
+func Get[T constraints.Number](f *File, name string) (*tensor.Tensor[T], error) {
+	return get(f, name, decoderFor[T])
+}
+
+// GetHalf is like Get for the half precision types, whose elements are
+// rounded to nearest even unless the tensor is stored with the type H.
+func GetHalf[H half.Float](f *File, name string) (*tensor.Tensor[H], error) {
+	return get(f, name, halfDecoderFor[H])
+}
+
+func get[T constraints.Storage](f *File, name string, decoderFor func(DType) (func([]byte) []T, error)) (*tensor.Tensor[T], error) {
+	if f.data == nil {
+		return nil, ErrClosed
+	}
+
+	info, ok := f.infos[name]
+	if !ok {
+		return nil, fmt.Errorf("safetensors: tensor %q not found", name)
+	}
+
+	shape := make(tensor.Shape, len(info.Shape))
+	for i, d := range info.Shape {
+		if d == 0 {
+			return nil, fmt.Errorf("safetensors: tensor %q has zero sized dimension", name)
+		}
+		shape[len(shape)-1-i] = uint(d)
+	}
+
+	decode, err := decoderFor(info.DType)
+	if err != nil {
+		return nil, fmt.Errorf("safetensors: tensor %q: %w", name, err)
+	}
+
+	forward := func() []T {
+		if f.data == nil {
+			panic(ErrClosed)
+		}
+		return decode(f.data[info.Offsets[0]:info.Offsets[1]])
+	}
+
+	return tensor.Op(shape, nil, forward, nil), nil
+}
+
+// All returns every tensor in the file converted to the numeric type T.
+func All[T constraints.Number](f *File) (map[string]*tensor.Tensor[T], error) {
+	return all(f, Get[T])
+}
+
+// AllHalf is like All for the half precision types.
+func AllHalf[H half.Float](f *File) (map[string]*tensor.Tensor[H], error) {
+	return all(f, GetHalf[H])
+}
+
+func all[T constraints.Storage](f *File, get func(*File, string) (*tensor.Tensor[T], error)) (map[string]*tensor.Tensor[T], error) {
+	tensors := make(map[string]*tensor.Tensor[T], len(f.infos))
+	for name := range f.infos {
+		t, err := get(f, name)
+		if err != nil {
+			return nil, err
+		}
+		tensors[name] = t
+	}
+	return tensors, nil
+}
+
+// Load reads all tensors and the metadata of the file located at path. Unlike
+// Open all elements are decoded before returning.
+func Load[T constraints.Number](path string) (map[string]*tensor.Tensor[T], map[string]string, error) {
+	return load(path, All[T])
+}
+
+// LoadHalf is like Load for the half precision types.
+func LoadHalf[H half.Float](path string) (map[string]*tensor.Tensor[H], map[string]string, error) {
+	return load(path, AllHalf[H])
+}
+
+func load[T constraints.Storage](path string, all func(*File) (map[string]*tensor.Tensor[T], error)) (map[string]*tensor.Tensor[T], map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	f, err := Read(bufio.NewReader(file))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tensors, err := all(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, t := range tensors {
+		t.Elements()
+	}
+	return tensors, f.Metadata(), nil
+}
+
+// Write encodes the tensors and the metadata in the safetensors format into w.
+// Tensors are stored sorted by name using the dtype matching T.
+func Write[T constraints.Number](w io.Writer, tensors map[string]*tensor.Tensor[T], metadata map[string]string) error {
+	dtype, err := dtypeOf[T]()
+	if err != nil {
+		return err
+	}
+	return write(w, tensors, metadata, dtype, func(elements []T) []byte {
+		return encode(dtype, elements)
+	})
+}
+
+// WriteHalf is like Write for the half precision types, stored as F16 or
+// BF16.
+func WriteHalf[H half.Float](w io.Writer, tensors map[string]*tensor.Tensor[H], metadata map[string]string) error {
+	return write(w, tensors, metadata, halfDTypeOf[H](), encodeHalf[H])
+}
+
+func write[T constraints.Storage](w io.Writer, tensors map[string]*tensor.Tensor[T], metadata map[string]string, dtype DType, encode func([]T) []byte) error {
+	names := make([]string, 0, len(tensors))
+	for name := range tensors {
+		if name == metadataKey {
+			return fmt.Errorf("safetensors: invalid tensor name %q", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	header := make(map[string]any, len(tensors)+1)
+	if len(metadata) > 0 {
+		header[metadataKey] = metadata
+	}
+
+	sizes := make(map[string]uint64, len(tensors))
+	offset := uint64(0)
+	for _, name := range names {
+		t := tensors[name]
+		shape := t.Shape()
+		info := Info{DType: dtype, Shape: make([]uint64, len(shape))}
+		size := uint64(1)
+		for i, d := range shape {
+			info.Shape[len(shape)-1-i] = uint64(d)
+			size *= uint64(d)
+		}
+		info.Offsets = [2]uint64{offset, offset + size*uint64(dtype.Size())}
+		offset = info.Offsets[1]
+		sizes[name] = info.Offsets[1] - info.Offsets[0]
+		header[name] = info
+	}
+
+	headerBytes, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	// pad the header with spaces so the buffer starts 8 byte aligned
+	for len(headerBytes)%8 != 0 {
+		headerBytes = append(headerBytes, ' ')
+	}
+
+	bw := bufio.NewWriter(w)
+	var sizeBytes [8]byte
+	binary.LittleEndian.PutUint64(sizeBytes[:], uint64(len(headerBytes)))
+	if _, err := bw.Write(sizeBytes[:]); err != nil {
+		return err
+	}
+	if _, err := bw.Write(headerBytes); err != nil {
+		return err
+	}
+	for _, name := range names {
+		elements := tensors[name].Elements()
+		if bytes := uint64(len(elements) * dtype.Size()); bytes != sizes[name] {
+			return fmt.Errorf("safetensors: invalid number of bytes for tensor %q expected=%d got=%d", name, sizes[name], bytes)
+		}
+		if _, err := bw.Write(encode(elements)); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Save writes the tensors and the metadata into the file located at path,
+// the file is created or truncated if it already exists.
+func Save[T constraints.Number](path string, tensors map[string]*tensor.Tensor[T], metadata map[string]string) error {
+	return save(path, tensors, metadata, Write[T])
+}
+
+// SaveHalf is like Save for the half precision types.
+func SaveHalf[H half.Float](path string, tensors map[string]*tensor.Tensor[H], metadata map[string]string) error {
+	return save(path, tensors, metadata, WriteHalf[H])
+}
+
+func save[T constraints.Storage](path string, tensors map[string]*tensor.Tensor[T], metadata map[string]string, write func(io.Writer, map[string]*tensor.Tensor[T], map[string]string) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := write(file, tensors, metadata); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func dtypeOf[T constraints.Number]() (DType, error) {
+	switch reflect.ValueOf(T(0)).Kind() {
+	case reflect.Uint8:
+		return U8, nil
+	case reflect.Int8:
+		return I8, nil
+	case reflect.Uint16:
+		return U16, nil
+	case reflect.Int16:
+		return I16, nil
+	case reflect.Uint32:
+		return U32, nil
+	case reflect.Int32:
+		return I32, nil
+	case reflect.Float32:
+		return F32, nil
+	case reflect.Uint64, reflect.Uintptr:
+		return U64, nil
+	case reflect.Int64:
+		return I64, nil
+	case reflect.Float64:
+		return F64, nil
+	case reflect.Uint:
+		if strconv.IntSize == 32 {
+			return U32, nil
+		}
+		return U64, nil
+	case reflect.Int:
+		if strconv.IntSize == 32 {
+			return I32, nil
+		}
+		return I64, nil
+	default:
+		return "", fmt.Errorf("safetensors: unsupported type %T", T(0))
+	}
+}
+
+func halfDTypeOf[H half.Float]() DType {
+	var h H
+	switch any(h).(type) {
+	case half.Float16:
+		return F16
+	default:
+		return BF16
+	}
+}
+
+func decoderFor[T constraints.Number](dtype DType) (func([]byte) []T, error) {
+	size, decode, err := elementDecoderFor[T](dtype)
+	if err != nil {
+		return nil, err
+	}
+	return decoder(size, decode), nil
+}
+
+// halfDecoderFor returns the decoder of elements of the given dtype into half
+// precision numbers, which keep their bits if the dtype is the one of H and
+// are rounded from the value of other elements otherwise.
+func halfDecoderFor[H half.Float](dtype DType) (func([]byte) []H, error) {
+	if dtype == halfDTypeOf[H]() {
+		return decoder(2, func(b []byte) H { return half.FromBits[H](binary.LittleEndian.Uint16(b)) }), nil
+	}
+	size, decode, err := elementDecoderFor[float64](dtype)
+	if err != nil {
+		return nil, err
+	}
+	return decoder(size, func(b []byte) H { return half.New[H](decode(b)) }), nil
+}
+
+// elementDecoderFor returns the size and the decoder of a single element of
+// the given dtype.
+func elementDecoderFor[T constraints.Number](dtype DType) (int, func([]byte) T, error) {
+	le := binary.LittleEndian
+	switch dtype {
+	case BOOL:
+		return 1, func(b []byte) T {
+			if b[0] != 0 {
+				return 1
+			}
+			return 0
+		}, nil
+	case U8:
+		return 1, func(b []byte) T { return T(b[0]) }, nil
+	case I8:
+		return 1, func(b []byte) T { return T(int8(b[0])) }, nil
+	case U16:
+		return 2, func(b []byte) T { return T(le.Uint16(b)) }, nil
+	case I16:
+		return 2, func(b []byte) T { return T(int16(le.Uint16(b))) }, nil
+	case U32:
+		return 4, func(b []byte) T { return T(le.Uint32(b)) }, nil
+	case I32:
+		return 4, func(b []byte) T { return T(int32(le.Uint32(b))) }, nil
+	case F16:
+		return 2, func(b []byte) T { return T(half.FromBits[half.Float16](le.Uint16(b)).Float64()) }, nil
+	case BF16:
+		return 2, func(b []byte) T { return T(half.FromBits[half.BFloat16](le.Uint16(b)).Float64()) }, nil
+	case F32:
+		return 4, func(b []byte) T { return T(math.Float32frombits(le.Uint32(b))) }, nil
+	case U64:
+		return 8, func(b []byte) T { return T(le.Uint64(b)) }, nil
+	case I64:
+		return 8, func(b []byte) T { return T(int64(le.Uint64(b))) }, nil
+	case F64:
+		return 8, func(b []byte) T { return T(math.Float64frombits(le.Uint64(b))) }, nil
+	default:
+		return 0, nil, fmt.Errorf("unsupported dtype %s", dtype)
+	}
+}
+
+func decoder[T constraints.Storage](size int, decode func([]byte) T) func([]byte) []T {
+	return func(b []byte) []T {
+		elements := make([]T, len(b)/size)
+		for i := range elements {
+			elements[i] = decode(b[i*size : (i+1)*size])
+		}
+		return elements
+	}
+}
+
+func encode[T constraints.Number](dtype DType, elements []T) []byte {
+	size := dtype.Size()
+	b := make([]byte, len(elements)*size)
+	le := binary.LittleEndian
+	for i, e := range elements {
+		o := b[i*size : (i+1)*size]
+		switch dtype {
+		case U8:
+			o[0] = uint8(e)
+		case I8:
+			o[0] = uint8(int8(e))
+		case U16:
+			le.PutUint16(o, uint16(e))
+		case I16:
+			le.PutUint16(o, uint16(int16(e)))
+		case U32:
+			le.PutUint32(o, uint32(e))
+		case I32:
+			le.PutUint32(o, uint32(int32(e)))
+		case F32:
+			le.PutUint32(o, math.Float32bits(float32(e)))
+		case U64:
+			le.PutUint64(o, uint64(e))
+		case I64:
+			le.PutUint64(o, uint64(int64(e)))
+		case F64:
+			le.PutUint64(o, math.Float64bits(float64(e)))
+		}
+	}
+	return b
+}
+
+// encodeHalf returns the bits of the half precision numbers.
+func encodeHalf[H half.Float](elements []H) []byte {
+	b := make([]byte, len(elements)*2)
+	for i, e := range elements {
+		binary.LittleEndian.PutUint16(b[i*2:], e.Bits())
+	}
+	return b
+}
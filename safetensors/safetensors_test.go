@@ -0,0 +1,179 @@
+package safetensors_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+
+	"github.com/blast-go/blast/half"
+	"github.com/blast-go/blast/safetensors"
+	"github.com/blast-go/blast/tensor"
+)
+
+func TestWriteRead(t *testing.T) {
+	tensors := map[string]*tensor.Tensor[float32]{
+		"weight": tensor.New(tensor.Shape{3, 2}, []float32{1, 2, 3, 4, 5, 6}),
+		"bias":   tensor.New(tensor.Shape{2}, []float32{-1, 1}),
+	}
+	metadata := map[string]string{"format": "pt"}
+
+	var buf bytes.Buffer
+	if err := safetensors.Write(&buf, tensors, metadata); err != nil {
+		t.Fatalf("%s: write failed: %v", t.Name(), err)
+	}
+
+	f, err := safetensors.Read(&buf)
+	if err != nil {
+		t.Fatalf("%s: read failed: %v", t.Name(), err)
+	}
+
+	if names := f.Names(); len(names) != 2 || names[0] != "bias" || names[1] != "weight" {
+		t.Errorf("%s: invalid names got=%v", t.Name(), names)
+	}
+
+	if f.Metadata()["format"] != "pt" {
+		t.Errorf("%s: invalid metadata got=%v", t.Name(), f.Metadata())
+	}
+
+	info, _ := f.Info("weight")
+	if info.DType != safetensors.F32 || len(info.Shape) != 2 || info.Shape[0] != 2 || info.Shape[1] != 3 {
+		t.Errorf("%s: invalid info got=%v", t.Name(), info)
+	}
+
+	for name, expected := range tensors {
+		actual, err := safetensors.Get[float32](f, name)
+		if err != nil {
+			t.Fatalf("%s: get failed: %v", t.Name(), err)
+		}
+		if !tensor.Equal(actual, expected) {
+			t.Errorf("%s: expected=%v got=%v", t.Name(), expected, actual)
+		}
+	}
+}
+
+func TestReadConvert(t *testing.T) {
+	header := []byte(`{"a":{"dtype":"I16","shape":[2,2],"data_offsets":[0,8]}}`)
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, uint64(len(header)))
+	buf.Write(header)
+	binary.Write(&buf, binary.LittleEndian, []int16{-2, -1, 1, 2})
+
+	f, err := safetensors.Read(&buf)
+	if err != nil {
+		t.Fatalf("%s: read failed: %v", t.Name(), err)
+	}
+
+	actual, err := safetensors.Get[float64](f, "a")
+	if err != nil {
+		t.Fatalf("%s: get failed: %v", t.Name(), err)
+	}
+
+	expected := tensor.New(tensor.Shape{2, 2}, []float64{-2, -1, 1, 2})
+	if !tensor.Equal(actual, expected) {
+		t.Errorf("%s: expected=%v got=%v", t.Name(), expected, actual)
+	}
+}
+
+func TestHalf(t *testing.T) {
+	values := []float64{1, -2.5, 0.1, 65504}
+	elements := make([]half.Float16, len(values))
+	for i, v := range values {
+		elements[i] = half.NewFloat16(v)
+	}
+	tensors := map[string]*tensor.Tensor[half.Float16]{"a": tensor.New(tensor.Shape{2, 2}, elements)}
+
+	var buf bytes.Buffer
+	if err := safetensors.WriteHalf(&buf, tensors, nil); err != nil {
+		t.Fatalf("%s: write failed: %v", t.Name(), err)
+	}
+	f, err := safetensors.Read(&buf)
+	if err != nil {
+		t.Fatalf("%s: read failed: %v", t.Name(), err)
+	}
+	if info, _ := f.Info("a"); info.DType != safetensors.F16 {
+		t.Errorf("%s: invalid dtype %s", t.Name(), info.DType)
+	}
+
+	same, err := safetensors.GetHalf[half.Float16](f, "a")
+	if err != nil {
+		t.Fatalf("%s: get failed: %v", t.Name(), err)
+	}
+	if !tensor.Equal(same, tensors["a"]) {
+		t.Errorf("%s: expected=%v got=%v", t.Name(), tensors["a"], same)
+	}
+
+	// float16 to float32 is exact and float32 to bfloat16 rounds
+	upcast, _ := safetensors.Get[float32](f, "a")
+	bfloat, _ := safetensors.GetHalf[half.BFloat16](f, "a")
+	for i, e := range elements {
+		if upcast.Elements()[i] != e.Float32() {
+			t.Errorf("%s: float32 expected=%v got=%v", t.Name(), e, upcast.Elements()[i])
+		}
+		if expected := half.NewBFloat16(e.Float64()); bfloat.Elements()[i] != expected {
+			t.Errorf("%s: bfloat16 expected=%v got=%v", t.Name(), expected, bfloat.Elements()[i])
+		}
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	headers := []string{
+		`{"a":{"dtype":"F32","shape":[2,2],"data_offsets":[0,8]}}`,
+		`{"a":{"dtype":"X32","shape":[1],"data_offsets":[0,4]}}`,
+		`{"a":{"dtype":"F32","shape":[1],"data_offsets":[0,32]}}`,
+		`{"a":{"dtype":"F32","shape":[0],"data_offsets":[0,0]}}`,
+		// 4611686018427387905*4 wraps around to 4 elements, 16 bytes
+		`{"a":{"dtype":"F32","shape":[4611686018427387905,4],"data_offsets":[0,16]}}`,
+		`{"a":`,
+	}
+
+	for _, header := range headers {
+		var buf bytes.Buffer
+		binary.Write(&buf, binary.LittleEndian, uint64(len(header)))
+		buf.WriteString(header)
+		buf.Write(make([]byte, 16))
+
+		if _, err := safetensors.Read(&buf); err == nil {
+			t.Errorf("%s: header should be invalid %s", t.Name(), header)
+		}
+	}
+}
+
+func TestSaveOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.safetensors")
+	tensors := map[string]*tensor.Tensor[int32]{
+		"a": tensor.New(tensor.Shape{2, 2, 2}, []int32{1, 2, 3, 4, 5, 6, 7, 8}),
+	}
+	if err := safetensors.Save(path, tensors, nil); err != nil {
+		t.Fatalf("%s: save failed: %v", t.Name(), err)
+	}
+
+	f, err := safetensors.Open(path)
+	if err != nil {
+		t.Fatalf("%s: open failed: %v", t.Name(), err)
+	}
+
+	actual, err := safetensors.Get[int32](f, "a")
+	if err != nil {
+		t.Fatalf("%s: get failed: %v", t.Name(), err)
+	}
+	if !tensor.Equal(actual, tensors["a"]) {
+		t.Errorf("%s: expected=%v got=%v", t.Name(), tensors["a"], actual)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Errorf("%s: close failed: %v", t.Name(), err)
+	}
+	if _, err := safetensors.Get[int32](f, "a"); err != safetensors.ErrClosed {
+		t.Errorf("%s: get should fail after close", t.Name())
+	}
+
+	loaded, _, err := safetensors.Load[int32](path)
+	if err != nil {
+		t.Fatalf("%s: load failed: %v", t.Name(), err)
+	}
+	if !tensor.Equal(loaded["a"], tensors["a"]) {
+		t.Errorf("%s: expected=%v got=%v", t.Name(), tensors["a"], loaded["a"])
+	}
+}
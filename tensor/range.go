@@ -0,0 +1,54 @@
+package tensor
+
+import "math"
+
+// Range selects the elements of a dimension from Start up to Stop, not
+// included, taking one every Step elements. Negative Start and Stop count
+// from the end of the dimension and both are clamped to its bounds. A Step of
+// zero is treated as one and a negative Step selects elements in reverse
+// order.
+type Range struct {
+	Start int
+	Stop  int
+	Step  int
+}
+
+// All returns a Range that selects every element of a dimension.
+func All() Range {
+	return Range{Start: 0, Stop: math.MaxInt, Step: 1}
+}
+
+// Indices returns the positions selected by the range on a dimension of
+// the given size.
+func (r Range) Indices(size uint) []int {
+	n := int(size)
+	step := r.Step
+	if step == 0 {
+		step = 1
+	}
+
+	// lower and upper are the bounds of valid start and stop values
+	lower, upper := 0, n
+	if step < 0 {
+		lower, upper = -1, n-1
+	}
+	clamp := func(i int) int {
+		if i < 0 {
+			i += n
+			if i < lower {
+				i = lower
+			}
+		}
+		if i > upper {
+			i = upper
+		}
+		return i
+	}
+
+	start, stop := clamp(r.Start), clamp(r.Stop)
+	var indices []int
+	for i := start; (step > 0 && i < stop) || (step < 0 && i > stop); i += step {
+		indices = append(indices, i)
+	}
+	return indices
+}
@@ -0,0 +1,74 @@
+package tensor
+
+import (
+	"fmt"
+
+	"github.com/blast-go/blast/constraints"
+)
+
+// SparseGrad is a gradient in which only some rows are not zero, a row being
+// the elements that share the same index in the last dimension. Operations
+// like embedding lookups produce sparse gradients to avoid allocating a
+// gradient as large as the whole tensor.
+type SparseGrad[T constraints.Storage] struct {
+	rowSize int
+	rows    []int
+	values  map[int][]T
+}
+
+// Rows returns the indices of the rows of the gradient in the order they were
+// added.
+func (g *SparseGrad[T]) Rows() []int {
+	return g.rows
+}
+
+// Row returns the elements of the gradient of the given row, nil if the row
+// is not part of the gradient.
+func (g *SparseGrad[T]) Row(row int) []T {
+	return g.values[row]
+}
+
+// RowSize returns the number of elements of every row.
+func (g *SparseGrad[T]) RowSize() int {
+	return g.rowSize
+}
+
+// SparseGrad returns the sparse gradient of the tensor, nil if no operation
+// has produced one.
+func (t *Tensor[T]) SparseGrad() *SparseGrad[T] {
+	return t.sparse
+}
+
+// RowGrad returns the elements of the sparse gradient of the given row, which
+// start at zero, so backward functions can accumulate into them. The
+// backward functions of operations only read the dense gradient, so for a
+// tensor computed by an operation, like a reshaped table, the row of the
+// dense gradient is returned instead. Panics if the row is out of range.
+func (t *Tensor[T]) RowGrad(row int) []T {
+	last := t.shape[len(t.shape)-1]
+	if row < 0 || row >= int(last) {
+		panic(fmt.Sprintf("row out of bounds %d for size %d", row, last))
+	}
+
+	rowSize := Size(t.shape[:len(t.shape)-1])
+	if len(t.parents) > 0 {
+		return t.Grad()[row*rowSize : (row+1)*rowSize]
+	}
+
+	if t.sparse == nil {
+		t.sparse = &SparseGrad[T]{rowSize: rowSize, values: make(map[int][]T)}
+	}
+	values, ok := t.sparse.values[row]
+	if !ok {
+		values = make([]T, t.sparse.rowSize)
+		t.sparse.values[row] = values
+		t.sparse.rows = append(t.sparse.rows, row)
+	}
+	return values
+}
+
+// HasGrad returns true if the dense gradient of the tensor has been
+// allocated, which happens the first time Grad is called.
+func (t *Tensor[T]) HasGrad() bool {
+	return t.grad != nil
+}
@@ -8,26 +8,67 @@ import (
 	"github.com/blast-go/blast/constraints"
 )
 
-type BackwardFunc[T constraints.Number] func(*Tensor[T])
-type ForwardFunc[T constraints.Number] func() []T
+type BackwardFunc[T constraints.Storage] func(*Tensor[T])
+type ForwardFunc[T constraints.Storage] func() []T
 
-// Tensor is the basic type that stores values over N dimensions.
-type Tensor[T constraints.Number] struct {
+// Tensor is the basic type that stores values over N dimensions, the values
+// can be numbers, complex numbers or half precision numbers, which are only
+// stored and converted.
+type Tensor[T constraints.Storage] struct {
 	shape    Shape
 	elements []T
 	grad     []T
-	parents  []*Tensor[T]
+	sparse   *SparseGrad[T]
+	parents  []Node
 	forward  ForwardFunc[T]
 	backward BackwardFunc[T]
 }
 
+// Node is a tensor of any element type as part of a computation graph, so
+// operations can have parents with an element type different from their own.
+type Node interface {
+	nodeParents() []Node
+	runBackward()
+	clearGrad()
+}
+
 // Type to describe the shape of a tensor.
 type Shape = []uint
 
+// SameShape returns true if the two shapes have the same dimensions.
+func SameShape(s1, s2 Shape) bool {
+	if len(s1) != len(s2) {
+		return false
+	}
+	for i := range s1 {
+		if s1[i] != s2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Size returns the number of elements of a tensor with the given shape.
+func Size(shape Shape) int {
+	s := 1
+	for _, d := range shape {
+		s *= int(d)
+	}
+	return s
+}
+
+// CopyShape returns a copy of the shape, so the result of an operation
+// doesn't share the shape of its input.
+func CopyShape(shape Shape) Shape {
+	s := make(Shape, len(shape))
+	copy(s, shape)
+	return s
+}
+
 // New returns a new Tensor of the shape, numeric type and the elements are
 // specified by the caller. Panics if the number of elements provided
 // does not match the number of elements corresponding to its shape.
-func New[T constraints.Number](shape Shape, elements []T) *Tensor[T] {
+func New[T constraints.Storage](shape Shape, elements []T) *Tensor[T] {
 	size := 1
 	for i, d := range shape {
 		if d == 0 {
@@ -46,7 +87,7 @@ func New[T constraints.Number](shape Shape, elements []T) *Tensor[T] {
 // Empty returns a new Tensor of the given shape specified by the caller but
 // doesn't allocate memory for the elements. If any dimension is set to zero
 // the function will panic.
-func Empty[T constraints.Number](shape Shape) *Tensor[T] {
+func Empty[T constraints.Storage](shape Shape) *Tensor[T] {
 	for i, d := range shape {
 		if d == 0 {
 			panic(fmt.Sprintf("dimension %d can't be zero", i))
@@ -58,7 +99,7 @@ func Empty[T constraints.Number](shape Shape) *Tensor[T] {
 // Zeros returns a new Tensor of the shape and numeric type specified by the
 // caller in which all its elements are set to zero. If any dimension is set
 // to zero the function will panic.
-func Zeros[T constraints.Number](shape Shape) *Tensor[T] {
+func Zeros[T constraints.Storage](shape Shape) *Tensor[T] {
 	size := 1
 	for i, d := range shape {
 		if d == 0 {
@@ -73,7 +114,7 @@ func Zeros[T constraints.Number](shape Shape) *Tensor[T] {
 // Ones returns a new Tensor of the shape and numeric type specified by the
 // caller in which all its elements are set to one. If any dimension is set
 // to zero the function will panic.
-func Ones[T constraints.Number](shape Shape) *Tensor[T] {
+func Ones[T constraints.Element](shape Shape) *Tensor[T] {
 	t := Zeros[T](shape)
 
 	one := T(1)
@@ -100,7 +141,32 @@ func Rand[T constraints.Number](shape Shape) *Tensor[T] {
 	return t
 }
 
-func Op[T constraints.Number](shape Shape, parents []*Tensor[T], forward ForwardFunc[T], backward BackwardFunc[T]) *Tensor[T] {
+// RandComplex returns a new Tensor of complex numbers of the shape specified
+// by the caller whose real and imaginary parts are random numbers like the
+// ones of Rand for floating point types. If any dimension is set to zero the
+// function will panic.
+func RandComplex[T constraints.Complex](shape Shape) *Tensor[T] {
+	t := Zeros[T](shape)
+
+	for i := 0; i < len(t.elements); i++ {
+		t.elements[i] = T(complex(rand.NormFloat64(), rand.NormFloat64()))
+	}
+
+	return t
+}
+
+func Op[T constraints.Storage](shape Shape, parents []*Tensor[T], forward ForwardFunc[T], backward BackwardFunc[T]) *Tensor[T] {
+	nodes := make([]Node, len(parents))
+	for i, p := range parents {
+		nodes[i] = p
+	}
+	return MixedOp(shape, nodes, forward, backward)
+}
+
+// MixedOp is like Op for operations whose parents have other element types,
+// like conversions between element types. The backward function propagates
+// the gradients to the parents of every type.
+func MixedOp[T constraints.Storage](shape Shape, parents []Node, forward ForwardFunc[T], backward BackwardFunc[T]) *Tensor[T] {
 	return &Tensor[T]{shape: shape, parents: parents, forward: forward, backward: backward}
 }
 
@@ -154,26 +220,37 @@ func (t *Tensor[T]) String() string {
 // Get returns a single element located at the coordinates provided by the
 // caller. Panics if the coordinates are out of bounds.
 func (t *Tensor[T]) Get(cords ...uint) T {
+	return t.Elements()[t.offset(cords)]
+}
+
+// Set replaces the element located at the coordinates provided by the caller.
+// Panics if the coordinates are out of bounds.
+func (t *Tensor[T]) Set(value T, cords ...uint) {
+	t.Elements()[t.offset(cords)] = value
+}
+
+// offset returns the position in memory of the element located at cords.
+func (t *Tensor[T]) offset(cords []uint) uint {
 	if len(cords) != len(t.shape) {
-		panic("coordinates out of not match the shape of the tensor")
+		panic("coordinates do not match the shape of the tensor")
 	}
 
 	offset := uint(0)
-	prevSize := uint(1)
+	stride := uint(1)
 	for i, size := range t.shape {
 		cord := cords[i]
-		if cord > size {
+		if cord >= size {
 			panic(fmt.Sprintf("index out of bounds %d for size %d", cord, size))
 		}
-		offset += cord * prevSize
-		prevSize = size
+		offset += cord * stride
+		stride *= size
 	}
-	return t.Elements()[offset]
+	return offset
 }
 
 // Returns true if the two tensors have the same shape and elements, returns
 // false otherwise.
-func Equal[T constraints.Number](t1, t2 *Tensor[T]) bool {
+func Equal[T constraints.Storage](t1, t2 *Tensor[T]) bool {
 	if !EqualShape(t1, t2) {
 		return false
 	}
@@ -192,60 +269,95 @@ func Equal[T constraints.Number](t1, t2 *Tensor[T]) bool {
 // Backward performs a back propagation pass for the entire computation graph.
 // This should be called on the output of node of a graph.
 func (t *Tensor[T]) Backward() {
-	visited := make(map[*Tensor[T]]struct{})
 	grad := t.Grad()
+	one := one[T]()
 	for i := 0; i < len(grad); i++ {
-		grad[i] = 1
+		grad[i] = one
+	}
+
+	// a tensor used by several operations must receive the gradients of all
+	// of them before propagating its own, so tensors are visited in reverse
+	// topological order
+	visited := make(map[Node]struct{})
+	var order []Node
+	topologicalSort(t, visited, &order)
+	for i := len(order) - 1; i >= 0; i-- {
+		order[i].runBackward()
 	}
-	applyBackward(t, visited)
 }
 
-func applyBackward[T constraints.Number](t *Tensor[T], visited map[*Tensor[T]]struct{}) {
-	if _, ok := visited[t]; !ok {
-		visited[t] = struct{}{}
-		if t.backward != nil {
-			t.backward(t)
-		}
-		for _, p := range t.parents {
-			applyBackward(p, visited)
-		}
+// topologicalSort appends to order every tensor of the graph after its
+// parents.
+func topologicalSort(t Node, visited map[Node]struct{}, order *[]Node) {
+	if _, ok := visited[t]; ok {
+		return
+	}
+	visited[t] = struct{}{}
+	for _, p := range t.nodeParents() {
+		topologicalSort(p, visited, order)
 	}
+	*order = append(*order, t)
 }
 
-// Returns true if the two tensors have the same shape, returns false otherwise.
-func EqualShape[T constraints.Number](t1, t2 *Tensor[T]) bool {
-	t1Shape := t1.Shape()
-	t2Shape := t2.Shape()
-	if len(t1Shape) != len(t2Shape) {
-		return false
+func (t *Tensor[T]) nodeParents() []Node {
+	return t.parents
+}
+
+func (t *Tensor[T]) runBackward() {
+	if t.backward != nil {
+		t.backward(t)
 	}
+}
 
-	for i := 0; i < len(t1Shape); i++ {
-		if t1Shape[i] != t2Shape[i] {
-			return false
-		}
+func (t *Tensor[T]) clearGrad() {
+	var zero T
+	for i := 0; i < len(t.grad); i++ {
+		t.grad[i] = zero
 	}
-	return true
+	t.sparse = nil
+}
+
+// Returns true if the two tensors have the same shape, returns false otherwise.
+func EqualShape[T constraints.Storage](t1, t2 *Tensor[T]) bool {
+	return SameShape(t1.Shape(), t2.Shape())
 }
 
 func (t *Tensor[T]) ZeroGrad() {
-	visited := make(map[*Tensor[T]]struct{})
+	visited := make(map[Node]struct{})
 	applyZeroGrad(t, visited)
 }
 
-func applyZeroGrad[T constraints.Number](t *Tensor[T], visited map[*Tensor[T]]struct{}) {
+func applyZeroGrad(t Node, visited map[Node]struct{}) {
 	if _, ok := visited[t]; !ok {
 		visited[t] = struct{}{}
-		for i := 0; i < len(t.grad); i++ {
-			t.grad[i] = 0
-		}
+		t.clearGrad()
 
-		for _, p := range t.parents {
+		for _, p := range t.nodeParents() {
 			applyZeroGrad(p, visited)
 		}
 	}
 }
 
+// one returns the number one of type T. Panics for the half precision types,
+// whose tensors are stored and converted but not computed on.
+func one[T constraints.Storage]() T {
+	var one T
+	v := reflect.ValueOf(&one).Elem()
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1)
+	case reflect.Complex64, reflect.Complex128:
+		v.SetComplex(1)
+	default:
+		panic(fmt.Sprintf("tensor of %T can't be differentiated", one))
+	}
+	return one
+}
+
 func randFuncFor[T constraints.Number](zero T) func() T {
 	switch reflect.ValueOf(zero).Kind() {
 	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint:
@@ -1,8 +1,10 @@
 package tensor_test
 
 import (
+	"fmt"
 	"testing"
 
+	"github.com/blast-go/blast/half"
 	"github.com/blast-go/blast/tensor"
 )
 
@@ -57,6 +59,37 @@ func TestRandomTensor(t *testing.T) {
 	}
 }
 
+func TestComplexTensor(t *testing.T) {
+	t1 := tensor.RandComplex[complex64]([]uint{10})
+
+	for _, e := range t1.Elements() {
+		if real(e) == 0 || imag(e) == 0 {
+			t.Errorf("%s: real and imaginary parts are not random %s", t.Name(), t1)
+			break
+		}
+	}
+
+	t2 := tensor.Ones[complex128]([]uint{2})
+	t2.Set(1+2i, 1)
+	if expected := "[(1+0i) (1+2i)]"; t2.String() != expected {
+		t.Errorf("%s: expected=%s actual=%s", t.Name(), expected, t2.String())
+	}
+}
+
+func TestHalfTensor(t *testing.T) {
+	t1 := tensor.New(tensor.Shape{2}, []half.BFloat16{half.NewBFloat16(1), half.NewBFloat16(-0.5)})
+	if expected := "[1 -0.5]"; t1.String() != expected {
+		t.Errorf("%s: expected=%s actual=%s", t.Name(), expected, t1.String())
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("%s: should have failed due to backward on half precision numbers", t.Name())
+		}
+	}()
+	t1.Backward()
+}
+
 func TestTensorToString(t *testing.T) {
 	t1 := tensor.New([]uint{3, 2}, []uint8{1, 2, 3, 4, 5, 6})
 
@@ -106,6 +139,37 @@ func TestBackward(t *testing.T) {
 
 }
 
+func TestBackwardSharedNode(t *testing.T) {
+	// t1 -> t2 -> (t3, t4) -> t5, t2 must receive the gradients of t3 and t4
+	// before propagating to t1
+	scale := func(parent *tensor.Tensor[float32], factor float32) *tensor.Tensor[float32] {
+		return tensor.Op(parent.Shape(), []*tensor.Tensor[float32]{parent}, nil, func(tout *tensor.Tensor[float32]) {
+			pGrad := parent.Grad()
+			for i, g := range tout.Grad() {
+				pGrad[i] += g * factor
+			}
+		})
+	}
+
+	t1 := tensor.Zeros[float32](tensor.Shape{2})
+	t2 := scale(t1, 2)
+	t3 := scale(t2, 3)
+	t4 := scale(t2, 5)
+	t5 := tensor.Op(tensor.Shape{2}, []*tensor.Tensor[float32]{t3, t4}, nil, func(tout *tensor.Tensor[float32]) {
+		for i, g := range tout.Grad() {
+			t3.Grad()[i] += g
+			t4.Grad()[i] += g
+		}
+	})
+	t5.Backward()
+
+	for _, g := range t1.Grad() {
+		if g != 16 {
+			t.Errorf("%s: gradient failed expected=16 got=%f", t.Name(), g)
+		}
+	}
+}
+
 func TestEqualShape(t *testing.T) {
 	t1 := tensor.Empty[uint16](tensor.Shape{2, 20})
 	t2 := tensor.Empty[uint16](tensor.Shape{2, 20})
@@ -121,6 +185,22 @@ func TestEqualShape(t *testing.T) {
 
 }
 
+func TestShapeHelpers(t *testing.T) {
+	shape := tensor.Shape{2, 3, 4}
+	if !tensor.SameShape(shape, tensor.Shape{2, 3, 4}) || tensor.SameShape(shape, tensor.Shape{2, 3}) {
+		t.Errorf("%s: SameShape failed", t.Name())
+	}
+	if size := tensor.Size(shape); size != 24 {
+		t.Errorf("%s: Size expected=24 got=%d", t.Name(), size)
+	}
+
+	copied := tensor.CopyShape(shape)
+	copied[0] = 5
+	if shape[0] != 2 {
+		t.Errorf("%s: CopyShape shares the shape", t.Name())
+	}
+}
+
 func TestEqual(t *testing.T) {
 	t1 := tensor.New(tensor.Shape{2, 3}, []uint16{1, 2, 3, 4, 5, 6})
 	t2 := tensor.New(tensor.Shape{2, 3}, []uint16{1, 2, 3, 4, 5, 6})
@@ -140,3 +220,77 @@ func TestEqual(t *testing.T) {
 	}
 
 }
+
+func TestGetSet(t *testing.T) {
+	t1 := tensor.New(tensor.Shape{3, 2, 2}, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12})
+
+	if e := t1.Get(2, 1, 1); e != 12 {
+		t.Errorf("%s: expected=12 got=%d", t.Name(), e)
+	}
+	if e := t1.Get(1, 0, 1); e != 8 {
+		t.Errorf("%s: expected=8 got=%d", t.Name(), e)
+	}
+
+	t1.Set(-1, 0, 1, 0)
+	if e := t1.Elements()[3]; e != -1 {
+		t.Errorf("%s: expected=-1 got=%d", t.Name(), e)
+	}
+}
+
+func TestGetOutOfBounds(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("%s: should have failed due to index out of bounds", t.Name())
+		}
+	}()
+	tensor.Zeros[int](tensor.Shape{3, 2}).Get(3, 0)
+}
+
+func TestRange(t *testing.T) {
+	cases := []struct {
+		r        tensor.Range
+		expected []int
+	}{
+		{tensor.All(), []int{0, 1, 2, 3, 4}},
+		{tensor.Range{Start: 1, Stop: 3}, []int{1, 2}},
+		{tensor.Range{Start: 0, Stop: 5, Step: 2}, []int{0, 2, 4}},
+		{tensor.Range{Start: -2, Stop: 100}, []int{3, 4}},
+		{tensor.Range{Start: 4, Stop: -6, Step: -2}, []int{4, 2, 0}},
+		{tensor.Range{Start: 3, Stop: 1}, nil},
+	}
+
+	for _, c := range cases {
+		actual := c.r.Indices(5)
+		if fmt.Sprint(actual) != fmt.Sprint(c.expected) {
+			t.Errorf("%s: range %v expected=%v got=%v", t.Name(), c.r, c.expected, actual)
+		}
+	}
+}
+
+func TestRowGrad(t *testing.T) {
+	// 3 rows of 2 elements
+	t1 := tensor.Zeros[float32](tensor.Shape{2, 3})
+	if t1.SparseGrad() != nil {
+		t.Fatalf("%s: sparse gradient should be nil", t.Name())
+	}
+
+	t1.RowGrad(2)[1] += 1
+	t1.RowGrad(0)[0] += 2
+	t1.RowGrad(2)[1] += 3
+
+	sparse := t1.SparseGrad()
+	if sparse.RowSize() != 2 || len(sparse.Rows()) != 2 || sparse.Rows()[0] != 2 || sparse.Rows()[1] != 0 {
+		t.Errorf("%s: invalid rows size=%d rows=%v", t.Name(), sparse.RowSize(), sparse.Rows())
+	}
+	if sparse.Row(2)[1] != 4 || sparse.Row(0)[0] != 2 || sparse.Row(1) != nil {
+		t.Errorf("%s: invalid values %v %v", t.Name(), sparse.Row(0), sparse.Row(2))
+	}
+	if t1.HasGrad() {
+		t.Errorf("%s: dense gradient should not be allocated", t.Name())
+	}
+
+	t1.ZeroGrad()
+	if t1.SparseGrad() != nil {
+		t.Errorf("%s: sparse gradient should be cleared", t.Name())
+	}
+}